@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/session"
+	"github.com/gotd/td/telegram/dcs"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"golang.org/x/net/proxy"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/diag"
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/sessions"
+	"telegram-auto-checkin/pkg/scheduler"
+)
+
+// clockSkewFail is the clock skew at which MTProto rejects requests
+const clockSkewFail = 5 * time.Minute
+
+// doctor prints check results and remembers whether any check failed
+type doctor struct {
+	failed bool
+}
+
+func (d *doctor) ok(check, format string, args ...any) {
+	fmt.Printf("[ OK ] %s: %s\n", check, fmt.Sprintf(format, args...))
+}
+
+func (d *doctor) warn(check, detail, fix string) {
+	fmt.Printf("[WARN] %s: %s\n       fix: %s\n", check, detail, fix)
+}
+
+func (d *doctor) fail(check, detail, fix string) {
+	d.failed = true
+	fmt.Printf("[FAIL] %s: %s\n       fix: %s\n", check, detail, fix)
+}
+
+// runDoctorCommand checks the network, clock and sessions needed to reach Telegram and returns the exit code
+func runDoctorCommand(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	cfgPath := fs.String("config", "config.yaml", "Path to main config file")
+	account := fs.String("account", "", "Only check the session of this account (name or phone)")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout of every network check")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	d := &doctor{}
+	cfg, err := config.LoadConfig(*cfgPath, viper.New())
+	if err != nil {
+		d.fail("config", err.Error(), "fix the configuration file, or generate one with the init command")
+		return exitError
+	}
+	d.ok("config", "%s loaded, %d account(s)", *cfgPath, len(cfg.Accounts))
+
+	ctx := context.Background()
+	dial := d.checkProxy(ctx, cfg.Proxy, *timeout)
+	for _, addr := range cfg.ProxyFallbacks {
+		d.checkFallbackProxy(ctx, addr, *timeout)
+	}
+	d.checkDNS(ctx, cfg.Proxy != "", *timeout)
+	d.checkDCs(ctx, cfg.UseTestDC, dial, *timeout)
+	d.checkClock(ctx, cfg.Proxy, *timeout)
+	for _, acc := range cfg.Accounts {
+		if *account != "" && acc.Key() != *account && acc.Phone != *account {
+			continue
+		}
+		d.checkSession(ctx, cfg, acc, *timeout)
+	}
+
+	if d.failed {
+		return exitError
+	}
+	return exitOK
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// checkProxy checks that the SOCKS5 proxy accepts connections and returns the dialer used by the other checks
+func (d *doctor) checkProxy(ctx context.Context, addr string, timeout time.Duration) dialFunc {
+	direct := &net.Dialer{Timeout: timeout}
+	if addr == "" {
+		return direct.DialContext
+	}
+	if strings.Contains(addr, "://") {
+		d.fail("proxy", fmt.Sprintf("%q is not a host:port address", addr), "write proxy as host:port, e.g. 127.0.0.1:1080, a SOCKS5 proxy is assumed")
+		return direct.DialContext
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := direct.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		d.fail("proxy", err.Error(), "start the SOCKS5 proxy at "+addr+" or correct the proxy setting")
+		return direct.DialContext
+	}
+	conn.Close()
+	d.ok("proxy", "%s accepts connections", addr)
+
+	dialer, err := proxy.SOCKS5("tcp", addr, nil, direct)
+	if err != nil {
+		d.fail("proxy", err.Error(), "correct the proxy setting")
+		return direct.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if cd, ok := dialer.(proxy.ContextDialer); ok {
+			return cd.DialContext(ctx, network, addr)
+		}
+		return dialer.Dial(network, addr)
+	}
+}
+
+// checkFallbackProxy checks that a fallback proxy accepts connections, the primary proxy
+// carries the connections so a dead fallback only warns
+func (d *doctor) checkFallbackProxy(ctx context.Context, addr string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		d.warn("proxy fallback", err.Error(), "start the SOCKS5 proxy at "+addr+" or remove it from proxy_fallbacks")
+		return
+	}
+	conn.Close()
+	d.ok("proxy fallback", "%s accepts connections", addr)
+}
+
+// checkDNS resolves a Telegram host, behind a proxy the proxy resolves names so failures only warn
+func (d *doctor) checkDNS(ctx context.Context, viaProxy bool, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, "telegram.org")
+	switch {
+	case err == nil:
+		d.ok("dns", "telegram.org resolves to %s", strings.Join(addrs, ", "))
+	case viaProxy:
+		d.warn("dns", err.Error(), "only notifications and web requests made without the proxy are affected, check /etc/resolv.conf if they fail")
+	default:
+		d.fail("dns", err.Error(), "check the DNS servers in /etc/resolv.conf (or the container's DNS), or configure a proxy")
+	}
+}
+
+// checkDCs opens a TCP connection to every Telegram data center
+func (d *doctor) checkDCs(ctx context.Context, testDC bool, dial dialFunc, timeout time.Duration) {
+	list := dcs.Prod()
+	if testDC {
+		list = dcs.Test()
+	}
+	seen := make(map[int]bool)
+	for _, opt := range list.Options {
+		if opt.Ipv6 || opt.MediaOnly || opt.CDN || seen[opt.ID] {
+			continue
+		}
+		seen[opt.ID] = true
+
+		check := "dc" + strconv.Itoa(opt.ID)
+		addr := net.JoinHostPort(opt.IPAddress, strconv.Itoa(opt.Port))
+		dialCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		conn, err := dial(dialCtx, "tcp", addr)
+		cancel()
+		if err != nil {
+			d.fail(check, fmt.Sprintf("%s unreachable: %v", addr, err), "Telegram may be blocked on this network, configure a proxy or check the firewall")
+			continue
+		}
+		conn.Close()
+		d.ok(check, "%s reachable in %s", addr, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// checkClock compares the local clock with telegram.org
+func (d *doctor) checkClock(ctx context.Context, proxyAddr string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	fix := "synchronize the system clock, e.g. timedatectl set-ntp true, or sync the host clock of the container; clock_correction: true compensates meanwhile"
+	skew, err := diag.ClockSkew(ctx, proxyAddr)
+	if err != nil {
+		d.warn("clock", "could not fetch the server time: "+err.Error(), fix)
+		return
+	}
+
+	detail := fmt.Sprintf("local clock is off by %s", skew)
+	switch {
+	case skew.Abs() >= clockSkewFail:
+		d.fail("clock", detail+", Telegram will reject requests", fix)
+	case skew.Abs() >= diag.ClockSkewWarn:
+		d.warn("clock", detail, fix)
+	default:
+		d.ok("clock", "in sync with Telegram (off by %s)", skew)
+	}
+}
+
+// checkSession checks that the session file of an account exists and is still logged in
+func (d *doctor) checkSession(ctx context.Context, cfg *config.Config, acc config.AccountConfig, timeout time.Duration) {
+	check := "session " + acc.Key()
+	path := sessions.Path(acc.SessionName())
+	loginFix := "log in once with the daemon in a terminal, or import a session with the session import command"
+
+	data, err := sessions.Load(ctx, path)
+	switch {
+	case errors.Is(err, session.ErrNotFound):
+		d.fail(check, path+" does not exist", loginFix)
+		return
+	case err != nil:
+		d.fail(check, fmt.Sprintf("%s is unreadable: %v", path, err), "restore the session file from a backup or log in again")
+		return
+	case len(data.AuthKey) == 0:
+		d.fail(check, path+" is not logged in", loginFix)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*timeout)
+	defer cancel()
+	err = scheduler.CheckSession(ctx, cfg, acc, zerolog.Nop())
+	switch {
+	case err == nil:
+		d.ok(check, "logged in on DC %d", data.DC)
+	case errors.Is(errs.Classify(err), errs.ErrAuthRequired):
+		d.fail(check, "the session was revoked or expired", loginFix)
+	default:
+		d.fail(check, err.Error(), "fix the failed network checks above, then run doctor again")
+	}
+}
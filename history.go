@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/history"
+)
+
+const historyUsage = `Usage: telegram-auto-checkin history <command> [options]
+
+Commands:
+  export    Write the run history as CSV or JSON, e.g. for spreadsheets
+`
+
+// runHistoryCommand dispatches "history" subcommands and returns the exit code
+func runHistoryCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, historyUsage)
+		return exitError
+	}
+
+	var err error
+	switch args[0] {
+	case "export":
+		err = runHistoryExport(args[1:])
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stdout, historyUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown history command %q\n\n%s", args[0], historyUsage)
+		return exitError
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func runHistoryExport(args []string) error {
+	fs := flag.NewFlagSet("history export", flag.ContinueOnError)
+	format := fs.String("format", "csv", "Output format: csv|json")
+	since := fs.String("since", "", "Only runs since this date (2006-01-02) or this long ago (e.g. 7d, 12h)")
+	account := fs.String("account", "", "Only runs of this account")
+	task := fs.String("task", "", "Only runs of this task")
+	failed := fs.Bool("failed", false, "Only failed runs")
+	cfgPath := fs.String("config", "config.yaml", "Path to main config file, used to find history_file")
+	file := fs.String("file", "", "History file to read instead of the configured one")
+	out := fs.String("out", "", "Output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q, expected csv or json", *format)
+	}
+
+	var from time.Time
+	if *since != "" {
+		t, err := parseSince(*since, time.Now())
+		if err != nil {
+			return err
+		}
+		from = t
+	}
+
+	path := *file
+	if path == "" {
+		cfg, err := config.LoadConfig(*cfgPath, viper.New())
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		path = cfg.HistoryFile
+	}
+	store, err := history.Open(path)
+	if err != nil {
+		return err
+	}
+	records, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Path(), err)
+	}
+	records = slices.DeleteFunc(records, func(r history.Record) bool {
+		return r.Time.Before(from) ||
+			(*account != "" && r.Account != *account) ||
+			(*task != "" && r.Task != *task) ||
+			(*failed && r.Success)
+	})
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if records == nil {
+			records = []history.Record{}
+		}
+		return enc.Encode(records)
+	}
+	return writeHistoryCSV(w, records)
+}
+
+// parseSince parses a date, a date and time, or a duration back from now where d counts days
+func parseSince(value string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, expected a date like 2006-01-02 or a duration like 7d", value)
+}
+
+// writeHistoryCSV writes one row per run, values extracted from replies get a column each
+func writeHistoryCSV(w io.Writer, records []history.Record) error {
+	var keys []string
+	for _, r := range records {
+		for k := range r.Extracted {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.Sort(keys)
+
+	cw := csv.NewWriter(w)
+	header := []string{"time", "account", "task", "target", "method", "trigger", "request_id", "success", "error_class", "error", "duration_ms", "reply", "artifacts"}
+	for _, k := range keys {
+		header = append(header, "extracted."+k)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			r.Time.Format(time.RFC3339),
+			r.Account,
+			r.Task,
+			r.Target,
+			r.Method,
+			r.Trigger,
+			r.RequestID,
+			strconv.FormatBool(r.Success),
+			r.ErrorClass,
+			r.Error,
+			strconv.FormatInt(r.DurationMs, 10),
+			r.Reply,
+			strings.Join(r.Artifacts, ";"),
+		}
+		for _, k := range keys {
+			value := ""
+			if v, ok := r.Extracted[k]; ok {
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			row = append(row, value)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/logger"
+	"telegram-auto-checkin/pkg/client"
+)
+
+var (
+	appHashPattern = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	phonePattern   = regexp.MustCompile(`^\+[0-9]{7,15}$`)
+)
+
+// initAnswers holds the values collected by the init wizard
+type initAnswers struct {
+	AppID    int
+	AppHash  string
+	Phone    string
+	Password string
+	Proxy    string
+	Name     string
+	Target   string
+	Method   string
+	Payload  string
+	Schedule string
+}
+
+// initTemplate is a minimal, commented config; see the bundled config.yaml for every option
+var initTemplate = template.Must(template.New("config").Funcs(template.FuncMap{"q": strconv.Quote}).Parse(`# Telegram Auto Check-in Configuration File
+# Generated by "telegram-auto-checkin init", see config.yaml in the repository for all options
+
+# Language setting: en | zh
+language: "en"
+
+# Optional, SOCKS5 proxy address, e.g. "127.0.0.1:1080"
+proxy: {{q .Proxy}}
+
+# App credentials from https://my.telegram.org/apps
+# Secrets may also reference the environment or a file: "env:VAR_NAME" | "file:/path"
+app_id: {{.AppID}}
+app_hash: {{q .AppHash}}
+
+log:
+  dir: "./log"
+  level: "info"
+  format: "text"
+
+accounts:
+  - name: {{q .Name}}
+    # Leave phone empty to log in by QR code
+    phone: {{q .Phone}}
+    # Two-factor authentication password, leave empty if not enabled
+    password: {{q .Password}}
+    tasks:
+      - name: "daily_checkin"
+        target: {{q .Target}} # Username (starting with @) or user ID
+        enabled: true
+        method: {{q .Method}} # message | button | reaction | vote | dice | forward
+        payload: {{q .Payload}}
+        schedule: {{q .Schedule}} # Cron expression
+        run_on_start: false
+`))
+
+func runInitCommand(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	out := fs.String("config", "config.yaml", "Config file to write")
+	force := fs.Bool("force", false, "Overwrite an existing config file")
+	noLogin := fs.Bool("no-login", false, "Only write the config, skip the first login")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if err := runInit(*out, *force, *noLogin); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func runInit(path string, force bool, noLogin bool) error {
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite", path)
+		}
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	var ans initAnswers
+	var err error
+
+	fmt.Println("Get your app credentials from https://my.telegram.org/apps")
+	if ans.AppID, err = askInt(in, "App ID", func(v int) error {
+		if v <= 0 {
+			return errors.New("must be a positive number")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if ans.AppHash, err = ask(in, "App hash", "", func(v string) error {
+		if !appHashPattern.MatchString(v) && !strings.HasPrefix(v, "env:") && !strings.HasPrefix(v, "file:") {
+			return errors.New("expected 32 hexadecimal characters")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if ans.Phone, err = ask(in, "Phone number in international format, empty for QR login", "", func(v string) error {
+		if v != "" && !phonePattern.MatchString(v) {
+			return errors.New("expected a number like +1234567890")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if ans.Phone != "" {
+		if ans.Password, err = ask(in, "Two-factor password, empty if not enabled", "", nil); err != nil {
+			return err
+		}
+	}
+	if ans.Proxy, err = ask(in, "SOCKS5 proxy host:port, empty for none", "", func(v string) error {
+		if v == "" {
+			return nil
+		}
+		_, port, err := net.SplitHostPort(v)
+		if err != nil {
+			return errors.New("expected host:port")
+		}
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return errors.New("invalid port")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if ans.Name, err = ask(in, "Account name", "main", nil); err != nil {
+		return err
+	}
+
+	fmt.Println("First task:")
+	if ans.Target, err = ask(in, "Target chat (@username or user ID)", "", func(v string) error {
+		if v == "" {
+			return errors.New("target is required")
+		}
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil && !strings.HasPrefix(v, "@") {
+			return errors.New("expected @username or a numeric ID")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if ans.Method, err = ask(in, "Method (message|button|reaction|vote|dice|forward)", "message", func(v string) error {
+		switch v {
+		case "message", "button", "reaction", "vote", "dice", "forward":
+			return nil
+		}
+		return errors.New("unknown method")
+	}); err != nil {
+		return err
+	}
+	payloadDefault := ""
+	if ans.Method == "message" {
+		payloadDefault = "/checkin"
+	}
+	if ans.Payload, err = ask(in, "Payload (message text, button text, emoji, poll option or forwarded message)", payloadDefault, nil); err != nil {
+		return err
+	}
+	if ans.Schedule, err = ask(in, "Schedule (cron expression)", "0 9 * * *", func(v string) error {
+		if _, err := cron.ParseStandard(v); err != nil {
+			return fmt.Errorf("invalid cron expression: %v", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := initTemplate.Execute(f, ans); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Config written to %s\n", path)
+
+	if noLogin {
+		return nil
+	}
+	return initLogin(path)
+}
+
+// initLogin performs the first login of the generated account, creating its session file
+func initLogin(cfgPath string) error {
+	cfg, err := config.LoadConfig(cfgPath, viper.New())
+	if err != nil {
+		return fmt.Errorf("failed to load generated config: %w", err)
+	}
+	if len(cfg.Accounts) == 0 {
+		return fmt.Errorf("no account found in %s", cfgPath)
+	}
+	acc := cfg.Accounts[0]
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log := logger.SetupLogger("info")
+	c, err := client.NewClient(cfg.AppID, cfg.AppHash, acc.SessionName()+".session", client.ConnectOptions{ProxyAddr: cfg.Proxy, Device: client.DeviceOptions(cfg.DeviceFor(acc)), LoginCode: client.LoginCodeOptions(cfg.LoginCodeFor(acc))}, log)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Logging in, follow the prompts...")
+	if err := c.Auth(ctx, acc.Phone, acc.Password); err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	fmt.Println("Login successful, start the tool with: telegram-auto-checkin --config " + cfgPath)
+	return nil
+}
+
+// ask prompts for a line until validate accepts it, returning def for empty input
+func ask(in *bufio.Reader, prompt string, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Printf("%s [%s]: ", prompt, def)
+		} else {
+			fmt.Printf("%s: ", prompt)
+		}
+		line, err := in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("input aborted")
+		}
+		value := strings.TrimSpace(line)
+		if value == "" {
+			value = def
+		}
+		if validate == nil {
+			return value, nil
+		}
+		if err := validate(value); err != nil {
+			fmt.Printf("  %v\n", err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+// askInt prompts for a number until validate accepts it
+func askInt(in *bufio.Reader, prompt string, validate func(int) error) (int, error) {
+	var n int
+	_, err := ask(in, prompt, "", func(v string) error {
+		var convErr error
+		if n, convErr = strconv.Atoi(v); convErr != nil {
+			return errors.New("expected a number")
+		}
+		return validate(n)
+	})
+	return n, err
+}
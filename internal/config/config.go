@@ -1,74 +1,349 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Accounts          []AccountConfig `yaml:"accounts" mapstructure:"accounts"`
-	Proxy             string          `yaml:"proxy" mapstructure:"proxy"`                             // socks5://127.0.0.1:1080
-	AppID             int             `yaml:"app_id" mapstructure:"app_id"`                           // Optional, account-level config takes priority
-	AppHash           string          `yaml:"app_hash" mapstructure:"app_hash"`                       // Optional, account-level config takes priority
-	ReplyWaitSeconds  int             `yaml:"reply_wait_seconds" mapstructure:"reply_wait_seconds"`   // Seconds to wait for bot reply, default: 3 seconds
-	ReplyHistoryLimit int             `yaml:"reply_history_limit" mapstructure:"reply_history_limit"` // Number of historical messages to fetch, default: 10
-	Log               LogConfig       `yaml:"log" mapstructure:"log"`                                 // Logging configuration
-	Language          string          `yaml:"language" mapstructure:"language"`                       // Language setting: en | zh, default: en
+	Accounts           []AccountConfig       `yaml:"accounts" mapstructure:"accounts"`
+	Include            []string              `yaml:"include" mapstructure:"include"`                         // Glob patterns of extra account files, e.g. accounts.d/*.yaml
+	TaskTemplates      map[string]TaskConfig `yaml:"task_templates" mapstructure:"task_templates"`           // Reusable tasks referenced by name from account tasks
+	Proxy              string                `yaml:"proxy" mapstructure:"proxy"`                             // socks5://127.0.0.1:1080
+	ProxyFallbacks     []string              `yaml:"proxy_fallbacks" mapstructure:"proxy_fallbacks"`         // SOCKS5 proxies Telegram connections fail over to, in order, when proxy fails
+	FallbackDirect     bool                  `yaml:"fallback_direct" mapstructure:"fallback_direct"`         // Connect to Telegram without a proxy when all proxies fail
+	UseTestDC          bool                  `yaml:"use_test_dc" mapstructure:"use_test_dc"`                 // Connect to Telegram's test data centers, for development only
+	ClockCorrection    bool                  `yaml:"clock_correction" mapstructure:"clock_correction"`       // Compensate a skewed local clock measured at startup, for hosts without NTP
+	NonInteractive     bool                  `yaml:"non_interactive" mapstructure:"non_interactive"`         // Fail instead of prompting when an account needs to log in, for containers without stdin
+	RemoteLogin        bool                  `yaml:"remote_login" mapstructure:"remote_login"`               // Daemon mode: request login codes via the notification channels and accept them via the control API
+	LoginCode          LoginCodeConfig       `yaml:"login_code" mapstructure:"login_code"`                   // Where the login code of a phone login is read from, default: stdin
+	AppID              int                   `yaml:"app_id" mapstructure:"app_id"`                           // Optional, account-level config takes priority
+	AppHash            string                `yaml:"app_hash" mapstructure:"app_hash"`                       // Optional, account-level config takes priority
+	ReplyWaitSeconds   int                   `yaml:"reply_wait_seconds" mapstructure:"reply_wait_seconds"`   // Seconds to wait for bot reply, default: 3 seconds; prefer defaults.reply_wait_seconds
+	ReplyHistoryLimit  int                   `yaml:"reply_history_limit" mapstructure:"reply_history_limit"` // Number of historical messages to fetch, default: 10; prefer defaults.reply_history_limit
+	Defaults           DefaultsConfig        `yaml:"defaults" mapstructure:"defaults"`                       // Worker, queue, reply and retry settings of accounts that do not set their own
+	Log                LogConfig             `yaml:"log" mapstructure:"log"`                                 // Logging configuration
+	Language           string                `yaml:"language" mapstructure:"language"`                       // Language setting: en | zh | ru | es | fa, default: detected from the OS locale
+	AccountConcurrency int                   `yaml:"account_concurrency" mapstructure:"account_concurrency"` // Accounts processed in parallel in --once mode, default: 1
+	MaxWorkers         int                   `yaml:"max_workers" mapstructure:"max_workers"`                 // Cap on tasks running at once across all accounts in --once mode, default: unlimited
+	ConnectionMode     string                `yaml:"connection_mode" mapstructure:"connection_mode"`         // Daemon mode sessions: persistent (default) | on_demand (connect per task run)
+	Network            NetworkConfig         `yaml:"network" mapstructure:"network"`                         // MTProto transport, keepalive and reconnection tuning for unstable networks
+	Presence           string                `yaml:"presence" mapstructure:"presence"`                       // Online status: auto (default) | offline (go offline after each task) | online (stay online)
+	Device             DeviceConfig          `yaml:"device" mapstructure:"device"`                           // Device info shown in Telegram's active sessions list
+	DefaultSchedule    string                `yaml:"default_schedule" mapstructure:"default_schedule"`       // Schedule of tasks without their own, unless the account sets one
+	StaggerSeconds     int                   `yaml:"stagger_seconds" mapstructure:"stagger_seconds"`         // Window over which accounts sharing a schedule are spread, default: 0 (all at once)
+	HistoryFile        string                `yaml:"history_file" mapstructure:"history_file"`               // Run history file (JSON lines), default: ./data/history.jsonl
+	ArtifactsDir       string                `yaml:"artifacts_dir" mapstructure:"artifacts_dir"`             // Directory for media saved from bot replies, default: ./data/artifacts
+	StateFile          string                `yaml:"state_file" mapstructure:"state_file"`                   // Runtime state such as enable/disable toggles, default: ./data/state.json
+	Control            ControlConfig         `yaml:"control" mapstructure:"control"`                         // HTTP control API
+	Diagnostics        DiagnosticsConfig     `yaml:"diagnostics" mapstructure:"diagnostics"`                 // pprof endpoint and periodic self-report for troubleshooting
+	Notifications      []NotifierConfig      `yaml:"notifications" mapstructure:"notifications"`             // Notification channels
+	Digest             DigestConfig          `yaml:"digest" mapstructure:"digest"`                           // Daily summary sent to the notification channels
+	Streaks            StreakConfig          `yaml:"streaks" mapstructure:"streaks"`                         // Alerts when a daily success streak is about to break
+	SendQuota          SendQuotaConfig       `yaml:"send_quota" mapstructure:"send_quota"`                   // Daily cap on the messages an account sends to one chat
+
+	remoteVersion string // ETag or content hash of a remote main config, set by LoadConfig
+}
+
+// ControlConfig configures the HTTP control API, disabled unless listen is set
+type ControlConfig struct {
+	Listen string `yaml:"listen" mapstructure:"listen"` // Listen address, e.g. 127.0.0.1:8080
+	Token  string `yaml:"token" mapstructure:"token"`   // Bearer token required on every request, required unless listen is a loopback address
+}
+
+// DiagnosticsConfig enables troubleshooting aids for a long-running daemon, all disabled by default
+type DiagnosticsConfig struct {
+	Listen        string `yaml:"listen" mapstructure:"listen"`                 // pprof and /debug/stats address, e.g. 127.0.0.1:6060; keep it local, it has no authentication
+	ReportSeconds int    `yaml:"report_seconds" mapstructure:"report_seconds"` // Interval of the self-report log (goroutines, heap, queues, open task logs), 0 disables
+}
+
+// NetworkConfig tunes the MTProto connection for unstable mobile or NAT networks, zero fields use the defaults
+type NetworkConfig struct {
+	Transport           string `yaml:"transport" mapstructure:"transport"`                         // intermediate (default) | abridged | padded_intermediate
+	KeepaliveSeconds    int    `yaml:"keepalive_seconds" mapstructure:"keepalive_seconds"`         // Interval of a request keeping idle sessions alive, 0 (default) relies on the ping every minute
+	DialTimeoutSeconds  int    `yaml:"dial_timeout_seconds" mapstructure:"dial_timeout_seconds"`   // Timeout of opening a connection, default: 35
+	ReconnectMaxSeconds int    `yaml:"reconnect_max_seconds" mapstructure:"reconnect_max_seconds"` // Longest wait between reconnection attempts, default: 60
+}
+
+// LoginCodeConfig configures where the login code of a phone login comes from
+type LoginCodeConfig struct {
+	Source  string   `yaml:"source" mapstructure:"source"`   // stdin (default) | file | env | command
+	Path    string   `yaml:"path" mapstructure:"path"`       // file: file or named pipe the code is written to
+	Env     string   `yaml:"env" mapstructure:"env"`         // env: variable holding the code, default: TG_LOGIN_CODE
+	Command string   `yaml:"command" mapstructure:"command"` // command: executable printing the code, gets CHECKIN_PHONE
+	Args    []string `yaml:"args" mapstructure:"args"`       // command: arguments
+	Timeout int      `yaml:"timeout" mapstructure:"timeout"` // Seconds to wait for a file or command, default: 300
+}
+
+// NotifierConfig is a notification channel
+type NotifierConfig struct {
+	Type     string            `yaml:"type" mapstructure:"type"`           // telegram | webhook | gotify | ntfy | serverchan | pushplus | wecom
+	BotToken string            `yaml:"bot_token" mapstructure:"bot_token"` // telegram: Bot API token
+	ChatID   string            `yaml:"chat_id" mapstructure:"chat_id"`     // telegram: chat to send to
+	URL      string            `yaml:"url" mapstructure:"url"`             // webhook: URL receiving JSON {title, text, priority}; gotify, ntfy: server URL; wecom: group bot webhook
+	Headers  map[string]string `yaml:"headers" mapstructure:"headers"`     // webhook: extra request headers
+	Token    string            `yaml:"token" mapstructure:"token"`         // gotify: application token; ntfy: access token of a protected topic; serverchan: SendKey; pushplus: token
+	Topic    string            `yaml:"topic" mapstructure:"topic"`         // ntfy: topic to publish to
+}
+
+// DigestConfig schedules the daily summary of the run history
+type DigestConfig struct {
+	Schedule string `yaml:"schedule" mapstructure:"schedule"` // Cron expression, e.g. "0 22 * * *", empty to disable
+}
+
+// StreakConfig sends alerts about the streaks of consecutive days on which a task succeeded
+type StreakConfig struct {
+	Deadline string `yaml:"deadline" mapstructure:"deadline"` // Local time, e.g. "21:00", after which a streak without a success today is at risk; empty to disable
+	MinDays  int    `yaml:"min_days" mapstructure:"min_days"` // Shortest streak worth an alert, default: 2
+}
+
+// DeadlineCron returns the cron expression firing daily at the deadline
+func (s StreakConfig) DeadlineCron() (string, error) {
+	t, err := time.Parse("15:04", s.Deadline)
+	if err != nil {
+		return "", fmt.Errorf("streaks.deadline: invalid time %q, expected HH:MM", s.Deadline)
+	}
+	return fmt.Sprintf("%d %d * * *", t.Minute(), t.Hour()), nil
 }
 
 type LogConfig struct {
-	Dir    string `yaml:"dir" mapstructure:"dir"`       // Log directory, default: ./log
-	Level  string `yaml:"level" mapstructure:"level"`   // Log level, default: info
-	Format string `yaml:"format" mapstructure:"format"` // Log format: text (console) or json, default: text
+	Dir     string            `yaml:"dir" mapstructure:"dir"`         // Log directory, default: ./log
+	Level   string            `yaml:"level" mapstructure:"level"`     // Log level, default: info
+	Format  string            `yaml:"format" mapstructure:"format"`   // Log format: text (console) or json, default: text
+	Outputs []LogOutputConfig `yaml:"outputs" mapstructure:"outputs"` // Destinations of the main log, default: console and app.log
+	MTProto bool              `yaml:"mtproto" mapstructure:"mtproto"` // Log gotd's MTProto internals (auth key, transport, RPC errors), for troubleshooting
+}
+
+// LogOutputConfig is a destination of the main log
+type LogOutputConfig struct {
+	Type    string            `yaml:"type" mapstructure:"type"`       // console | file (app.log) | syslog | journald | loki | http
+	Format  string            `yaml:"format" mapstructure:"format"`   // text | json, default: log.format
+	Level   string            `yaml:"level" mapstructure:"level"`     // Minimum level of this output, default: log.level
+	Address string            `yaml:"address" mapstructure:"address"` // syslog: remote server as udp://host:514 or tcp://host:514, default: the local daemon
+	Tag     string            `yaml:"tag" mapstructure:"tag"`         // syslog and journald: program identifier, loki: job label, default: telegram-auto-checkin
+	URL     string            `yaml:"url" mapstructure:"url"`         // loki: Loki base URL; http: collector receiving JSON arrays of log lines
+	Headers map[string]string `yaml:"headers" mapstructure:"headers"` // loki and http: extra request headers, e.g. Authorization or X-Scope-OrgID
 }
 
 type AccountConfig struct {
-	Name              string       `yaml:"name" mapstructure:"name"`
-	Phone             string       `yaml:"phone" mapstructure:"phone"`
-	Password          string       `yaml:"password" mapstructure:"password"` // Two-factor authentication password
-	AppID             int          `yaml:"app_id" mapstructure:"app_id"`
-	AppHash           string       `yaml:"app_hash" mapstructure:"app_hash"`
-	WorkerCount       int          `yaml:"worker_count" mapstructure:"worker_count"`               // Number of concurrent workers, default: 4
-	TaskQueueSize     int          `yaml:"task_queue_size" mapstructure:"task_queue_size"`         // Task queue size, default: 100
-	ReplyWaitSeconds  int          `yaml:"reply_wait_seconds" mapstructure:"reply_wait_seconds"`   // Seconds to wait for bot reply
-	ReplyHistoryLimit int          `yaml:"reply_history_limit" mapstructure:"reply_history_limit"` // Number of historical messages to fetch
-	Tasks             []TaskConfig `yaml:"tasks" mapstructure:"tasks"`
+	Name                  string          `yaml:"name" mapstructure:"name"`
+	Enabled               *bool           `yaml:"enabled" mapstructure:"enabled"` // Enabled by default, false switches off all tasks of the account
+	Tags                  []string        `yaml:"tags" mapstructure:"tags"`       // Groups for --tag filtering, apply to all tasks of the account
+	Phone                 string          `yaml:"phone" mapstructure:"phone"`
+	Password              string          `yaml:"password" mapstructure:"password"` // Two-factor authentication password
+	AppID                 int             `yaml:"app_id" mapstructure:"app_id"`
+	AppHash               string          `yaml:"app_hash" mapstructure:"app_hash"`
+	WorkerCount           int             `yaml:"worker_count" mapstructure:"worker_count"`                         // Number of concurrent workers, default: defaults.worker_count
+	TaskQueueSize         int             `yaml:"task_queue_size" mapstructure:"task_queue_size"`                   // Task queue size, default: defaults.task_queue_size
+	QueueOverflow         string          `yaml:"queue_overflow" mapstructure:"queue_overflow"`                     // When the queue is full: drop (default) | block | drop_oldest | expand
+	QueueBlockSeconds     int             `yaml:"queue_block_seconds" mapstructure:"queue_block_seconds"`           // Longest wait for a free slot with queue_overflow: block, default: defaults.queue_block_seconds
+	Retry                 RetryConfig     `yaml:"retry" mapstructure:"retry"`                                       // Overrides fields of defaults.retry
+	ConnectionMode        string          `yaml:"connection_mode" mapstructure:"connection_mode"`                   // Overrides the global connection_mode
+	Presence              string          `yaml:"presence" mapstructure:"presence"`                                 // Overrides the global presence
+	Device                DeviceConfig    `yaml:"device" mapstructure:"device"`                                     // Overrides fields of the global device
+	LoginCode             LoginCodeConfig `yaml:"login_code" mapstructure:"login_code"`                             // Replaces the global login_code when its source is set
+	DefaultSchedule       string          `yaml:"default_schedule" mapstructure:"default_schedule"`                 // Overrides the global default_schedule
+	Sequential            bool            `yaml:"sequential" mapstructure:"sequential"`                             // Run tasks strictly one-by-one (forces a single worker)
+	InterTaskDelaySeconds int             `yaml:"inter_task_delay_seconds" mapstructure:"inter_task_delay_seconds"` // Gap between tasks when sequential is enabled
+	ReplyWaitSeconds      int             `yaml:"reply_wait_seconds" mapstructure:"reply_wait_seconds"`             // Seconds to wait for bot reply
+	ReplyHistoryLimit     int             `yaml:"reply_history_limit" mapstructure:"reply_history_limit"`           // Number of historical messages to fetch
+	ReplyWindowSeconds    int             `yaml:"reply_window_seconds" mapstructure:"reply_window_seconds"`         // Only messages dated at most this long after the send count as the reply, 0 for no time limit
+	AllowedTargets        []string        `yaml:"allowed_targets" mapstructure:"allowed_targets"`                   // Chats the tasks may send to, other targets are refused unless --unsafe is passed; empty allows all
+	SessionWatchMinutes   int             `yaml:"session_watch_minutes" mapstructure:"session_watch_minutes"`       // Daemon mode: check the account's sessions every N minutes and alert on new ones, 0 disables
+	Tasks                 []TaskConfig    `yaml:"tasks" mapstructure:"tasks"`
 }
 
 type TaskConfig struct {
-	Name              string `yaml:"name" mapstructure:"name"`                               // Task name for identification
-	Target            string `yaml:"target" mapstructure:"target"`                           // Target username or ID
-	Method            string `yaml:"method" mapstructure:"method"`                           // message or button
-	Payload           string `yaml:"payload" mapstructure:"payload"`                         // Message content or button text
-	Schedule          string `yaml:"schedule" mapstructure:"schedule"`                       // Cron expression or @every 1h
-	Enabled           *bool  `yaml:"enabled" mapstructure:"enabled"`                         // Enabled by default
-	RunOnStart        bool   `yaml:"run_on_start" mapstructure:"run_on_start"`               // Execute once on startup when true
-	ReplyWaitSeconds  int    `yaml:"reply_wait_seconds" mapstructure:"reply_wait_seconds" `  // Seconds to wait for bot reply
-	ReplyHistoryLimit int    `yaml:"reply_history_limit" mapstructure:"reply_history_limit"` // Number of historical messages to fetch
+	Template           string              `yaml:"template" mapstructure:"template"`                         // Name of a task_templates entry, fields set here override it
+	Tags               []string            `yaml:"tags" mapstructure:"tags"`                                 // Groups for --tag filtering
+	Name               string              `yaml:"name" mapstructure:"name"`                                 // Task name for identification
+	Target             string              `yaml:"target" mapstructure:"target"`                             // Target username or ID
+	Method             string              `yaml:"method" mapstructure:"method"`                             // message, button, reaction, vote, dice, forward, media, location or script
+	Payload            string              `yaml:"payload" mapstructure:"payload"`                           // Message content, button text, reaction/dice emoji, poll option, forwarded message or media caption
+	PayloadFormat      string              `yaml:"payload_format" mapstructure:"payload_format"`             // Message and media methods: plain (default) | markdown | html, parsed into bold, code, links...
+	Media              MediaConfig         `yaml:"media" mapstructure:"media"`                               // Media method: photo, sticker or GIF to send
+	Location           LocationConfig      `yaml:"location" mapstructure:"location"`                         // Location method: geo point to share
+	Messages           []MessageStep       `yaml:"messages" mapstructure:"messages"`                         // Message method: messages sent one after another instead of payload
+	Buttons            []string            `yaml:"buttons" mapstructure:"buttons"`                           // Button texts clicked one after another: instead of payload (button method) or after the reply (message method)
+	ReactTo            string              `yaml:"react_to" mapstructure:"react_to"`                         // Message to react to: latest (default) | pinned | message ID
+	ForwardFrom        string              `yaml:"forward_from" mapstructure:"forward_from"`                 // Forward method: chat the message is taken from, default: me (Saved Messages)
+	ReplyToMessage     string              `yaml:"reply_to_message" mapstructure:"reply_to_message"`         // Send the message as a reply to: latest | pinned | message ID
+	Silent             bool                `yaml:"silent" mapstructure:"silent"`                             // Send the message without notification
+	MarkRead           bool                `yaml:"mark_read" mapstructure:"mark_read"`                       // Mark the target dialog as read after check-in
+	PostAction         PostActionConfig    `yaml:"post_action" mapstructure:"post_action"`                   // Delete the sent messages or clear the chat after check-in
+	DeleteAfterSeconds int                 `yaml:"delete_after_seconds" mapstructure:"delete_after_seconds"` // Delete the sent messages this long after the bot replied, shorthand for post_action delete_sent
+	SimulateTyping     bool                `yaml:"simulate_typing" mapstructure:"simulate_typing"`           // Show "typing..." for a randomized interval before sending
+	SaveReplyMedia     bool                `yaml:"save_reply_media" mapstructure:"save_reply_media"`         // Download a photo in the bot reply into the artifacts directory
+	SaveTranscript     bool                `yaml:"save_transcript" mapstructure:"save_transcript"`           // Also write the exchange of every run as a JSON file into the artifacts directory
+	ReplyExtract       []string            `yaml:"reply_extract" mapstructure:"reply_extract"`               // Regexes with named groups parsing numbers (points, streak...) from the reply
+	SuccessKeywords    []string            `yaml:"success_keywords" mapstructure:"success_keywords"`         // Task fails unless the reply contains one of these (case-insensitive)
+	FollowUps          []FollowUpRule      `yaml:"follow_ups" mapstructure:"follow_ups"`                     // Answers to bot follow-up prompts, e.g. "Are you sure?"
+	Webhook            WebhookConfig       `yaml:"webhook" mapstructure:"webhook"`                           // HTTP call made when the task completes
+	PingURL            string              `yaml:"ping_url" mapstructure:"ping_url"`                         // healthchecks.io style check URL, pinged at start, success and failure
+	OtherButton        string              `yaml:"other_button" mapstructure:"other_button"`                 // Matched button is not a callback button: fail (default) | skip | open
+	WaitForEdit        bool                `yaml:"wait_for_edit" mapstructure:"wait_for_edit"`               // After a button press, use the bot's edit of the message as the reply
+	ButtonWaitSeconds  int                 `yaml:"button_wait_seconds" mapstructure:"button_wait_seconds"`   // Button method: poll this long for a message with the button instead of failing at once
+	EditWaitSeconds    int                 `yaml:"edit_wait_seconds" mapstructure:"edit_wait_seconds"`       // Message method: when the reply has no buttons yet, wait this long for the bot to edit them in
+	Script             ScriptConfig        `yaml:"script" mapstructure:"script"`                             // Local executable run by the script method
+	WebApp             WebAppConfig        `yaml:"webapp" mapstructure:"webapp"`                             // Follow-up request when the button opens a web app (mini app)
+	CallbackURL        CallbackURLConfig   `yaml:"callback_url" mapstructure:"callback_url"`                 // What to do when a button press answers with a URL instead of text
+	Priority           int                 `yaml:"priority" mapstructure:"priority"`                         // Higher runs first when the queue is congested, default: 0
+	DependsOn          []DependencyConfig  `yaml:"depends_on" mapstructure:"depends_on"`                     // Run only after these tasks of the account succeed, instead of on its own
+	Schedule           string              `yaml:"schedule" mapstructure:"schedule"`                         // Cron expression or @every 1h
+	ScheduleOffset     int                 `yaml:"schedule_offset" mapstructure:"schedule_offset"`           // Seconds to shift the (inherited) schedule by, e.g. 300 runs 5 minutes later
+	RunAt              string              `yaml:"run_at" mapstructure:"run_at"`                             // One-time trigger in local time, e.g. "2025-07-01 09:00"; the task is disabled after it fires
+	ScheduleAhead      ScheduleAheadConfig `yaml:"schedule_ahead" mapstructure:"schedule_ahead"`             // Message method: queue the payload with Telegram's scheduled messages instead of sending it
+	Skip               SkipConfig          `yaml:"skip" mapstructure:"skip"`                                 // Days on which scheduled runs are suppressed
+	Enabled            *bool               `yaml:"enabled" mapstructure:"enabled"`                           // Enabled by default
+	RunOnStart         bool                `yaml:"run_on_start" mapstructure:"run_on_start"`                 // Execute once on startup when true
+	ReplyWaitSeconds   int                 `yaml:"reply_wait_seconds" mapstructure:"reply_wait_seconds" `    // Seconds to wait for bot reply
+	ReplyHistoryLimit  int                 `yaml:"reply_history_limit" mapstructure:"reply_history_limit"`   // Number of historical messages to fetch
+	ReplyWindowSeconds int                 `yaml:"reply_window_seconds" mapstructure:"reply_window_seconds"` // Only messages dated at most this long after the send count as the reply, 0 for no time limit
+	ReplyFrom          []string            `yaml:"reply_from" mapstructure:"reply_from"`                     // Only messages of these users (@username or user ID) count as the reply, for group targets
 }
 
-func LoadConfig(path string, v *viper.Viper) (*Config, error) {
-	v.SetConfigFile(path)
+// DependencyConfig is an edge of the per-account task graph: the task runs after Task succeeds
+type DependencyConfig struct {
+	Task         string `yaml:"task" mapstructure:"task"`                   // Name (or target) of the task to wait for
+	DelaySeconds int    `yaml:"delay_seconds" mapstructure:"delay_seconds"` // Pause after Task succeeds before running
+}
+
+// MessageStep is one message of a multi-message task, e.g. /checkin followed by /bonus
+type MessageStep struct {
+	Text         string `yaml:"text" mapstructure:"text"`                   // Message to send
+	DelaySeconds int    `yaml:"delay_seconds" mapstructure:"delay_seconds"` // Pause before sending, counted from the previous message's reply
+}
+
+// FollowUpRule answers a bot reply matching IfReplyMatches by sending a message or clicking a button
+type FollowUpRule struct {
+	IfReplyMatches string `yaml:"if_reply_matches" mapstructure:"if_reply_matches"` // Regular expression matched against the latest reply
+	ThenSend       string `yaml:"then_send" mapstructure:"then_send"`               // Message to send
+	ThenClick      string `yaml:"then_click" mapstructure:"then_click"`             // Button text to click in the latest message
+}
 
+// WebhookConfig is an HTTP call made after a task completes. URL, header values and body are
+// Go templates over the task result, e.g. {{.Task}} {{.Success}} {{json .Reply}} {{.Extracted.points}}.
+type WebhookConfig struct {
+	URL     string            `yaml:"url" mapstructure:"url"`         // Webhook URL, empty to disable
+	Method  string            `yaml:"method" mapstructure:"method"`   // HTTP method, default: POST
+	Headers map[string]string `yaml:"headers" mapstructure:"headers"` // Extra request headers
+	Body    string            `yaml:"body" mapstructure:"body"`       // Body template, default: the result as JSON
+	On      string            `yaml:"on" mapstructure:"on"`           // When to call: always (default) | success | failure
+}
+
+// DeviceConfig is the device reported when a session connects, shown in Telegram's active
+// sessions list. Empty fields use the gotd defaults.
+type DeviceConfig struct {
+	Model          string `yaml:"model" mapstructure:"model"`                       // e.g. "iPhone 15 Pro"
+	SystemVersion  string `yaml:"system_version" mapstructure:"system_version"`     // e.g. "iOS 17.5"
+	AppVersion     string `yaml:"app_version" mapstructure:"app_version"`           // e.g. "10.14.1"
+	LangCode       string `yaml:"lang_code" mapstructure:"lang_code"`               // Client language, ISO 639-1, default: en
+	SystemLangCode string `yaml:"system_lang_code" mapstructure:"system_lang_code"` // OS language, ISO 639-1, default: en
+}
+
+// ScriptConfig is a local executable run by the script method. The task context is passed
+// as CHECKIN_* environment variables and as JSON on stdin; exit code 0 means success and
+// stdout becomes the task reply.
+type ScriptConfig struct {
+	Command string            `yaml:"command" mapstructure:"command"` // Executable path, relative paths are resolved against the working directory
+	Args    []string          `yaml:"args" mapstructure:"args"`       // Command arguments
+	Dir     string            `yaml:"dir" mapstructure:"dir"`         // Working directory, default: the current one
+	Env     map[string]string `yaml:"env" mapstructure:"env"`         // Extra environment variables
+	Timeout int               `yaml:"timeout" mapstructure:"timeout"` // Seconds before the script is killed, default: 60
+}
+
+// CallbackURLConfig configures what happens when a callback answer carries a URL
+// (redirect-style check-ins). The URL is opened through the configured proxy.
+type CallbackURLConfig struct {
+	Open         bool `yaml:"open" mapstructure:"open"`                   // Open the URL and fail the task on an error status, default: only log it
+	MaxRedirects int  `yaml:"max_redirects" mapstructure:"max_redirects"` // Redirects to follow, default: 10
+}
+
+// WebAppConfig configures the optional HTTP call made after opening a web app button.
+// URL, body and header values may use {url} and {init_data} placeholders.
+type WebAppConfig struct {
+	Request bool              `yaml:"request" mapstructure:"request"` // Perform the HTTP call, default: only log the web app URL
+	Method  string            `yaml:"method" mapstructure:"method"`   // HTTP method, default: GET
+	URL     string            `yaml:"url" mapstructure:"url"`         // Request URL, default: the web app URL
+	Body    string            `yaml:"body" mapstructure:"body"`       // Request body
+	Headers map[string]string `yaml:"headers" mapstructure:"headers"` // Extra request headers
+}
+
+// SessionName returns the session name of the account: its phone number, or session_<app_id> for QR login
+func (a AccountConfig) SessionName() string {
+	if a.Phone != "" {
+		return a.Phone
+	}
+	return fmt.Sprintf("session_%d", a.AppID)
+}
+
+// Key identifies the account in runtime state and the control API: its name, or its session name
+func (a AccountConfig) Key() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.SessionName()
+}
+
+// DisplayName returns the task name, or its target for unnamed tasks
+func (t TaskConfig) DisplayName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Target
+}
+
+// DeviceFor returns the device of an account: the global device with the account's non-empty fields applied
+func (c *Config) DeviceFor(acc AccountConfig) DeviceConfig {
+	dev := c.Device
+	override := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	override(&dev.Model, acc.Device.Model)
+	override(&dev.SystemVersion, acc.Device.SystemVersion)
+	override(&dev.AppVersion, acc.Device.AppVersion)
+	override(&dev.LangCode, acc.Device.LangCode)
+	override(&dev.SystemLangCode, acc.Device.SystemLangCode)
+	return dev
+}
+
+// LoginCodeFor returns the login code source of an account, the account setting replaces the global one
+func (c *Config) LoginCodeFor(acc AccountConfig) LoginCodeConfig {
+	if acc.LoginCode.Source != "" {
+		return acc.LoginCode
+	}
+	return c.LoginCode
+}
+
+func LoadConfig(path string, v *viper.Viper) (*Config, error) {
 	// Support environment variable override
 	// Environment variable naming rule: TG_ + config path (separated by underscore)
 	// Example: TG_LOG_LEVEL, TG_ACCOUNTS_0_PHONE, TG_APP_ID
 	v.SetEnvPrefix("TG")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
+	// Keys usually absent from the file are only seen by Unmarshal when bound explicitly
+	_ = v.BindEnv("non_interactive")
+	_ = v.BindEnv("log.mtproto")
 
-	// Read main config file
-	if err := v.ReadInConfig(); err != nil {
+	// Read main config, from TG_CONFIG_YAML / TG_CONFIG_BASE64 when set, the file or URL otherwise
+	version, err := readMainConfig(path, v)
+	if err != nil {
 		return nil, err
 	}
 
 	// Try to merge environment-specific config file (e.g. config.test.yaml, config.prod.yaml)
 	// Priority: environment config > main config
-	if env := os.Getenv("APP_ENV"); env != "" {
+	if env := os.Getenv("APP_ENV"); env != "" && !IsRemote(path) {
 		// Build environment config file name
 		dir := filepath.Dir(path)
 		base := filepath.Base(path)
@@ -79,7 +354,16 @@ func LoadConfig(path string, v *viper.Viper) (*Config, error) {
 		// Merge if environment config file exists
 		if _, err := os.Stat(envConfigPath); err == nil {
 			v.SetConfigFile(envConfigPath)
-			if err := v.MergeInConfig(); err != nil {
+			plain, _, encrypted, err := decryptFile(envConfigPath)
+			switch {
+			case err != nil:
+				return nil, err
+			case encrypted:
+				err = v.MergeConfig(bytes.NewReader(plain))
+			default:
+				err = v.MergeInConfig()
+			}
+			if err != nil {
 				return nil, fmt.Errorf("failed to merge config file %s: %w", envConfigPath, err)
 			}
 		}
@@ -89,199 +373,135 @@ func LoadConfig(path string, v *viper.Viper) (*Config, error) {
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
+	if err := loadIncludes(path, &cfg); err != nil {
+		return nil, err
+	}
+	if err := Prepare(&cfg); err != nil {
+		return nil, err
+	}
+	cfg.remoteVersion = version
 	return &cfg, nil
 }
 
-func MergeConfig(base, override *Config) (*Config, error) {
-	if base == nil {
-		return override, nil
-	}
-	if override == nil {
-		return base, nil
-	}
+// RemoteVersion returns the version of a main config loaded from a URL, compared by
+// RemoteChanged, and "" for a config read from a file or the environment
+func (c *Config) RemoteVersion() string {
+	return c.remoteVersion
+}
 
-	merged := *base
-	if override.Proxy != "" {
-		merged.Proxy = override.Proxy
+// Prepare expands templates and defaults, validates the tasks and resolves secrets of a
+// config that was not read by LoadConfig, e.g. one built by a program embedding the engine
+func Prepare(cfg *Config) error {
+	if err := expandVariables(cfg); err != nil {
+		return err
 	}
-	if override.AppID != 0 {
-		merged.AppID = override.AppID
+	if err := applyTaskTemplates(cfg); err != nil {
+		return err
 	}
-	if override.AppHash != "" {
-		merged.AppHash = override.AppHash
+	applyScheduleDefaults(cfg)
+	applyAccountDefaults(cfg)
+	if err := validateRunAt(cfg); err != nil {
+		return err
 	}
-
-	if len(override.Accounts) > 0 {
-		accounts, err := mergeAccounts(base.Accounts, override.Accounts)
-		if err != nil {
-			return nil, err
-		}
-		merged.Accounts = accounts
+	if err := validateDependencies(cfg); err != nil {
+		return err
 	}
-
-	return &merged, nil
-}
-
-func mergeAccounts(base, override []AccountConfig) ([]AccountConfig, error) {
-	if len(override) == 0 {
-		return base, nil
-	}
-	if allAccountsNamed(base) && allAccountsNamed(override) {
-		return mergeAccountsByName(base, override), nil
+	if err := validateScheduleAhead(cfg); err != nil {
+		return err
 	}
-	if len(base) != len(override) {
-		return nil, fmt.Errorf("accounts length mismatch: base=%d override=%d", len(base), len(override))
+	if err := validatePayloadFormats(cfg); err != nil {
+		return err
 	}
-	return mergeAccountsByIndex(base, override), nil
-}
-
-func mergeAccount(base, override AccountConfig) AccountConfig {
-	merged := base
-	if override.Name != "" {
-		merged.Name = override.Name
+	if err := validateMedia(cfg); err != nil {
+		return err
 	}
-	if override.Phone != "" {
-		merged.Phone = override.Phone
+	if err := validateLocations(cfg); err != nil {
+		return err
 	}
-	if override.Password != "" {
-		merged.Password = override.Password
+	if err := validateSkipRules(cfg); err != nil {
+		return err
 	}
-	if override.AppID != 0 {
-		merged.AppID = override.AppID
+	if err := applyDeleteAfter(cfg); err != nil {
+		return err
 	}
-	if override.AppHash != "" {
-		merged.AppHash = override.AppHash
+	if err := validatePostActions(cfg); err != nil {
+		return err
 	}
-	if len(override.Tasks) > 0 {
-		merged.Tasks = mergeTasks(base.Tasks, override.Tasks)
+	if len(cfg.ProxyFallbacks) > 0 && cfg.Proxy == "" {
+		return fmt.Errorf("proxy_fallbacks requires proxy")
 	}
-	return merged
-}
-
-func allAccountsNamed(accounts []AccountConfig) bool {
-	if len(accounts) == 0 {
-		return false
+	if cfg.FallbackDirect && cfg.Proxy == "" {
+		return fmt.Errorf("fallback_direct requires proxy")
 	}
-	for _, acc := range accounts {
-		if strings.TrimSpace(acc.Name) == "" {
-			return false
-		}
+	if err := validateNetwork(cfg.Network); err != nil {
+		return err
 	}
-	return true
-}
-
-func mergeAccountsByName(base, override []AccountConfig) []AccountConfig {
-	overrideIndex := make(map[string]AccountConfig, len(override))
-	for _, acc := range override {
-		overrideIndex[strings.TrimSpace(acc.Name)] = acc
+	if err := validateSendQuota(cfg.SendQuota); err != nil {
+		return err
 	}
-
-	merged := make([]AccountConfig, 0, len(base))
-	seen := make(map[string]struct{}, len(override))
-	for _, acc := range base {
-		key := strings.TrimSpace(acc.Name)
-		if over, ok := overrideIndex[key]; ok {
-			merged = append(merged, mergeAccount(acc, over))
-			seen[key] = struct{}{}
-		} else {
-			merged = append(merged, acc)
+	for i, acc := range cfg.Accounts {
+		if acc.SessionWatchMinutes < 0 {
+			return fmt.Errorf("accounts[%d]: session_watch_minutes must not be negative", i)
 		}
 	}
-
-	for _, acc := range override {
-		key := strings.TrimSpace(acc.Name)
-		if _, ok := seen[key]; ok {
-			continue
+	if cfg.Streaks.Deadline != "" {
+		if _, err := cfg.Streaks.DeadlineCron(); err != nil {
+			return err
 		}
-		merged = append(merged, acc)
 	}
-
-	return merged
+	if err := resolveSecrets(cfg); err != nil {
+		return err
+	}
+	return validateControl(cfg.Control)
 }
 
-func mergeAccountsByIndex(base, override []AccountConfig) []AccountConfig {
-	maxLen := len(base)
-	if len(override) > maxLen {
-		maxLen = len(override)
+// validateControl requires a token when the control API listens beyond the loopback
+// interface, it accepts login codes and toggles from whoever reaches it
+func validateControl(c ControlConfig) error {
+	if c.Listen == "" || c.Token != "" {
+		return nil
 	}
-
-	merged := make([]AccountConfig, 0, maxLen)
-	for i := 0; i < maxLen; i++ {
-		var b AccountConfig
-		var o AccountConfig
-		hasBase := i < len(base)
-		hasOverride := i < len(override)
-		if hasBase {
-			b = base[i]
-		}
-		if hasOverride {
-			o = override[i]
-		}
-
-		switch {
-		case hasBase && hasOverride:
-			merged = append(merged, mergeAccount(b, o))
-		case hasBase:
-			merged = append(merged, b)
-		default:
-			merged = append(merged, o)
-		}
+	host, _, err := net.SplitHostPort(c.Listen)
+	if err != nil {
+		return fmt.Errorf("control.listen: %w", err)
 	}
-
-	return merged
-}
-
-func mergeTasks(base, override []TaskConfig) []TaskConfig {
-	maxLen := len(base)
-	if len(override) > maxLen {
-		maxLen = len(override)
+	if host == "localhost" {
+		return nil
 	}
-
-	merged := make([]TaskConfig, 0, maxLen)
-	for i := 0; i < maxLen; i++ {
-		var b TaskConfig
-		var o TaskConfig
-		hasBase := i < len(base)
-		hasOverride := i < len(override)
-		if hasBase {
-			b = base[i]
-		}
-		if hasOverride {
-			o = override[i]
-		}
-
-		switch {
-		case hasBase && hasOverride:
-			merged = append(merged, mergeTask(b, o))
-		case hasBase:
-			merged = append(merged, b)
-		default:
-			merged = append(merged, o)
-		}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
 	}
-
-	return merged
+	return fmt.Errorf("control.listen: %q is not a loopback address, set control.token to expose the control API", c.Listen)
 }
 
-func mergeTask(base, override TaskConfig) TaskConfig {
-	merged := base
-	if override.Target != "" {
-		merged.Target = override.Target
-	}
-	if override.Method != "" {
-		merged.Method = override.Method
-	}
-	if override.Payload != "" {
-		merged.Payload = override.Payload
+// validateNetwork checks the transport name and rejects negative intervals
+func validateNetwork(n NetworkConfig) error {
+	switch n.Transport {
+	case "", "intermediate", "abridged", "padded_intermediate":
+	default:
+		return fmt.Errorf("network.transport: unknown transport %q, expected intermediate, abridged or padded_intermediate", n.Transport)
 	}
-	if override.Schedule != "" {
-		merged.Schedule = override.Schedule
+	if n.KeepaliveSeconds < 0 || n.DialTimeoutSeconds < 0 || n.ReconnectMaxSeconds < 0 {
+		return fmt.Errorf("network: seconds must not be negative")
 	}
-	if override.Enabled != nil {
-		merged.Enabled = override.Enabled
-	}
-	if override.RunOnStart {
-		merged.RunOnStart = true
+	return nil
+}
+
+// validatePayloadFormats checks payload_format, which only applies to the message and media methods
+func validatePayloadFormats(cfg *Config) error {
+	for i, acc := range cfg.Accounts {
+		for j, task := range acc.Tasks {
+			switch task.PayloadFormat {
+			case "":
+				continue
+			case "plain", "markdown", "html":
+			default:
+				return fmt.Errorf("accounts[%d].tasks[%d]: unknown payload_format %q, expected plain, markdown or html", i, j, task.PayloadFormat)
+			}
+			if task.Method != "message" && task.Method != "media" {
+				return fmt.Errorf("accounts[%d].tasks[%d]: payload_format requires the message or media method", i, j)
+			}
+		}
 	}
-	return merged
+	return nil
 }
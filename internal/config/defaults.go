@@ -0,0 +1,69 @@
+package config
+
+// Built-in account settings, used when neither the account nor the defaults block sets them
+const (
+	DefaultWorkerCount         = 4
+	DefaultTaskQueueSize       = 100
+	DefaultQueueBlockSeconds   = 30
+	DefaultReplyWaitSeconds    = 3
+	DefaultReplyHistoryLimit   = 10
+	DefaultMaxFloodWaitSeconds = 60
+	DefaultTransientRetries    = 3
+)
+
+// DefaultsConfig holds account settings applied to every account that does not set its own
+type DefaultsConfig struct {
+	WorkerCount        int         `yaml:"worker_count" mapstructure:"worker_count"`                 // Number of concurrent workers, default: 4
+	TaskQueueSize      int         `yaml:"task_queue_size" mapstructure:"task_queue_size"`           // Task queue size, default: 100
+	QueueOverflow      string      `yaml:"queue_overflow" mapstructure:"queue_overflow"`             // When the queue is full: drop (default) | block | drop_oldest | expand
+	QueueBlockSeconds  int         `yaml:"queue_block_seconds" mapstructure:"queue_block_seconds"`   // Longest wait for a free slot with queue_overflow: block, default: 30
+	ReplyWaitSeconds   int         `yaml:"reply_wait_seconds" mapstructure:"reply_wait_seconds"`     // Seconds to wait for bot reply, default: the top-level reply_wait_seconds or 3
+	ReplyHistoryLimit  int         `yaml:"reply_history_limit" mapstructure:"reply_history_limit"`   // Number of historical messages to fetch, default: the top-level reply_history_limit or 10
+	ReplyWindowSeconds int         `yaml:"reply_window_seconds" mapstructure:"reply_window_seconds"` // Only messages dated at most this long after the send count as the reply, default: 0 (no time limit)
+	Retry              RetryConfig `yaml:"retry" mapstructure:"retry"`                               // Retries of failed Telegram requests
+}
+
+// RetryConfig configures how Telegram requests failing with a flood wait or a transient error are retried
+type RetryConfig struct {
+	MaxFloodWaitSeconds int `yaml:"max_flood_wait_seconds" mapstructure:"max_flood_wait_seconds"` // Longest FLOOD_WAIT slept through before the request fails, default: 60
+	TransientRetries    int `yaml:"transient_retries" mapstructure:"transient_retries"`           // Retries of a request failing with a network or server error, default: 3
+}
+
+// applyAccountDefaults fills the unset worker, queue, reply and retry settings of every account
+// from the defaults block, and those of the defaults block from the built-in values
+func applyAccountDefaults(cfg *Config) {
+	d := &cfg.Defaults
+	fill(&d.WorkerCount, DefaultWorkerCount)
+	fill(&d.TaskQueueSize, DefaultTaskQueueSize)
+	fill(&d.QueueBlockSeconds, DefaultQueueBlockSeconds)
+	// The top-level reply settings predate the defaults block and still apply
+	fill(&d.ReplyWaitSeconds, cfg.ReplyWaitSeconds, DefaultReplyWaitSeconds)
+	fill(&d.ReplyHistoryLimit, cfg.ReplyHistoryLimit, DefaultReplyHistoryLimit)
+	fill(&d.Retry.MaxFloodWaitSeconds, DefaultMaxFloodWaitSeconds)
+	fill(&d.Retry.TransientRetries, DefaultTransientRetries)
+
+	for i := range cfg.Accounts {
+		acc := &cfg.Accounts[i]
+		fill(&acc.WorkerCount, d.WorkerCount)
+		fill(&acc.TaskQueueSize, d.TaskQueueSize)
+		fill(&acc.QueueBlockSeconds, d.QueueBlockSeconds)
+		fill(&acc.ReplyWaitSeconds, d.ReplyWaitSeconds)
+		fill(&acc.ReplyHistoryLimit, d.ReplyHistoryLimit)
+		fill(&acc.ReplyWindowSeconds, d.ReplyWindowSeconds)
+		fill(&acc.Retry.MaxFloodWaitSeconds, d.Retry.MaxFloodWaitSeconds)
+		fill(&acc.Retry.TransientRetries, d.Retry.TransientRetries)
+		if acc.QueueOverflow == "" {
+			acc.QueueOverflow = d.QueueOverflow
+		}
+	}
+}
+
+// fill sets an unset (non-positive) *dst to the first positive value
+func fill(dst *int, values ...int) {
+	for _, v := range values {
+		if *dst > 0 {
+			return
+		}
+		*dst = v
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import "fmt"
+
+// validateDependencies checks that depends_on only names tasks of the same account, each
+// once, and that the task graph of every account is acyclic
+func validateDependencies(cfg *Config) error {
+	for i, acc := range cfg.Accounts {
+		byName := make(map[string]TaskConfig, len(acc.Tasks))
+		for _, task := range acc.Tasks {
+			byName[task.DisplayName()] = task
+		}
+		for j, task := range acc.Tasks {
+			seen := make(map[string]bool, len(task.DependsOn))
+			for _, dep := range task.DependsOn {
+				if _, ok := byName[dep.Task]; !ok {
+					return fmt.Errorf("accounts[%d].tasks[%d]: depends_on references unknown task %q", i, j, dep.Task)
+				}
+				if seen[dep.Task] {
+					return fmt.Errorf("accounts[%d].tasks[%d]: depends_on lists task %q more than once", i, j, dep.Task)
+				}
+				seen[dep.Task] = true
+			}
+		}
+
+		// Depth-first search, a task seen again while still on the path closes a cycle
+		const (
+			visiting = 1
+			done     = 2
+		)
+		marks := make(map[string]int, len(acc.Tasks))
+		var visit func(name string) error
+		visit = func(name string) error {
+			switch marks[name] {
+			case visiting:
+				return fmt.Errorf("account %q: depends_on cycle through task %q", acc.Key(), name)
+			case done:
+				return nil
+			}
+			marks[name] = visiting
+			for _, dep := range byName[name].DependsOn {
+				if err := visit(dep.Task); err != nil {
+					return err
+				}
+			}
+			marks[name] = done
+			return nil
+		}
+		for _, task := range acc.Tasks {
+			if err := visit(task.DisplayName()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IsRoot reports whether the task runs on its own trigger, i.e. none of its
+// dependencies are among tasks. Dependent tasks are run by the executor instead.
+func (t TaskConfig) IsRoot(tasks []TaskConfig) bool {
+	for _, dep := range t.DependsOn {
+		for _, other := range tasks {
+			if other.DisplayName() == dep.Task {
+				return false
+			}
+		}
+	}
+	return true
+}
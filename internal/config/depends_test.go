@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// dependentTask returns a task named name depending on deps
+func dependentTask(name string, deps ...string) TaskConfig {
+	task := TaskConfig{Name: name, Target: "@bot"}
+	for _, dep := range deps {
+		task.DependsOn = append(task.DependsOn, DependencyConfig{Task: dep})
+	}
+	return task
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		tasks   []TaskConfig
+		wantErr string
+	}{
+		{
+			name:  "chain",
+			tasks: []TaskConfig{dependentTask("a"), dependentTask("b", "a"), dependentTask("c", "b")},
+		},
+		{
+			name:  "join",
+			tasks: []TaskConfig{dependentTask("a"), dependentTask("b"), dependentTask("c", "a", "b")},
+		},
+		{
+			name:    "unknown task",
+			tasks:   []TaskConfig{dependentTask("a", "missing")},
+			wantErr: `unknown task "missing"`,
+		},
+		{
+			name:    "duplicate dependency",
+			tasks:   []TaskConfig{dependentTask("a"), dependentTask("b", "a", "a")},
+			wantErr: `lists task "a" more than once`,
+		},
+		{
+			name:    "self dependency",
+			tasks:   []TaskConfig{dependentTask("a", "a")},
+			wantErr: "cycle",
+		},
+		{
+			name:    "cycle",
+			tasks:   []TaskConfig{dependentTask("a", "c"), dependentTask("b", "a"), dependentTask("c", "b")},
+			wantErr: "cycle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Accounts: []AccountConfig{{Name: "alice", Tasks: tt.tasks}}}
+			err := validateDependencies(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// expandVariables replaces ${NAME} in every string of the config with the environment
+// variable NAME, so one config file serves several hosts. ${NAME:-default} falls back to
+// default when NAME is unset, $${NAME} stays a literal ${NAME}. An unset variable without
+// default is an error naming the field.
+func expandVariables(cfg *Config) error {
+	return expandValue(reflect.ValueOf(cfg).Elem(), "")
+}
+
+// expandValue expands the strings in v, path is the config key of v for error messages
+func expandValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.String:
+		expanded, err := expandString(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		v.SetString(expanded)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			if name == "" {
+				name = strings.ToLower(t.Field(i).Name)
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := expandValue(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := expandValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map values are not addressable, they are expanded in a copy and stored back
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)) })
+		for _, key := range keys {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			if err := expandValue(value, fmt.Sprintf("%s.%v", path, key)); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+	}
+	return nil
+}
+
+// expandString replaces the ${NAME} and ${NAME:-default} references in s. Anything else
+// after a $, such as $5 or the end anchor of a regex, is kept as it is.
+func expandString(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var out strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			out.WriteString(s)
+			return out.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			out.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			out.WriteString(s)
+			return out.String(), nil
+		}
+		name, fallback, hasFallback := strings.Cut(s[i+2:i+end], ":-")
+		if !isVariableName(name) {
+			out.WriteString(s[:i+2])
+			s = s[i+2:]
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if !hasFallback {
+				return "", fmt.Errorf("environment variable %s is not set, use ${%s:-default} for a fallback", name, name)
+			}
+			value = fallback
+		}
+		out.WriteString(s[:i] + value)
+		s = s[i+end+1:]
+	}
+}
+
+// isVariableName reports whether name is a valid environment variable name
+func isVariableName(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
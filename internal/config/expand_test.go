@@ -0,0 +1,72 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandString(t *testing.T) {
+	t.Setenv("CHECKIN_HOST", "alpha")
+	t.Setenv("CHECKIN_EMPTY", "")
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "${CHECKIN_HOST}", want: "alpha"},
+		{in: "session-${CHECKIN_HOST}.session", want: "session-alpha.session"},
+		{in: "${CHECKIN_HOST}/${CHECKIN_HOST}", want: "alpha/alpha"},
+		{in: "${CHECKIN_UNSET:-beta}", want: "beta"},
+		{in: "${CHECKIN_HOST:-beta}", want: "alpha"},
+		{in: "${CHECKIN_EMPTY:-beta}", want: ""},
+		{in: "$${CHECKIN_HOST}", want: "${CHECKIN_HOST}"},
+		{in: `\+(\d+) points$`, want: `\+(\d+) points$`},
+		{in: "costs $5", want: "costs $5"},
+		{in: "${1}", want: "${1}"},
+		{in: "${CHECKIN_HOST", want: "${CHECKIN_HOST"},
+		{in: "${CHECKIN_UNSET}", wantErr: "CHECKIN_UNSET is not set"},
+	}
+	for _, tt := range tests {
+		got, err := expandString(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expandString(%q): got error %v, want one containing %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("expandString(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestExpandVariables(t *testing.T) {
+	t.Setenv("CHECKIN_PHONE", "+10000000000")
+	t.Setenv("CHECKIN_TOKEN", "secret")
+	cfg := &Config{
+		Accounts: []AccountConfig{{
+			Phone: "${CHECKIN_PHONE}",
+			Tasks: []TaskConfig{{Target: "@bot", Payload: "/checkin ${CHECKIN_PHONE}"}},
+		}},
+		Notifications: []NotifierConfig{{Type: "webhook", Headers: map[string]string{"Authorization": "Bearer ${CHECKIN_TOKEN}"}}},
+	}
+	if err := expandVariables(cfg); err != nil {
+		t.Fatalf("expand failed: %v", err)
+	}
+	if got := cfg.Accounts[0].Phone; got != "+10000000000" {
+		t.Errorf("got phone %q", got)
+	}
+	if got := cfg.Accounts[0].Tasks[0].Payload; got != "/checkin +10000000000" {
+		t.Errorf("got payload %q", got)
+	}
+	if got := cfg.Notifications[0].Headers["Authorization"]; got != "Bearer secret" {
+		t.Errorf("got header %q, map values are expanded too", got)
+	}
+
+	cfg = &Config{Accounts: []AccountConfig{{Tasks: []TaskConfig{{Target: "${CHECKIN_UNSET}"}}}}}
+	err := expandVariables(cfg)
+	if err == nil || !strings.HasPrefix(err.Error(), "accounts[0].tasks[0].target:") {
+		t.Errorf("got error %v, want one naming the field", err)
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import "strings"
+
+// TaskFilter selects the accounts and tasks of a run, empty fields match everything
+type TaskFilter struct {
+	Accounts []string // Account names or phone numbers
+	Tags     []string // A task matches if it or its account has any of these tags
+	Tasks    []string // Task names (or targets of unnamed tasks)
+}
+
+// Empty reports whether the filter selects everything
+func (f TaskFilter) Empty() bool {
+	return len(f.Accounts) == 0 && len(f.Tags) == 0 && len(f.Tasks) == 0
+}
+
+// Apply returns a copy of cfg with only the selected accounts and tasks.
+// Accounts left without tasks are dropped.
+func (f TaskFilter) Apply(cfg *Config) *Config {
+	if f.Empty() {
+		return cfg
+	}
+
+	filtered := *cfg
+	filtered.Accounts = nil
+	for _, acc := range cfg.Accounts {
+		if len(f.Accounts) > 0 && !containsFold(f.Accounts, acc.Name) && !containsFold(f.Accounts, acc.Phone) {
+			continue
+		}
+
+		var tasks []TaskConfig
+		for _, task := range acc.Tasks {
+			name := task.Name
+			if name == "" {
+				name = task.Target
+			}
+			if len(f.Tasks) > 0 && !containsFold(f.Tasks, name) {
+				continue
+			}
+			if len(f.Tags) > 0 && !anyFold(f.Tags, acc.Tags) && !anyFold(f.Tags, task.Tags) {
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		acc.Tasks = tasks
+		filtered.Accounts = append(filtered.Accounts, acc)
+	}
+	return &filtered
+}
+
+// TaskCount returns the number of tasks over all accounts
+func (c *Config) TaskCount() int {
+	count := 0
+	for _, acc := range c.Accounts {
+		count += len(acc.Tasks)
+	}
+	return count
+}
+
+func containsFold(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyFold(list []string, values []string) bool {
+	for _, value := range values {
+		if containsFold(list, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// loadIncludes appends the accounts of every file matched by the include patterns.
+// Relative patterns are resolved against the directory of the main config file.
+// An included file holds either a single account or an "accounts" list.
+func loadIncludes(mainPath string, cfg *Config) error {
+	seen := make(map[string]string)
+	for _, acc := range cfg.Accounts {
+		if name := strings.TrimSpace(acc.Name); name != "" {
+			seen[name] = mainPath
+		}
+	}
+
+	// Includes of a remote main config are local files relative to the working directory
+	baseDir := filepath.Dir(mainPath)
+	if IsRemote(mainPath) {
+		baseDir = "."
+	}
+	for _, pattern := range cfg.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			accounts, err := loadAccountsFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to load included file %s: %w", file, err)
+			}
+			for _, acc := range accounts {
+				name := strings.TrimSpace(acc.Name)
+				if prev, ok := seen[name]; ok && name != "" {
+					return fmt.Errorf("account %q in %s is already defined in %s", name, file, prev)
+				}
+				if name != "" {
+					seen[name] = file
+				}
+				cfg.Accounts = append(cfg.Accounts, acc)
+			}
+		}
+	}
+	return nil
+}
+
+// loadAccountsFile reads the accounts defined in an included file
+func loadAccountsFile(path string) ([]AccountConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	plain, format, encrypted, err := decryptFile(path)
+	switch {
+	case err != nil:
+		return nil, err
+	case encrypted:
+		v.SetConfigType(format)
+		err = v.ReadConfig(bytes.NewReader(plain))
+	default:
+		err = v.ReadInConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if v.IsSet("accounts") {
+		var file struct {
+			Accounts []AccountConfig `mapstructure:"accounts"`
+		}
+		if err := v.Unmarshal(&file); err != nil {
+			return nil, err
+		}
+		return file.Accounts, nil
+	}
+
+	var acc AccountConfig
+	if err := v.Unmarshal(&acc); err != nil {
+		return nil, err
+	}
+	return []AccountConfig{acc}, nil
+}
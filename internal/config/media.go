@@ -0,0 +1,71 @@
+package config
+
+import "fmt"
+
+// MediaConfig is the attachment sent by the media method, from a local file, a Bot API
+// file_id or a sticker set. The task payload becomes the caption of photos and GIFs.
+type MediaConfig struct {
+	Type       string `yaml:"type" mapstructure:"type"`               // photo | sticker | gif
+	File       string `yaml:"file" mapstructure:"file"`               // Local file to upload, relative paths are resolved against the working directory
+	FileID     string `yaml:"file_id" mapstructure:"file_id"`         // Bot API file_id of an already uploaded file
+	StickerSet string `yaml:"sticker_set" mapstructure:"sticker_set"` // Sticker method: short name of the set, as in t.me/addstickers/<name>
+	Sticker    string `yaml:"sticker" mapstructure:"sticker"`         // Emoji picking the sticker of sticker_set, default: the first one
+}
+
+// validateMedia checks that media tasks name a type and exactly one source for it
+func validateMedia(cfg *Config) error {
+	for i, acc := range cfg.Accounts {
+		for j, task := range acc.Tasks {
+			if task.Method != "media" {
+				continue
+			}
+			m := task.Media
+			sources := 0
+			for _, s := range []string{m.File, m.FileID, m.StickerSet} {
+				if s != "" {
+					sources++
+				}
+			}
+			switch {
+			case m.Type != "photo" && m.Type != "sticker" && m.Type != "gif":
+				return fmt.Errorf("accounts[%d].tasks[%d]: unknown media.type %q, expected photo, sticker or gif", i, j, m.Type)
+			case sources != 1:
+				return fmt.Errorf("accounts[%d].tasks[%d]: media needs exactly one of file, file_id or sticker_set", i, j)
+			case m.StickerSet != "" && m.Type != "sticker":
+				return fmt.Errorf("accounts[%d].tasks[%d]: media.sticker_set requires type sticker", i, j)
+			case m.Type == "sticker" && task.Payload != "":
+				return fmt.Errorf("accounts[%d].tasks[%d]: stickers cannot have a caption, leave payload empty", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// LocationConfig is the geo point shared by the location method
+type LocationConfig struct {
+	Latitude       float64 `yaml:"latitude" mapstructure:"latitude"`               // Degrees, -90 to 90
+	Longitude      float64 `yaml:"longitude" mapstructure:"longitude"`             // Degrees, -180 to 180
+	JitterMeters   float64 `yaml:"jitter_meters" mapstructure:"jitter_meters"`     // Move the point randomly by up to this distance on every run, default: 0
+	AccuracyMeters int     `yaml:"accuracy_meters" mapstructure:"accuracy_meters"` // Accuracy radius shown with the point, default: none
+}
+
+// validateLocations checks the coordinates of location tasks
+func validateLocations(cfg *Config) error {
+	for i, acc := range cfg.Accounts {
+		for j, task := range acc.Tasks {
+			if task.Method != "location" {
+				continue
+			}
+			l := task.Location
+			switch {
+			case l.Latitude == 0 && l.Longitude == 0:
+				return fmt.Errorf("accounts[%d].tasks[%d]: location needs latitude and longitude", i, j)
+			case l.Latitude < -90 || l.Latitude > 90 || l.Longitude < -180 || l.Longitude > 180:
+				return fmt.Errorf("accounts[%d].tasks[%d]: location %v,%v is out of range", i, j, l.Latitude, l.Longitude)
+			case l.JitterMeters < 0 || l.AccuracyMeters < 0:
+				return fmt.Errorf("accounts[%d].tasks[%d]: location jitter_meters and accuracy_meters must not be negative", i, j)
+			}
+		}
+	}
+	return nil
+}
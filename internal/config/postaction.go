@@ -0,0 +1,46 @@
+package config
+
+import "fmt"
+
+// Post actions, see PostActionConfig
+const (
+	PostActionDeleteSent   = "delete_sent"   // Delete the messages the task sent
+	PostActionClearHistory = "clear_history" // Clear the account's history of the (private) target chat
+)
+
+// PostActionConfig tidies the target chat after a successful check-in
+type PostActionConfig struct {
+	Action       string `yaml:"action" mapstructure:"action"`               // delete_sent | clear_history, empty to disable
+	DelaySeconds int    `yaml:"delay_seconds" mapstructure:"delay_seconds"` // Wait before the action, the task's worker is busy meanwhile
+}
+
+// applyDeleteAfter turns delete_after_seconds into a delete_sent post action
+func applyDeleteAfter(cfg *Config) error {
+	for i := range cfg.Accounts {
+		for j := range cfg.Accounts[i].Tasks {
+			task := &cfg.Accounts[i].Tasks[j]
+			if task.DeleteAfterSeconds <= 0 {
+				continue
+			}
+			if task.PostAction.Action != "" && task.PostAction.Action != PostActionDeleteSent {
+				return fmt.Errorf("accounts[%d].tasks[%d]: delete_after_seconds conflicts with post_action %q", i, j, task.PostAction.Action)
+			}
+			task.PostAction = PostActionConfig{Action: PostActionDeleteSent, DelaySeconds: task.DeleteAfterSeconds}
+		}
+	}
+	return nil
+}
+
+// validatePostActions rejects unknown post_action values
+func validatePostActions(cfg *Config) error {
+	for i, acc := range cfg.Accounts {
+		for j, task := range acc.Tasks {
+			switch task.PostAction.Action {
+			case "", PostActionDeleteSent, PostActionClearHistory:
+			default:
+				return fmt.Errorf("accounts[%d].tasks[%d].post_action: unknown action %q, expected delete_sent or clear_history", i, j, task.PostAction.Action)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import "fmt"
+
+// SendQuotaConfig caps the messages sent to one chat per day, so a schedule firing far more
+// often than intended cannot flood a chat
+type SendQuotaConfig struct {
+	Daily      int            `yaml:"daily" mapstructure:"daily"`             // Messages per day to every target, 0 for no cap
+	Targets    map[string]int `yaml:"targets" mapstructure:"targets"`         // Caps of single targets by @username or ID, replacing daily
+	PerAccount bool           `yaml:"per_account" mapstructure:"per_account"` // Count every account on its own instead of all accounts together
+}
+
+// Limit returns the daily cap of target, 0 when it has none
+func (q SendQuotaConfig) Limit(target string) int {
+	name := TargetName(target)
+	for t, limit := range q.Targets {
+		if TargetName(t) == name {
+			return limit
+		}
+	}
+	return q.Daily
+}
+
+// validateSendQuota rejects negative caps
+func validateSendQuota(q SendQuotaConfig) error {
+	if q.Daily < 0 {
+		return fmt.Errorf("send_quota.daily must not be negative")
+	}
+	for t, limit := range q.Targets {
+		if limit < 0 {
+			return fmt.Errorf("send_quota.targets[%s] must not be negative", t)
+		}
+	}
+	return nil
+}
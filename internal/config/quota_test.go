@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestSendQuotaLimit(t *testing.T) {
+	q := SendQuotaConfig{Daily: 5, Targets: map[string]int{"@PointsBot": 2, "-1001234567890": 0}}
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"@pointsbot", 2},
+		{"pointsbot", 2},
+		{"https://t.me/pointsbot?start=ref", 2},
+		{"-1001234567890", 0},
+		{"@otherbot", 5},
+	}
+	for _, tt := range tests {
+		if got := q.Limit(tt.target); got != tt.want {
+			t.Errorf("Limit(%q) = %d, want %d", tt.target, got, tt.want)
+		}
+	}
+}
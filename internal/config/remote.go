@@ -0,0 +1,200 @@
+package config
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Environment variables used to fetch a main config from an s3:// URL. Without credentials
+// the object is fetched anonymously, e.g. from a public bucket.
+const (
+	EnvS3Endpoint  = "TG_CONFIG_S3_ENDPOINT" // e.g. https://minio.example.com, AWS when unset
+	envS3Region    = "AWS_REGION"
+	envS3AccessKey = "AWS_ACCESS_KEY_ID"
+	envS3SecretKey = "AWS_SECRET_ACCESS_KEY"
+	envS3Token     = "AWS_SESSION_TOKEN"
+)
+
+// remoteTimeout bounds a single fetch of a remote config
+const remoteTimeout = 30 * time.Second
+
+// remoteMaxSize is the largest remote config accepted
+const remoteMaxSize = 4 << 20
+
+// emptyPayloadHash is the SHA-256 of an empty request body, signed into S3 GET requests
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// IsRemote reports whether the config path is an http(s):// or s3:// URL instead of a file
+func IsRemote(path string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowPlainHTTP lets configs be fetched over http://, see SetAllowPlainHTTP
+var allowPlainHTTP atomic.Bool
+
+// SetAllowPlainHTTP lets remote configs be fetched over plain http://. It is refused by
+// default: anyone on the network path could read the secrets of the config or replace its tasks.
+func SetAllowPlainHTTP(allow bool) {
+	allowPlainHTTP.Store(allow)
+}
+
+// RemoteChanged fetches the remote config at path unless its version is still version and
+// returns the new version. version is the ETag of the config, or a hash of its content when
+// the server sends none.
+func RemoteChanged(ctx context.Context, path, version string) (string, bool, error) {
+	_, newVersion, err := fetchRemote(ctx, path, version)
+	if err != nil {
+		return "", false, err
+	}
+	return newVersion, newVersion != version, nil
+}
+
+// fetchRemote downloads the remote config at path. When the server confirms that version is
+// current, content is nil and version is returned unchanged.
+func fetchRemote(ctx context.Context, path, version string) ([]byte, string, error) {
+	if strings.HasPrefix(path, "http://") && !allowPlainHTTP.Load() {
+		return nil, "", fmt.Errorf("refusing to fetch config %s over plain http://, use https:// (pass --unsafe to fetch it anyway)", redactURL(path))
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+	req, err := remoteRequest(ctx, path)
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.HasPrefix(version, `"`) || strings.HasPrefix(version, `W/"`) {
+		req.Header.Set("If-None-Match", version)
+	}
+	if strings.HasPrefix(path, "s3://") {
+		signS3(req, time.Now())
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config %s: %w", redactURL(path), err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, version, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("failed to fetch config %s: HTTP %s", redactURL(path), resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config %s: %w", redactURL(path), err)
+	}
+	if len(content) > remoteMaxSize {
+		return nil, "", fmt.Errorf("config %s is larger than %d bytes", redactURL(path), remoteMaxSize)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return content, etag, nil
+	}
+	sum := sha256.Sum256(content)
+	return content, "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// remoteRequest builds the GET request of a remote config. s3://bucket/key is fetched from
+// EnvS3Endpoint with a path-style URL, or from the virtual-hosted AWS endpoint of the bucket.
+func remoteRequest(ctx context.Context, path string) (*http.Request, error) {
+	if !strings.HasPrefix(path, "s3://") {
+		return http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	}
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(path, "s3://"), "/")
+	if !ok || bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid config URL %s, expected s3://bucket/key", path)
+	}
+	target := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, s3Region(), escapeS3Key(key))
+	if endpoint := os.Getenv(EnvS3Endpoint); endpoint != "" {
+		target = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + escapeS3Key(key)
+	}
+	return http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+}
+
+// s3Region returns the region requests are signed for
+func s3Region() string {
+	if region := os.Getenv(envS3Region); region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+// escapeS3Key escapes every segment of an object key the way S3 signs it
+func escapeS3Key(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
+
+// signS3 adds an AWS Signature Version 4 to req when credentials are set in the environment
+func signS3(req *http.Request, now time.Time) {
+	accessKey, secretKey := os.Getenv(envS3AccessKey), os.Getenv(envS3SecretKey)
+	if accessKey == "" || secretKey == "" {
+		return
+	}
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	region := s3Region()
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if token := os.Getenv(envS3Token); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	// Only the host and the x-amz-* headers are signed, sorted by name
+	names := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("X-Amz-Security-Token") != "" {
+		names = append(names, "x-amz-security-token")
+	}
+	var headers strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canonical := strings.Join([]string{req.Method, req.URL.EscapedPath(), req.URL.RawQuery, headers.String(), signed, emptyPayloadHash}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{day, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signed, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// redactURL drops the credentials of a URL before it is logged
+func redactURL(path string) string {
+	u, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckAllowedTargets returns an error naming the first task whose target or forward_from chat
+// is missing from the allowed_targets of its account, so a typo in a target cannot send
+// check-ins to the wrong chat. Accounts without allowed_targets are not restricted.
+func CheckAllowedTargets(cfg *Config) error {
+	for _, acc := range cfg.Accounts {
+		if len(acc.AllowedTargets) == 0 {
+			continue
+		}
+		allowed := make(map[string]bool, len(acc.AllowedTargets))
+		for _, t := range acc.AllowedTargets {
+			allowed[TargetName(t)] = true
+		}
+		for _, task := range acc.Tasks {
+			for _, target := range taskChats(task) {
+				if !allowed[TargetName(target)] {
+					return fmt.Errorf("task %s of account %s: %q is not in the allowed_targets of the account (pass --unsafe to run it anyway)", task.DisplayName(), acc.Key(), target)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// taskChats returns the chats a task sends to or forwards from, Saved Messages excluded
+func taskChats(task TaskConfig) []string {
+	var chats []string
+	if task.Target != "" {
+		chats = append(chats, task.Target)
+	}
+	if from := strings.TrimSpace(task.ForwardFrom); task.Method == "forward" && from != "" && !strings.EqualFold(from, "me") {
+		chats = append(chats, from)
+	}
+	return chats
+}
+
+// TargetName reduces the forms of a target (@bot, t.me/bot?start=x, https://t.me/bot) to the
+// lower-case username or ID it refers to
+func TargetName(target string) string {
+	target = strings.TrimSpace(target)
+	for _, prefix := range []string{"https://", "http://"} {
+		target = strings.TrimPrefix(target, prefix)
+	}
+	for _, host := range []string{"t.me/", "telegram.me/"} {
+		target = strings.TrimPrefix(target, host)
+	}
+	name, _, _ := strings.Cut(target, "?")
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSuffix(name, "/"), "@"))
+}
+
+// CheckRemoteCommands returns an error naming the first script task or login_code command of a
+// remote config, whoever controls the URL could run any command on the host with them.
+// Disabled tasks count too, they can be enabled at runtime.
+func CheckRemoteCommands(cfg *Config) error {
+	const hint = "(pass --allow-remote-scripts to run it)"
+	if cfg.LoginCode.Source == "command" {
+		return fmt.Errorf("login_code: the remote config runs command %q %s", cfg.LoginCode.Command, hint)
+	}
+	for _, acc := range cfg.Accounts {
+		if acc.LoginCode.Source == "command" {
+			return fmt.Errorf("login_code of account %s: the remote config runs command %q %s", acc.Key(), acc.LoginCode.Command, hint)
+		}
+		for _, task := range acc.Tasks {
+			if task.Method == "script" {
+				return fmt.Errorf("task %s of account %s: the remote config runs script %q %s", task.DisplayName(), acc.Key(), task.Script.Command, hint)
+			}
+		}
+	}
+	return nil
+}
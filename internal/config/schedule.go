@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// runAtLayouts are the accepted formats of run_at, without a zone the local time is used
+var runAtLayouts = []string{"2006-01-02 15:04", "2006-01-02 15:04:05", time.RFC3339}
+
+// applyScheduleDefaults gives tasks without a schedule the default_schedule of their account,
+// or the global one. Tasks with depends_on or run_at are left alone.
+func applyScheduleDefaults(cfg *Config) {
+	for i := range cfg.Accounts {
+		acc := &cfg.Accounts[i]
+		schedule := acc.DefaultSchedule
+		if schedule == "" {
+			schedule = cfg.DefaultSchedule
+		}
+		if schedule == "" {
+			continue
+		}
+		for j := range acc.Tasks {
+			task := acc.Tasks[j]
+			if task.Schedule == "" && task.RunAt == "" && len(task.DependsOn) == 0 {
+				acc.Tasks[j].Schedule = schedule
+			}
+		}
+	}
+}
+
+// ParseRunAt parses the run_at time of a task
+func ParseRunAt(value string) (time.Time, error) {
+	for _, layout := range runAtLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid run_at %q, expected YYYY-MM-DD HH:MM", value)
+}
+
+// validateRunAt checks run_at times and that a task does not set both run_at and schedule
+func validateRunAt(cfg *Config) error {
+	for i, acc := range cfg.Accounts {
+		for j, task := range acc.Tasks {
+			if task.RunAt == "" {
+				continue
+			}
+			if _, err := ParseRunAt(task.RunAt); err != nil {
+				return fmt.Errorf("accounts[%d].tasks[%d]: %w", i, j, err)
+			}
+			if task.Schedule != "" {
+				return fmt.Errorf("accounts[%d].tasks[%d]: run_at and schedule cannot be combined", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// ScheduleAheadConfig queues a message task's payload with Telegram's scheduled messages instead
+// of sending it, so it is delivered even while the daemon is down. Every run of the task tops up
+// the queue and fails when an earlier queued message was not delivered.
+type ScheduleAheadConfig struct {
+	At   string `yaml:"at" mapstructure:"at"`     // Local time of day the message is delivered, e.g. "09:00"; empty disables
+	Days int    `yaml:"days" mapstructure:"days"` // Number of days queued ahead, default: 1
+}
+
+// maxScheduleAheadDays keeps the queue within Telegram's limit of 100 scheduled messages per chat
+const maxScheduleAheadDays = 90
+
+// minScheduleLead skips delivery times too close to be queued reliably
+const minScheduleLead = time.Minute
+
+// Times returns the delivery times to queue after now: the next Days occurrences of At
+func (s ScheduleAheadConfig) Times(now time.Time) ([]time.Time, error) {
+	at, err := time.Parse("15:04", s.At)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule_ahead.at %q, expected HH:MM", s.At)
+	}
+	days := max(s.Days, 1)
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	if next.Sub(now) < minScheduleLead {
+		next = next.AddDate(0, 0, 1)
+	}
+	times := make([]time.Time, 0, days)
+	for range days {
+		times = append(times, next)
+		next = next.AddDate(0, 0, 1)
+	}
+	return times, nil
+}
+
+// validateScheduleAhead checks schedule_ahead times, which only apply to the message method
+func validateScheduleAhead(cfg *Config) error {
+	for i, acc := range cfg.Accounts {
+		for j, task := range acc.Tasks {
+			ahead := task.ScheduleAhead
+			if ahead.At == "" {
+				continue
+			}
+			if _, err := ahead.Times(time.Now()); err != nil {
+				return fmt.Errorf("accounts[%d].tasks[%d]: %w", i, j, err)
+			}
+			if ahead.Days > maxScheduleAheadDays {
+				return fmt.Errorf("accounts[%d].tasks[%d]: schedule_ahead.days must be at most %d", i, j, maxScheduleAheadDays)
+			}
+			if task.Method != "message" || len(task.Messages) > 0 {
+				return fmt.Errorf("accounts[%d].tasks[%d]: schedule_ahead requires the message method with a payload", i, j)
+			}
+		}
+	}
+	return nil
+}
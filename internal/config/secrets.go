@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Secret reference prefixes, e.g. "env:TG_PASSWORD" or "file:/run/secrets/app_hash"
+const (
+	secretEnvPrefix  = "env:"
+	secretFilePrefix = "file:"
+)
+
+// ResolveSecret expands an env:VAR_NAME or file:/path reference, other values are returned unchanged.
+// Trailing whitespace of file contents is trimmed.
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := strings.TrimPrefix(value, secretFilePrefix)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimRight(string(content), " \t\r\n"), nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveSecrets replaces secret references in the fields that may hold credentials
+func resolveSecrets(cfg *Config) error {
+	resolve := func(field string, value *string) error {
+		secret, err := ResolveSecret(*value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		*value = secret
+		return nil
+	}
+
+	if err := resolve("app_hash", &cfg.AppHash); err != nil {
+		return err
+	}
+	if err := resolve("proxy", &cfg.Proxy); err != nil {
+		return err
+	}
+	for i := range cfg.ProxyFallbacks {
+		if err := resolve(fmt.Sprintf("proxy_fallbacks[%d]", i), &cfg.ProxyFallbacks[i]); err != nil {
+			return err
+		}
+	}
+	if err := resolve("control.token", &cfg.Control.Token); err != nil {
+		return err
+	}
+	for i := range cfg.Notifications {
+		n := &cfg.Notifications[i]
+		prefix := fmt.Sprintf("notifications[%d]", i)
+		if err := resolve(prefix+".bot_token", &n.BotToken); err != nil {
+			return err
+		}
+		if err := resolve(prefix+".url", &n.URL); err != nil {
+			return err
+		}
+		if err := resolve(prefix+".token", &n.Token); err != nil {
+			return err
+		}
+		if err := resolveHeaders(prefix+".headers", n.Headers, resolve); err != nil {
+			return err
+		}
+	}
+	for i := range cfg.Log.Outputs {
+		out := &cfg.Log.Outputs[i]
+		prefix := fmt.Sprintf("log.outputs[%d]", i)
+		if err := resolve(prefix+".url", &out.URL); err != nil {
+			return err
+		}
+		if err := resolveHeaders(prefix+".headers", out.Headers, resolve); err != nil {
+			return err
+		}
+	}
+	for i := range cfg.Accounts {
+		acc := &cfg.Accounts[i]
+		if err := resolve(fmt.Sprintf("accounts[%d].password", i), &acc.Password); err != nil {
+			return err
+		}
+		if err := resolve(fmt.Sprintf("accounts[%d].app_hash", i), &acc.AppHash); err != nil {
+			return err
+		}
+		for j := range acc.Tasks {
+			task := &acc.Tasks[j]
+			prefix := fmt.Sprintf("accounts[%d].tasks[%d]", i, j)
+			if err := resolveHeaders(prefix+".webapp.headers", task.WebApp.Headers, resolve); err != nil {
+				return err
+			}
+			if err := resolve(prefix+".webhook.url", &task.Webhook.URL); err != nil {
+				return err
+			}
+			if err := resolve(prefix+".ping_url", &task.PingURL); err != nil {
+				return err
+			}
+			if err := resolveHeaders(prefix+".script.env", task.Script.Env, resolve); err != nil {
+				return err
+			}
+			if err := resolveHeaders(prefix+".webhook.headers", task.Webhook.Headers, resolve); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func resolveHeaders(field string, headers map[string]string, resolve func(string, *string) error) error {
+	for name, value := range headers {
+		if err := resolve(field+"."+name, &value); err != nil {
+			return err
+		}
+		headers[name] = value
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// dateLayout is the format of skip dates
+const dateLayout = "2006-01-02"
+
+// SkipConfig suppresses the scheduled runs of a task on some days, e.g. weekends or maintenance
+// windows. Manual and run_on_start runs are not affected.
+type SkipConfig struct {
+	Weekdays     []string `yaml:"weekdays" mapstructure:"weekdays"`           // Days of the week: mon, tue, wed, thu, fri, sat, sun
+	Dates        []string `yaml:"dates" mapstructure:"dates"`                 // Dates (2026-12-25) or inclusive ranges (2026-12-24..2026-12-26)
+	CalendarFile string   `yaml:"calendar_file" mapstructure:"calendar_file"` // File with one date or range per line, # starts a comment; re-read on every run
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Skips reports whether a scheduled run at t is suppressed, along with the matching rule
+func (s SkipConfig) Skips(t time.Time) (bool, string, error) {
+	for _, day := range s.Weekdays {
+		if wd, ok := parseWeekday(day); ok && wd == t.Weekday() {
+			return true, day, nil
+		}
+	}
+	for _, date := range s.Dates {
+		if matchDate(date, t) {
+			return true, date, nil
+		}
+	}
+	if s.CalendarFile == "" {
+		return false, "", nil
+	}
+	dates, err := readCalendar(s.CalendarFile)
+	if err != nil {
+		return false, "", err
+	}
+	for _, date := range dates {
+		if matchDate(date, t) {
+			return true, s.CalendarFile + ": " + date, nil
+		}
+	}
+	return false, "", nil
+}
+
+// parseWeekday accepts three-letter and full English day names in any case
+func parseWeekday(day string) (time.Weekday, bool) {
+	day = strings.ToLower(strings.TrimSpace(day))
+	if len(day) > 3 {
+		day = day[:3]
+	}
+	wd, ok := weekdays[day]
+	return wd, ok
+}
+
+// parseDateRange parses a date or an inclusive "from..to" range
+func parseDateRange(date string) (from, to time.Time, err error) {
+	start, end, isRange := strings.Cut(strings.TrimSpace(date), "..")
+	if from, err = time.Parse(dateLayout, strings.TrimSpace(start)); err != nil {
+		return
+	}
+	to = from
+	if isRange {
+		if to, err = time.Parse(dateLayout, strings.TrimSpace(end)); err != nil {
+			return
+		}
+		if to.Before(from) {
+			err = fmt.Errorf("range %q ends before it starts", date)
+		}
+	}
+	return
+}
+
+// matchDate reports whether the calendar day of t lies in date, invalid dates never match
+func matchDate(date string, t time.Time) bool {
+	from, to, err := parseDateRange(date)
+	if err != nil {
+		return false
+	}
+	day, _ := time.Parse(dateLayout, t.Format(dateLayout))
+	return !day.Before(from) && !day.After(to)
+}
+
+// readCalendar returns the dates and ranges listed in a calendar file
+func readCalendar(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read skip calendar: %w", err)
+	}
+	defer f.Close()
+
+	var dates []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			dates = append(dates, line)
+		}
+	}
+	return dates, scanner.Err()
+}
+
+// validateSkipRules rejects unknown weekdays and malformed dates, so mistakes surface at startup
+// rather than as runs that are silently not skipped
+func validateSkipRules(cfg *Config) error {
+	for i, acc := range cfg.Accounts {
+		for j, task := range acc.Tasks {
+			prefix := fmt.Sprintf("accounts[%d].tasks[%d].skip", i, j)
+			for _, day := range task.Skip.Weekdays {
+				if _, ok := parseWeekday(day); !ok {
+					return fmt.Errorf("%s: unknown weekday %q", prefix, day)
+				}
+			}
+			dates := task.Skip.Dates
+			if task.Skip.CalendarFile != "" {
+				calendar, err := readCalendar(task.Skip.CalendarFile)
+				if err != nil {
+					return fmt.Errorf("%s: %w", prefix, err)
+				}
+				dates = append(dates[:len(dates):len(dates)], calendar...)
+			}
+			for _, date := range dates {
+				if _, _, err := parseDateRange(date); err != nil {
+					return fmt.Errorf("%s: invalid date %q, expected YYYY-MM-DD or YYYY-MM-DD..YYYY-MM-DD", prefix, date)
+				}
+			}
+		}
+	}
+	return nil
+}
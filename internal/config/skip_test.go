@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSkips(t *testing.T) {
+	calendar := filepath.Join(t.TempDir(), "holidays.txt")
+	content := "# Public holidays\n2026-01-01\n\n2026-05-01..2026-05-03 # long weekend\n"
+	if err := os.WriteFile(calendar, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2026-05-02 is a Saturday
+	saturday := time.Date(2026, 5, 2, 8, 0, 0, 0, time.Local)
+	monday := time.Date(2026, 5, 4, 8, 0, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		skip     SkipConfig
+		at       time.Time
+		want     bool
+		wantRule string
+	}{
+		{name: "no rules", at: saturday},
+		{name: "weekday", skip: SkipConfig{Weekdays: []string{"sat", "sun"}}, at: saturday, want: true, wantRule: "sat"},
+		{name: "full weekday name", skip: SkipConfig{Weekdays: []string{"Saturday"}}, at: saturday, want: true, wantRule: "Saturday"},
+		{name: "other weekday", skip: SkipConfig{Weekdays: []string{"sat", "sun"}}, at: monday},
+		{name: "date", skip: SkipConfig{Dates: []string{"2026-05-04"}}, at: monday, want: true, wantRule: "2026-05-04"},
+		{name: "range end is inclusive", skip: SkipConfig{Dates: []string{"2026-05-01..2026-05-04"}}, at: monday.Add(15 * time.Hour), want: true, wantRule: "2026-05-01..2026-05-04"},
+		{name: "after range", skip: SkipConfig{Dates: []string{"2026-05-01..2026-05-03"}}, at: monday},
+		{name: "invalid date never matches", skip: SkipConfig{Dates: []string{"05/04/2026"}}, at: monday},
+		{name: "calendar range", skip: SkipConfig{CalendarFile: calendar}, at: saturday, want: true, wantRule: calendar + ": 2026-05-01..2026-05-03"},
+		{name: "not in calendar", skip: SkipConfig{CalendarFile: calendar}, at: monday},
+	}
+	for _, tt := range tests {
+		got, rule, err := tt.skip.Skips(tt.at)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want || rule != tt.wantRule {
+			t.Errorf("%s: got %v (%q), want %v (%q)", tt.name, got, rule, tt.want, tt.wantRule)
+		}
+	}
+
+	if _, _, err := (SkipConfig{CalendarFile: filepath.Join(t.TempDir(), "missing.txt")}).Skips(monday); err == nil {
+		t.Error("missing calendar file, want an error")
+	}
+}
+
+func TestValidateSkipRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		skip    SkipConfig
+		wantErr string
+	}{
+		{name: "valid", skip: SkipConfig{Weekdays: []string{"mon", "Sunday"}, Dates: []string{"2026-12-25", "2026-12-30..2027-01-01"}}},
+		{name: "unknown weekday", skip: SkipConfig{Weekdays: []string{"someday"}}, wantErr: `unknown weekday "someday"`},
+		{name: "malformed date", skip: SkipConfig{Dates: []string{"2026-13-01"}}, wantErr: `invalid date "2026-13-01"`},
+		{name: "backwards range", skip: SkipConfig{Dates: []string{"2026-12-26..2026-12-24"}}, wantErr: "invalid date"},
+		{name: "missing calendar", skip: SkipConfig{CalendarFile: filepath.Join(t.TempDir(), "missing.txt")}, wantErr: "failed to read skip calendar"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Accounts: []AccountConfig{{Tasks: []TaskConfig{{Target: "@bot", Skip: tt.skip}}}}}
+		err := validateSkipRules(cfg)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: got error %v, want none", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.HasPrefix(err.Error(), "accounts[0].tasks[0].skip") {
+			t.Errorf("%s: got error %v, want one containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EnvSOPSBinary overrides the sops binary used to decrypt encrypted configs, "sops" from PATH
+// by default. sops itself finds the age key in SOPS_AGE_KEY_FILE or SOPS_AGE_KEY.
+const EnvSOPSBinary = "TG_SOPS_BINARY"
+
+// sopsMarker starts every value SOPS encrypted
+const sopsMarker = "ENC[AES256_GCM,data:"
+
+// sopsTimeout bounds a run of sops, which may ask a KMS for the data key
+const sopsTimeout = 30 * time.Second
+
+// isEncrypted reports whether a config file is encrypted with SOPS
+func isEncrypted(content []byte) bool {
+	return bytes.Contains(content, []byte(sopsMarker))
+}
+
+// decryptFile reads the config file at path and returns its decrypted content and format when
+// it is encrypted with SOPS, and false when it is a plain file
+func decryptFile(path string) ([]byte, string, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil || !isEncrypted(content) {
+		// A plain or unreadable file is left to viper, which reports the error
+		return nil, "", false, nil
+	}
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	if format != "json" {
+		format = "yaml"
+	}
+	plain, err := decryptSOPS(content, format)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("failed to decrypt config file %s: %w", path, err)
+	}
+	return plain, format, true, nil
+}
+
+// decryptSOPS decrypts a SOPS-encrypted config in format (yaml or json) with the sops binary.
+// The encrypted content is passed through a temporary file, the plain text is only kept in memory.
+func decryptSOPS(content []byte, format string) ([]byte, error) {
+	binary := os.Getenv(EnvSOPSBinary)
+	if binary == "" {
+		binary = "sops"
+	}
+	if _, err := exec.LookPath(binary); err != nil {
+		return nil, fmt.Errorf("config is encrypted with SOPS but %s was not found, install sops or set %s: %w", binary, EnvSOPSBinary, err)
+	}
+
+	tmp, err := os.CreateTemp("", "config-*."+format)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sopsTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, "--decrypt", "--input-type", format, "--output-type", format, tmp.Name())
+	cmd.Stderr = &stderr
+	plain, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("sops: %s (is SOPS_AGE_KEY_FILE or SOPS_AGE_KEY set?)", msg)
+		}
+		return nil, fmt.Errorf("sops: %w", err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestIsEncrypted(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"plain yaml", "app_id: 123\napp_hash: abc\n", false},
+		{"encrypted yaml", "app_hash: ENC[AES256_GCM,data:q8Zk,iv:Tq,tag:Ag==,type:str]\nsops:\n  version: 3.9.0\n", true},
+		{"encrypted json", `{"app_hash": "ENC[AES256_GCM,data:q8Zk,iv:Tq,tag:Ag==,type:str]"}`, true},
+		{"other cipher", "app_hash: ENC[AES128,data:q8Zk]\n", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		if got := isEncrypted([]byte(tt.content)); got != tt.want {
+			t.Errorf("%s: isEncrypted = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// fakeSOPS installs a sops binary printing out, or failing with stderr, and returns the file
+// the arguments it was called with are written to
+func fakeSOPS(t *testing.T, out, stderr string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake sops binary is a shell script")
+	}
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + args + "\n"
+	if stderr != "" {
+		script += "echo '" + stderr + "' >&2\nexit 1\n"
+	} else {
+		script += "cat <<'EOF'\n" + out + "EOF\n"
+	}
+	binary := filepath.Join(dir, "sops")
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvSOPSBinary, binary)
+	return args
+}
+
+func TestDecryptFile(t *testing.T) {
+	const encrypted = "app_hash: ENC[AES256_GCM,data:q8Zk,iv:Tq,tag:Ag==,type:str]\n"
+	tests := []struct {
+		name          string
+		file          string
+		content       string
+		sopsOut       string
+		sopsErr       string
+		wantEncrypted bool
+		wantPlain     string
+		wantFormat    string
+		wantErr       string
+	}{
+		{name: "plain file is left alone", file: "config.yaml", content: "app_hash: abc\n"},
+		{name: "missing file is left to viper", file: ""},
+		{name: "yaml", file: "config.yaml", content: encrypted, sopsOut: "app_hash: abc\n", wantEncrypted: true, wantPlain: "app_hash: abc\n", wantFormat: "yaml"},
+		{name: "json", file: "config.json", content: encrypted, sopsOut: "{}\n", wantEncrypted: true, wantPlain: "{}\n", wantFormat: "json"},
+		{name: "sops fails", file: "config.yaml", content: encrypted, sopsErr: "no key could decrypt the data key", wantEncrypted: true, wantErr: "no key could decrypt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := fakeSOPS(t, tt.sopsOut, tt.sopsErr)
+			path := filepath.Join(t.TempDir(), "missing.yaml")
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), tt.file)
+				if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			plain, format, encrypted, err := decryptFile(path)
+			if encrypted != tt.wantEncrypted {
+				t.Errorf("got encrypted %v, want %v", encrypted, tt.wantEncrypted)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decrypt failed: %v", err)
+			}
+			if string(plain) != tt.wantPlain || format != tt.wantFormat {
+				t.Errorf("got %q as %q, want %q as %q", plain, format, tt.wantPlain, tt.wantFormat)
+			}
+			if tt.wantEncrypted {
+				called, _ := os.ReadFile(args)
+				if !strings.HasPrefix(string(called), "--decrypt --input-type "+tt.wantFormat+" --output-type "+tt.wantFormat+" ") {
+					t.Errorf("sops called with %q", called)
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptSOPSMissingBinary(t *testing.T) {
+	t.Setenv(EnvSOPSBinary, filepath.Join(t.TempDir(), "sops"))
+	_, err := decryptSOPS([]byte("app_hash: ENC[AES256_GCM,data:q8Zk]\n"), "yaml")
+	if err == nil || !strings.Contains(err.Error(), "was not found") {
+		t.Fatalf("got error %v, want the missing binary reported", err)
+	}
+}
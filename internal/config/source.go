@@ -0,0 +1,90 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+// Environment variables carrying the whole main config, for containers that cannot mount a file.
+// TG_CONFIG_YAML holds the YAML itself, TG_CONFIG_BASE64 the base64 encoded YAML.
+const (
+	EnvConfigYAML   = "TG_CONFIG_YAML"
+	EnvConfigBase64 = "TG_CONFIG_BASE64"
+)
+
+// ContainerPath is the well-known config mount used when no config path is given explicitly
+const ContainerPath = "/config/config.yaml"
+
+// ResolvePath returns the config file to use: ContainerPath when it exists and path is only the
+// default of the --config flag, path otherwise
+func ResolvePath(path string, explicit bool) string {
+	if explicit {
+		return path
+	}
+	if _, err := os.Stat(ContainerPath); err == nil {
+		return ContainerPath
+	}
+	return path
+}
+
+// readMainConfig reads the main config from the environment when set, or from the file or URL
+// at path, decrypting it when it is encrypted with SOPS. For a URL it returns the version of
+// the fetched config.
+func readMainConfig(path string, v *viper.Viper) (string, error) {
+	content, source, err := envConfig()
+	if err != nil {
+		return "", err
+	}
+	var version string
+	if content == nil && IsRemote(path) {
+		content, version, err = fetchRemote(context.Background(), path, "")
+		if err != nil {
+			return "", err
+		}
+		source = redactURL(path)
+	}
+	if content == nil {
+		v.SetConfigFile(path)
+		plain, format, encrypted, err := decryptFile(path)
+		switch {
+		case err != nil:
+			return "", err
+		case encrypted:
+			v.SetConfigType(format)
+			return "", v.ReadConfig(bytes.NewReader(plain))
+		default:
+			return "", v.ReadInConfig()
+		}
+	}
+	if isEncrypted(content) {
+		if content, err = decryptSOPS(content, "yaml"); err != nil {
+			return "", fmt.Errorf("failed to decrypt config in %s: %w", source, err)
+		}
+	}
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return "", fmt.Errorf("invalid config in %s: %w", source, err)
+	}
+	return version, nil
+}
+
+// envConfig returns the config content from the environment and the variable it came from,
+// nil when neither variable is set
+func envConfig() ([]byte, string, error) {
+	if content := os.Getenv(EnvConfigYAML); content != "" {
+		return []byte(content), EnvConfigYAML, nil
+	}
+	if encoded := os.Getenv(EnvConfigBase64); encoded != "" {
+		content, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid %s: %w", EnvConfigBase64, err)
+		}
+		return content, EnvConfigBase64, nil
+	}
+	return nil, "", nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// applyTaskTemplates expands tasks that reference a task_templates entry.
+// Every non-zero field of the task overrides the template, so boolean options can
+// only be switched on by an override (use enabled: false to disable a templated task).
+func applyTaskTemplates(cfg *Config) error {
+	for i := range cfg.Accounts {
+		acc := &cfg.Accounts[i]
+		for j, task := range acc.Tasks {
+			if task.Template == "" {
+				continue
+			}
+			// Viper lowercases map keys, so template names are case-insensitive
+			tmpl, ok := cfg.TaskTemplates[strings.ToLower(task.Template)]
+			if !ok {
+				return fmt.Errorf("accounts[%d].tasks[%d]: unknown task template %q", i, j, task.Template)
+			}
+			acc.Tasks[j] = overlayTask(tmpl, task)
+		}
+	}
+	return nil
+}
+
+// overlayTask returns base with every non-zero field of override applied. The result shares
+// no slice or map with base, so the tasks expanded from one template stay independent.
+func overlayTask(base, override TaskConfig) TaskConfig {
+	merged := base
+	dst := reflect.ValueOf(&merged).Elem()
+	src := reflect.ValueOf(override)
+	for i := 0; i < src.NumField(); i++ {
+		if field := src.Field(i); !field.IsZero() {
+			dst.Field(i).Set(field)
+		}
+	}
+	return copyValue(dst).Interface().(TaskConfig)
+}
+
+// copyValue returns a deep copy of v: slices, maps and pointers are copied instead of shared
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+		return c
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyValue(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < c.NumField(); i++ {
+			// Unexported fields, e.g. of time.Time, are kept as they are
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(copyValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
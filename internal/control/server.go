@@ -0,0 +1,222 @@
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/login"
+	"telegram-auto-checkin/internal/state"
+)
+
+// Server is the HTTP control API of a running daemon
+type Server struct {
+	cfg   *config.Config
+	state *state.State
+	log   zerolog.Logger
+	mux   *http.ServeMux
+}
+
+// taskStatus is a task entry of GET /api/toggles
+type taskStatus struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`  // Effective state
+	Override *bool  `json:"override"` // Runtime toggle, null when the config value applies
+}
+
+// accountStatus is an account entry of GET /api/toggles
+type accountStatus struct {
+	Account  string       `json:"account"`
+	Enabled  bool         `json:"enabled"` // Effective state
+	Override *bool        `json:"override"`
+	Tasks    []taskStatus `json:"tasks"`
+}
+
+// NewServer creates the control API for the given config and runtime state
+func NewServer(cfg *config.Config, st *state.State, log zerolog.Logger) *Server {
+	s := &Server{
+		cfg:   cfg,
+		state: st,
+		log:   log.With().Str("component", "control").Logger(),
+		mux:   http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /api/toggles", s.handleToggles)
+	s.mux.HandleFunc("POST /api/accounts/{account}/{action}", s.handleAccountToggle)
+	s.mux.HandleFunc("GET /api/login-codes", s.handlePendingLogins)
+	s.mux.HandleFunc("POST /api/accounts/{account}/login-code", s.handleLoginCode)
+	s.mux.HandleFunc("POST /api/tasks/{account}/{task}/{action}", s.handleTaskToggle)
+	return s
+}
+
+// Run serves the API on addr until ctx is cancelled
+func (s *Server) Run(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.authenticate(s.mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	s.log.Info().Str("listen", addr).Bool("auth", s.cfg.Control.Token != "").Msg(i18n.T("control_started"))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authenticate requires the configured bearer token on every request
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	token := s.cfg.Control.Token
+	if token == "" {
+		return next
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleToggles(w http.ResponseWriter, r *http.Request) {
+	accounts := make([]accountStatus, 0, len(s.cfg.Accounts))
+	for _, acc := range s.cfg.Accounts {
+		status := accountStatus{Account: acc.Key(), Enabled: state.AccountActive(s.state, acc), Tasks: []taskStatus{}}
+		if enabled, ok := s.state.AccountEnabled(acc.Key()); ok {
+			status.Override = &enabled
+		}
+		for _, task := range acc.Tasks {
+			ts := taskStatus{Name: task.DisplayName(), Enabled: state.TaskActive(s.state, acc, task)}
+			if enabled, ok := s.state.TaskEnabled(acc.Key(), task.DisplayName()); ok {
+				ts.Override = &enabled
+			}
+			status.Tasks = append(status.Tasks, ts)
+		}
+		accounts = append(accounts, status)
+	}
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+func (s *Server) handleAccountToggle(w http.ResponseWriter, r *http.Request) {
+	acc, ok := s.findAccount(r.PathValue("account"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+	override, ok := parseAction(r.PathValue("action"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "action must be enable, disable or reset")
+		return
+	}
+	if err := s.state.SetAccount(acc.Key(), override); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.log.Info().Str("account", acc.Key()).Str("action", r.PathValue("action")).Msg(i18n.T("account_toggled"))
+	writeJSON(w, http.StatusOK, map[string]any{"account": acc.Key(), "override": override})
+}
+
+func (s *Server) handleTaskToggle(w http.ResponseWriter, r *http.Request) {
+	acc, ok := s.findAccount(r.PathValue("account"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+	taskName := r.PathValue("task")
+	found := false
+	for _, task := range acc.Tasks {
+		if task.DisplayName() == taskName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+	override, ok := parseAction(r.PathValue("action"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "action must be enable, disable or reset")
+		return
+	}
+	if err := s.state.SetTask(acc.Key(), taskName, override); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.log.Info().Str("account", acc.Key()).Str("task", taskName).Str("action", r.PathValue("action")).Msg(i18n.T("task_toggled"))
+	writeJSON(w, http.StatusOK, map[string]any{"account": acc.Key(), "task": taskName, "override": override})
+}
+
+func (s *Server) handlePendingLogins(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"pending": login.Pending()})
+}
+
+func (s *Server) handleLoginCode(w http.ResponseWriter, r *http.Request) {
+	acc, ok := s.findAccount(r.PathValue("account"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil || body.Code == "" {
+		writeError(w, http.StatusBadRequest, `body must be {"code": "..."}`)
+		return
+	}
+	if err := login.SubmitCode(acc.Key(), body.Code); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	s.log.Info().Str("account", acc.Key()).Msg(i18n.T("login_code_submitted"))
+	writeJSON(w, http.StatusOK, map[string]any{"account": acc.Key(), "submitted": true})
+}
+
+func (s *Server) findAccount(key string) (config.AccountConfig, bool) {
+	for _, acc := range s.cfg.Accounts {
+		if acc.Key() == key {
+			return acc, true
+		}
+	}
+	return config.AccountConfig{}, false
+}
+
+// parseAction maps enable/disable/reset to the override to store
+func parseAction(action string) (*bool, bool) {
+	switch action {
+	case "enable":
+		enabled := true
+		return &enabled, true
+	case "disable":
+		enabled := false
+		return &enabled, true
+	case "reset":
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,67 @@
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Report describes a recovered panic
+type Report struct {
+	Time      time.Time
+	Component string // Where the panic was recovered, e.g. "task", "worker", "session"
+	Account   string
+	Task      string // Empty outside a task
+	Value     any    // Value passed to panic
+	Stack     []byte
+}
+
+// Capture builds a report for a recovered panic, call it in the deferred function that recovered
+// so the stack still shows where the panic happened
+func Capture(component, account, task string, value any) Report {
+	return Report{
+		Time:      time.Now(),
+		Component: component,
+		Account:   account,
+		Task:      task,
+		Value:     value,
+		Stack:     debug.Stack(),
+	}
+}
+
+// Error returns a one-line summary, so a report can be returned as the error of the crashed work
+func (r Report) Error() string {
+	return fmt.Sprintf("%s panic: %v", r.Component, r.Value)
+}
+
+// Dir returns the directory crash files are written to for the given log directory
+func Dir(logDir string) string {
+	return filepath.Join(logDir, "crash")
+}
+
+// Write saves the report with its stack trace as a new file in dir and returns the file path
+func Write(dir string, r Report) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", r.Time.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "component: %s\n", r.Component)
+	if r.Account != "" {
+		fmt.Fprintf(&b, "account: %s\n", r.Account)
+	}
+	if r.Task != "" {
+		fmt.Fprintf(&b, "task: %s\n", r.Task)
+	}
+	fmt.Fprintf(&b, "panic: %v\n\n%s", r.Value, r.Stack)
+
+	path := filepath.Join(dir, fmt.Sprintf("crash_%s_%s.log", r.Time.Format("20060102_150405.000000"), r.Component))
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
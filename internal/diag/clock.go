@@ -0,0 +1,51 @@
+package diag
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// ClockSkewWarn is the skew of the local clock worth a warning, MTProto rejects requests
+// once it reaches minutes (AUTH_KEY and msg_id errors)
+const ClockSkewWarn = 30 * time.Second
+
+// clockURL serves the Date header the local clock is compared with
+const clockURL = "https://telegram.org"
+
+// ClockSkew returns how far the local clock is ahead of telegram.org (negative when behind),
+// asked through the SOCKS5 proxy when proxyAddr is set. The result is accurate to a second.
+func ClockSkew(ctx context.Context, proxyAddr string) (time.Duration, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyAddr != "" {
+		dialer, err := proxy.SOCKS5("tcp", proxyAddr, nil, proxy.Direct)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create proxy dialer: %w", err)
+		}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.Dial(network, addr)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, clockURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	server, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("no usable Date header from %s", clockURL)
+	}
+	// The header is truncated to the second, half a second is its expected error
+	return time.Since(server.Add(500 * time.Millisecond)).Round(time.Second), nil
+}
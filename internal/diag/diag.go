@@ -0,0 +1,156 @@
+package diag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/events"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/pkg/executor"
+)
+
+// Snapshot is the self-report of the running process
+type Snapshot struct {
+	Time         time.Time             `json:"time"`
+	Uptime       string                `json:"uptime"`
+	Goroutines   int                   `json:"goroutines"`
+	HeapAlloc    uint64                `json:"heap_alloc"`     // Bytes of allocated heap objects
+	HeapInuse    uint64                `json:"heap_inuse"`     // Bytes in in-use heap spans
+	Sys          uint64                `json:"sys"`            // Bytes obtained from the OS
+	NumGC        uint32                `json:"num_gc"`         // Completed GC cycles
+	OpenTaskLogs int64                 `json:"open_task_logs"` // Task log files currently open
+	Queues       []executor.QueueStats `json:"queues"`         // Task queues of the running executors
+	Events       EventCounts           `json:"events"`         // Task events since the start
+}
+
+// EventCounts are the task events published since the process started
+type EventCounts struct {
+	TasksStarted   int64 `json:"tasks_started"`
+	TasksSucceeded int64 `json:"tasks_succeeded"`
+	TasksFailed    int64 `json:"tasks_failed"`
+	FloodWaits     int64 `json:"flood_waits"`
+	AuthRequired   int64 `json:"auth_required"`
+}
+
+var (
+	started = time.Now()
+
+	tasksStarted, tasksSucceeded, tasksFailed, floodWaits, authRequired atomic.Int64
+)
+
+// CountEvent is an events.Bus subscriber counting task events for the snapshot
+func CountEvent(ev events.Event) {
+	switch ev := ev.(type) {
+	case events.TaskStarted:
+		tasksStarted.Add(1)
+	case events.TaskFinished:
+		if ev.Success() {
+			tasksSucceeded.Add(1)
+		} else {
+			tasksFailed.Add(1)
+		}
+	case events.FloodWait:
+		floodWaits.Add(1)
+	case events.AuthRequired:
+		authRequired.Add(1)
+	}
+}
+
+// Collect takes a snapshot of the process
+func Collect() Snapshot {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return Snapshot{
+		Time:         time.Now(),
+		Uptime:       time.Since(started).Round(time.Second).String(),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		Sys:          mem.Sys,
+		NumGC:        mem.NumGC,
+		OpenTaskLogs: executor.OpenTaskLogs(),
+		Queues:       executor.Stats(),
+		Events: EventCounts{
+			TasksStarted:   tasksStarted.Load(),
+			TasksSucceeded: tasksSucceeded.Load(),
+			TasksFailed:    tasksFailed.Load(),
+			FloodWaits:     floodWaits.Load(),
+			AuthRequired:   authRequired.Load(),
+		},
+	}
+}
+
+// Report logs a snapshot every interval until ctx is cancelled
+func Report(ctx context.Context, interval time.Duration, log zerolog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snap := Collect()
+		queued := 0
+		for _, q := range snap.Queues {
+			queued += q.Queued
+		}
+		log.Info().
+			Str("uptime", snap.Uptime).
+			Int("goroutines", snap.Goroutines).
+			Uint64("heap_alloc_kb", snap.HeapAlloc/1024).
+			Uint64("heap_inuse_kb", snap.HeapInuse/1024).
+			Uint64("sys_kb", snap.Sys/1024).
+			Uint32("num_gc", snap.NumGC).
+			Int64("open_task_logs", snap.OpenTaskLogs).
+			Int("executors", len(snap.Queues)).
+			Int("queued", queued).
+			Int64("tasks_failed", snap.Events.TasksFailed).
+			Int64("flood_waits", snap.Events.FloodWaits).
+			Msg("Self-report")
+	}
+}
+
+// Serve exposes the pprof profiles under /debug/pprof/ and the snapshot as JSON under
+// /debug/stats on addr until ctx is cancelled
+func Serve(ctx context.Context, addr string, log zerolog.Logger) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(Collect())
+	})
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info().Str("listen", addr).Msg(i18n.T("diag_started"))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
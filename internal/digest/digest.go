@@ -0,0 +1,103 @@
+package digest
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-auto-checkin/internal/history"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/notify"
+)
+
+// accountSummary aggregates the runs of one account
+type accountSummary struct {
+	Succeeded int
+	Failed    int
+	Failures  []string           // Names of failed tasks, in run order without duplicates
+	Totals    map[string]float64 // Sums of extracted values
+}
+
+// Build aggregates the records since the given time into a digest message.
+// Failures raise the message priority.
+func Build(records []history.Record, since time.Time) notify.Message {
+	summaries := make(map[string]*accountSummary)
+	for _, rec := range records {
+		if rec.Time.Before(since) {
+			continue
+		}
+		sum, ok := summaries[rec.Account]
+		if !ok {
+			sum = &accountSummary{Totals: make(map[string]float64)}
+			summaries[rec.Account] = sum
+		}
+		if rec.Success {
+			sum.Succeeded++
+		} else {
+			sum.Failed++
+			if !slices.Contains(sum.Failures, rec.Task) {
+				sum.Failures = append(sum.Failures, rec.Task)
+			}
+		}
+		for key, value := range rec.Extracted {
+			sum.Totals[key] += value
+		}
+	}
+
+	msg := notify.Message{Title: i18n.T("digest_title", map[string]any{"Date": since.Format("2006-01-02")})}
+	if len(summaries) == 0 {
+		msg.Text = i18n.T("digest_no_tasks")
+		return msg
+	}
+
+	accounts := make([]string, 0, len(summaries))
+	for account := range summaries {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	var b strings.Builder
+	totalOK, totalFailed := 0, 0
+	for _, account := range accounts {
+		sum := summaries[account]
+		totalOK += sum.Succeeded
+		totalFailed += sum.Failed
+
+		status := "✅"
+		if sum.Failed > 0 {
+			status = "❌"
+		}
+		b.WriteString(i18n.T("digest_account", map[string]any{"Status": status, "Account": account, "Succeeded": sum.Succeeded, "Failed": sum.Failed}) + "\n")
+		if len(sum.Failures) > 0 {
+			b.WriteString("   " + i18n.T("digest_failed_tasks", map[string]any{"Tasks": strings.Join(sum.Failures, ", ")}) + "\n")
+		}
+		if len(sum.Totals) > 0 {
+			keys := make([]string, 0, len(sum.Totals))
+			for key := range sum.Totals {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			parts := make([]string, len(keys))
+			for i, key := range keys {
+				parts[i] = key + " " + strconv.FormatFloat(sum.Totals[key], 'f', -1, 64)
+			}
+			fmt.Fprintf(&b, "   %s\n", strings.Join(parts, ", "))
+		}
+	}
+	b.WriteString("\n" + i18n.T("digest_total", map[string]any{"Succeeded": totalOK, "Failed": totalFailed}))
+
+	msg.Text = b.String()
+	if totalFailed > 0 {
+		msg.Priority = notify.PriorityHigh
+	}
+	return msg
+}
+
+// StartOfDay returns midnight of the day t falls on, in t's location
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
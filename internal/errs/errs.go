@@ -0,0 +1,141 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/tgerr"
+)
+
+// Failure classes returned by client methods, match them with errors.Is
+var (
+	ErrPeerNotFound   = errors.New("peer not found")
+	ErrButtonNotFound = errors.New("button not found")
+	ErrFloodWait      = errors.New("flood wait")
+	ErrAuthRequired   = errors.New("authorization required")
+	ErrNetwork        = errors.New("network error")
+	ErrPeerRestricted = errors.New("peer restricted")
+	ErrPeerFlood      = errors.New("peer flood")
+	ErrQuotaExceeded  = errors.New("daily send quota exceeded")
+)
+
+// classes lists every failure class with its stable short name
+var classes = []struct {
+	err  error
+	name string
+}{
+	{ErrPeerNotFound, "peer_not_found"},
+	{ErrButtonNotFound, "button_not_found"},
+	{ErrFloodWait, "flood_wait"},
+	{ErrAuthRequired, "auth_required"},
+	{ErrNetwork, "network"},
+	{ErrPeerRestricted, "peer_restricted"},
+	{ErrPeerFlood, "peer_flood"},
+	{ErrQuotaExceeded, "quota_exceeded"},
+}
+
+// peerErrorTypes are RPC error types meaning the target chat cannot be resolved or accessed
+var peerErrorTypes = []string{
+	"USERNAME_NOT_OCCUPIED",
+	"USERNAME_INVALID",
+	"PEER_ID_INVALID",
+	"CHANNEL_INVALID",
+	"CHANNEL_PRIVATE",
+	"CHAT_ID_INVALID",
+}
+
+// restrictedErrorTypes are RPC error types refusing a message to a user, typically a bot the
+// account has never talked to
+var restrictedErrorTypes = []string{
+	"USER_PRIVACY_RESTRICTED",
+	"USER_IS_BLOCKED",
+}
+
+// FloodWaitError is an ErrFloodWait carrying the wait duration requested by Telegram
+type FloodWaitError struct {
+	Wait time.Duration
+	Err  error
+}
+
+func (e *FloodWaitError) Error() string {
+	return fmt.Sprintf("%s (retry after %s): %v", ErrFloodWait, e.Wait, e.Err)
+}
+
+func (e *FloodWaitError) Unwrap() []error {
+	return []error{ErrFloodWait, e.Err}
+}
+
+// Wrap tags err with a failure class, returns nil for a nil err
+func Wrap(class error, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", class, err)
+}
+
+// Classify tags a raw gotd/network error with its failure class.
+// Errors that are already classified or unknown are returned unchanged.
+func Classify(err error) error {
+	if err == nil || Class(err) != "" {
+		return err
+	}
+	if wait, ok := tgerr.AsFloodWait(err); ok {
+		return &FloodWaitError{Wait: wait, Err: err}
+	}
+	if auth.IsUnauthorized(err) || errors.Is(err, auth.ErrPasswordAuthNeeded) {
+		return Wrap(ErrAuthRequired, err)
+	}
+	if tgerr.Is(err, peerErrorTypes...) {
+		return Wrap(ErrPeerNotFound, err)
+	}
+	// PEER_FLOOD is the anti-spam limit of the account, sending anything more only prolongs it
+	if tgerr.Is(err, "PEER_FLOOD") {
+		return Wrap(ErrPeerFlood, err)
+	}
+	if tgerr.Is(err, restrictedErrorTypes...) {
+		return Wrap(ErrPeerRestricted, err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return Wrap(ErrNetwork, err)
+	}
+	return err
+}
+
+// Class returns the short name of the failure class of err, or "" if it is not classified
+func Class(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, c := range classes {
+		if errors.Is(err, c.err) {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// FloodWait returns the wait duration if err is a flood wait
+func FloodWait(err error) (time.Duration, bool) {
+	var fw *FloodWaitError
+	if errors.As(err, &fw) {
+		return fw.Wait, true
+	}
+	return tgerr.AsFloodWait(err)
+}
+
+// Transient reports whether err is a network failure or a Telegram server-side error
+// that is worth retrying as is
+func Transient(err error) bool {
+	if errors.Is(Classify(err), ErrNetwork) {
+		return true
+	}
+	if rpcErr, ok := tgerr.As(err); ok {
+		return rpcErr.Code >= 500 || rpcErr.IsType("TIMEOUT")
+	}
+	return false
+}
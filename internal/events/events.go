@@ -0,0 +1,118 @@
+// Package events carries what happens to tasks and sessions from the executor and scheduler
+// to their consumers (run history, notifications, statistics), so producers don't need to
+// know who is interested.
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"telegram-auto-checkin/internal/history"
+)
+
+// Event is one of TaskStarted, TaskFinished, AuthRequired or FloodWait
+type Event interface {
+	event()
+}
+
+// TaskStarted is published when a worker starts executing a task
+type TaskStarted struct {
+	Account   string
+	Task      string
+	Target    string
+	Method    string
+	Trigger   string
+	RequestID string
+	Time      time.Time
+}
+
+// TaskFinished describes the outcome of a single task execution
+type TaskFinished struct {
+	Account    string
+	Task       string
+	Target     string
+	Method     string
+	Trigger    string
+	RequestID  string
+	StartedAt  time.Time
+	Duration   time.Duration
+	Err        error
+	ErrorClass string                    // Failure class from the errs package, empty on success or unclassified errors
+	Reply      string                    // Bot reply or callback answer, if any
+	Artifacts  []string                  // Files saved from the bot reply
+	Extracted  map[string]float64        // Values parsed from the reply by reply_extract rules
+	Transcript []history.TranscriptEntry // Exchange with the target, see client.Report
+}
+
+// Success reports whether the task completed without error
+func (e TaskFinished) Success() bool {
+	return e.Err == nil
+}
+
+// AuthRequired is published when an account's session is no longer logged in, Task is
+// empty when the login at connect time failed
+type AuthRequired struct {
+	Account string
+	Task    string
+	Err     error
+}
+
+// FloodWait is published when Telegram rate-limited a task longer than the retries wait for
+type FloodWait struct {
+	Account string
+	Task    string
+	Wait    time.Duration
+}
+
+func (TaskStarted) event()  {}
+func (TaskFinished) event() {}
+func (AuthRequired) event() {}
+func (FloodWait) event()    {}
+
+// Bus delivers published events to its subscribers. The zero value is ready to use,
+// publishing to a nil Bus does nothing.
+type Bus struct {
+	mu   sync.RWMutex
+	next int
+	subs []subscriber
+}
+
+type subscriber struct {
+	id int
+	fn func(Event)
+}
+
+// NewBus returns a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers fn for every event published afterwards and returns a function
+// removing it again. fn runs in the publishing goroutine, possibly concurrently with
+// itself, and should hand slow work such as network requests to another goroutine.
+func (b *Bus) Subscribe(fn func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subs = append(b.subs, subscriber{id: id, fn: fn})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subs = slices.DeleteFunc(b.subs, func(s subscriber) bool { return s.id == id })
+	}
+}
+
+// Publish calls every subscriber with ev, in the order they subscribed
+func (b *Bus) Publish(ev Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	subs := slices.Clone(b.subs)
+	b.mu.RUnlock()
+	for _, s := range subs {
+		s.fn(ev)
+	}
+}
@@ -0,0 +1,274 @@
+// Package fake provides an in-memory stand-in for the Telegram API: a Server hosting
+// scripted bots that answers the requests of a real client.Client, so check-in flows run
+// through the client's retries, reply parsing and button handling without credentials or
+// network access.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+// Reply is a bot message, optionally carrying inline buttons
+type Reply struct {
+	Text    string
+	Buttons []Button
+}
+
+// Button is an inline button of a bot reply. OnClick builds the answer when pressed; the
+// returned reply is used as the callback answer and, if Edit is set, replaces the message.
+// A button with a URL is not a callback button, the client handles it per other_button.
+type Button struct {
+	Text    string
+	OnClick func() Reply
+	Edit    bool
+	URL     string
+}
+
+// Bot answers messages sent to it. OnMessage returns the reply to a message, or false to stay silent.
+type Bot struct {
+	Username  string
+	OnMessage func(text string) (Reply, bool)
+}
+
+// Message is a message in a fake chat
+type Message struct {
+	ID      int
+	Out     bool // Sent by the account
+	Text    string
+	Buttons []Button
+	Date    time.Time
+}
+
+// User IDs of the account and of the first bot, bots are numbered in the order they were added
+const (
+	selfID     = 1
+	firstBotID = 1000
+)
+
+// Server holds bots, chat histories and injected failures. It implements tg.Invoker, pass
+// it to client.NewClientWithInvoker to get a client talking to it.
+type Server struct {
+	mu       sync.Mutex
+	bots     []*Bot
+	chats    map[int64][]*Message // Chat with each bot by its user ID, oldest first
+	failures []error
+	nextID   int
+}
+
+// NewServer returns a server hosting the given bots
+func NewServer(bots ...*Bot) *Server {
+	s := &Server{chats: make(map[int64][]*Message)}
+	for _, bot := range bots {
+		s.AddBot(bot)
+	}
+	return s
+}
+
+// AddBot registers a bot, its username is matched without the leading @ and case-insensitively
+func (s *Server) AddBot(bot *Bot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bots = append(s.bots, bot)
+}
+
+// FailNext makes the next requests fail with the given errors, in order. The authorization
+// check at login is never failed, so the failures hit the requests of the tasks. Use errs
+// classes (e.g. errs.ErrNetwork) or RPC errors from tgerr.New to exercise retries and error
+// classification.
+func (s *Server) FailNext(errList ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, errList...)
+}
+
+// History returns a copy of the chat with a bot, oldest first
+func (s *Server) History(username string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, _, ok := s.botByName(username)
+	if !ok {
+		return nil
+	}
+	chat := s.chats[id]
+	out := make([]Message, len(chat))
+	for i, msg := range chat {
+		out[i] = *msg
+	}
+	return out
+}
+
+// Sent returns the texts the account sent to a bot, oldest first
+func (s *Server) Sent(username string) []string {
+	var texts []string
+	for _, msg := range s.History(username) {
+		if msg.Out {
+			texts = append(texts, msg.Text)
+		}
+	}
+	return texts
+}
+
+// Invoke answers a request of the client. Requests the fake does not know fail, so a flow
+// depending on one shows up in the test instead of passing unnoticed.
+func (s *Server) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	resp, err := s.handle(input)
+	if err != nil {
+		return err
+	}
+	var b bin.Buffer
+	if err := resp.Encode(&b); err != nil {
+		return err
+	}
+	return output.Decode(&b)
+}
+
+// handle returns the response to a request
+func (s *Server) handle(input bin.Encoder) (bin.Encoder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, login := input.(*tg.UsersGetUsersRequest); !login && len(s.failures) > 0 {
+		err := s.failures[0]
+		s.failures = s.failures[1:]
+		return nil, err
+	}
+
+	switch req := input.(type) {
+	case *tg.UsersGetUsersRequest:
+		return &tg.UserClassVector{Elems: []tg.UserClass{&tg.User{Self: true, ID: selfID, AccessHash: selfID, FirstName: "Test"}}}, nil
+	case *tg.ContactsResolveUsernameRequest:
+		id, bot, ok := s.botByName(req.Username)
+		if !ok {
+			return nil, tgerr.New(400, "USERNAME_NOT_OCCUPIED")
+		}
+		return &tg.ContactsResolvedPeer{Peer: &tg.PeerUser{UserID: id}, Users: []tg.UserClass{botUser(id, bot)}}, nil
+	case *tg.MessagesSendMessageRequest:
+		return s.send(req.Peer, req.Message)
+	case *tg.MessagesGetHistoryRequest:
+		return s.history(req.Peer, req.Limit)
+	case *tg.MessagesGetBotCallbackAnswerRequest:
+		return s.click(req.Peer, req.MsgID, req.Data)
+	case *tg.AccountUpdateStatusRequest:
+		return &tg.BoolTrue{}, nil
+	default:
+		return nil, fmt.Errorf("fake: %T is not supported", input)
+	}
+}
+
+// send delivers a message from the account and lets the bot reply
+func (s *Server) send(peer tg.InputPeerClass, text string) (bin.Encoder, error) {
+	id, bot, err := s.peerBot(peer)
+	if err != nil {
+		return nil, err
+	}
+	sent := s.appendMessage(id, &Message{Out: true, Text: text})
+	if bot.OnMessage != nil {
+		if reply, ok := bot.OnMessage(text); ok {
+			s.appendMessage(id, &Message{Text: reply.Text, Buttons: reply.Buttons})
+		}
+	}
+	return &tg.UpdateShortSentMessage{Out: true, ID: sent.ID, Date: int(sent.Date.Unix())}, nil
+}
+
+// history returns the latest limit messages of the chat with a bot, newest first
+func (s *Server) history(peer tg.InputPeerClass, limit int) (bin.Encoder, error) {
+	id, bot, err := s.peerBot(peer)
+	if err != nil {
+		return nil, err
+	}
+	chat := s.chats[id]
+	var msgs []tg.MessageClass
+	for i := len(chat) - 1; i >= 0 && (limit <= 0 || len(msgs) < limit); i-- {
+		msgs = append(msgs, chat[i].apiMessage(id))
+	}
+	return &tg.MessagesMessages{Messages: msgs, Users: []tg.UserClass{botUser(id, bot)}}, nil
+}
+
+// click presses the callback button data refers to and returns its answer
+func (s *Server) click(peer tg.InputPeerClass, msgID int, data []byte) (bin.Encoder, error) {
+	id, _, err := s.peerBot(peer)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range s.chats[id] {
+		if msg.ID != msgID || msg.Out {
+			continue
+		}
+		i, err := strconv.Atoi(string(data))
+		if err != nil || i < 0 || i >= len(msg.Buttons) {
+			return nil, tgerr.New(400, "DATA_INVALID")
+		}
+		btn := msg.Buttons[i]
+		answer := &tg.MessagesBotCallbackAnswer{}
+		if btn.OnClick != nil {
+			reply := btn.OnClick()
+			if btn.Edit {
+				msg.Text, msg.Buttons = reply.Text, reply.Buttons
+			}
+			answer.Message = reply.Text
+		}
+		return answer, nil
+	}
+	return nil, tgerr.New(400, "MESSAGE_ID_INVALID")
+}
+
+// botByName finds a bot by username, must be called with mu held
+func (s *Server) botByName(username string) (int64, *Bot, bool) {
+	username = strings.TrimPrefix(username, "@")
+	for i, bot := range s.bots {
+		if strings.EqualFold(strings.TrimPrefix(bot.Username, "@"), username) {
+			return firstBotID + int64(i), bot, true
+		}
+	}
+	return 0, nil, false
+}
+
+// peerBot finds the bot a request is addressed to, must be called with mu held
+func (s *Server) peerBot(peer tg.InputPeerClass) (int64, *Bot, error) {
+	if p, ok := peer.(*tg.InputPeerUser); ok {
+		if i := p.UserID - firstBotID; i >= 0 && i < int64(len(s.bots)) {
+			return p.UserID, s.bots[i], nil
+		}
+	}
+	return 0, nil, tgerr.New(400, "PEER_ID_INVALID")
+}
+
+// appendMessage adds a message to the chat with a bot, must be called with mu held
+func (s *Server) appendMessage(botID int64, msg *Message) *Message {
+	s.nextID++
+	msg.ID = s.nextID
+	msg.Date = time.Now()
+	s.chats[botID] = append(s.chats[botID], msg)
+	return msg
+}
+
+// apiMessage returns the message as Telegram sends it, callback buttons carry their index as data
+func (m *Message) apiMessage(botID int64) *tg.Message {
+	msg := &tg.Message{ID: m.ID, Out: m.Out, PeerID: &tg.PeerUser{UserID: botID}, Date: int(m.Date.Unix()), Message: m.Text}
+	if len(m.Buttons) == 0 {
+		return msg
+	}
+	var row tg.KeyboardButtonRow
+	for i, btn := range m.Buttons {
+		if btn.URL != "" {
+			row.Buttons = append(row.Buttons, &tg.KeyboardButtonURL{Text: btn.Text, URL: btn.URL})
+		} else {
+			row.Buttons = append(row.Buttons, &tg.KeyboardButtonCallback{Text: btn.Text, Data: []byte(strconv.Itoa(i))})
+		}
+	}
+	msg.ReplyMarkup = &tg.ReplyInlineMarkup{Rows: []tg.KeyboardButtonRow{row}}
+	return msg
+}
+
+// botUser returns the user of a bot as Telegram sends it
+func botUser(id int64, bot *Bot) *tg.User {
+	return &tg.User{ID: id, AccessHash: id, Bot: true, Username: strings.TrimPrefix(bot.Username, "@"), FirstName: bot.Username}
+}
@@ -0,0 +1,120 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultFile is the run history file used when none is configured
+const DefaultFile = "./data/history.jsonl"
+
+// Record is a single task execution stored in the run history
+type Record struct {
+	Time       time.Time          `json:"time"`
+	Account    string             `json:"account"`
+	Task       string             `json:"task"`
+	Target     string             `json:"target"`
+	Method     string             `json:"method"`
+	Trigger    string             `json:"trigger"`
+	RequestID  string             `json:"request_id"`
+	Success    bool               `json:"success"`
+	Error      string             `json:"error,omitempty"`
+	ErrorClass string             `json:"error_class,omitempty"`
+	DurationMs int64              `json:"duration_ms"`
+	Reply      string             `json:"reply,omitempty"`
+	Artifacts  []string           `json:"artifacts,omitempty"`  // Files saved from the bot reply, e.g. downloaded photos
+	Extracted  map[string]float64 `json:"extracted,omitempty"`  // Values parsed from the reply by reply_extract rules
+	Transcript []TranscriptEntry  `json:"transcript,omitempty"` // Exchange with the target: sent messages, replies and callback answers
+}
+
+// Kinds of TranscriptEntry
+const (
+	TranscriptSent     = "sent"     // Message sent by the account
+	TranscriptReceived = "received" // Message received from the target within the reply wait
+	TranscriptButton   = "button"   // Button pressed by the account
+	TranscriptCallback = "callback" // Answer to a callback button press
+	TranscriptEdited   = "edited"   // New text of a message the bot edited
+)
+
+// TranscriptEntry is one step of the exchange of a run with its target
+type TranscriptEntry struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	MessageID int       `json:"message_id,omitempty"`
+	Text      string    `json:"text,omitempty"`
+}
+
+// Store appends records to a JSON lines file, one record per line
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Open prepares the history file at path, creating its directory if needed
+func Open(path string) (*Store, error) {
+	if path == "" {
+		path = DefaultFile
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return &Store{path: path}, nil
+}
+
+// Path returns the history file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append writes a record to the end of the history file
+func (s *Store) Append(rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads all records, skipping lines that cannot be parsed
+func (s *Store) Load() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
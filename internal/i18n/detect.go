@@ -0,0 +1,39 @@
+package i18n
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DetectLanguage returns the language of the OS locale, e.g. "zh-CN" for LANG=zh_CN.UTF-8,
+// or "en" when it cannot be determined
+func DetectLanguage() string {
+	// Same precedence as POSIX: LC_ALL overrides LC_MESSAGES, which overrides LANG
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := parseLocale(os.Getenv(name)); lang != "" {
+			return lang
+		}
+	}
+	if lang := parseLocale(systemLocale()); lang != "" {
+		return lang
+	}
+	return "en"
+}
+
+// parseLocale converts a locale such as "zh_CN.UTF-8", "ru_RU@euro" or "es-ES" into a BCP 47 tag,
+// empty for unset or neutral locales ("C", "POSIX")
+func parseLocale(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		return ""
+	}
+	return tag.String()
+}
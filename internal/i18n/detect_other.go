@@ -0,0 +1,8 @@
+//go:build !windows
+
+package i18n
+
+// systemLocale has nothing beyond the environment variables to consult outside Windows
+func systemLocale() string {
+	return ""
+}
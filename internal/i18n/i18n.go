@@ -2,16 +2,22 @@ package i18n
 
 import (
 	"encoding/json"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"golang.org/x/text/language"
 	"gopkg.in/yaml.v3"
+
+	"telegram-auto-checkin/locales"
 )
 
 var bundle *i18n.Bundle
 var localizer *i18n.Localizer
+var defaultOnce sync.Once
 
 // Init Initialize internationalization support
 func Init(lang string) error {
@@ -42,19 +48,21 @@ func Init(lang string) error {
 	}
 
 	if localeDir == "" {
-		localeDir = "locales" // Fallback to default
-	}
-
-	// Load English (default)
-	enFile := filepath.Join(localeDir, "en.yaml")
-	if _, err := bundle.LoadMessageFile(enFile); err != nil {
-		return err
-	}
-
-	// Load Chinese
-	zhFile := filepath.Join(localeDir, "zh.yaml")
-	if _, err := bundle.LoadMessageFile(zhFile); err != nil {
-		return err
+		// Fallback to the translations built into the binary
+		if err := loadEmbedded(bundle); err != nil {
+			return err
+		}
+	} else {
+		// Load every translation file, the language is taken from the file name (en.yaml, ru.yaml, ...)
+		files, err := filepath.Glob(filepath.Join(localeDir, "*.yaml"))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if _, err := bundle.LoadMessageFile(file); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Set language based on configuration
@@ -66,17 +74,75 @@ func Init(lang string) error {
 	return nil
 }
 
-// T Translation function
-func T(messageID string) string {
-	if localizer == nil {
-		return messageID
+// loadEmbedded loads the translation files embedded in the binary
+func loadEmbedded(b *i18n.Bundle) error {
+	names, err := fs.Glob(locales.FS, "*.yaml")
+	if err != nil {
+		return err
 	}
+	for _, name := range names {
+		if _, err := b.LoadMessageFileFS(locales.FS, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	msg, err := localizer.Localize(&i18n.LocalizeConfig{
+// ensureDefault sets up English from the embedded translations when Init was not called,
+// e.g. in subcommands that run before the config is loaded
+func ensureDefault() {
+	defaultOnce.Do(func() {
+		if localizer != nil {
+			return
+		}
+		b := i18n.NewBundle(language.English)
+		b.RegisterUnmarshalFunc("yaml", yaml.Unmarshal)
+		if err := loadEmbedded(b); err != nil {
+			return
+		}
+		bundle = b
+		localizer = i18n.NewLocalizer(b, "en")
+	})
+}
+
+// T Translation function, the optional data fills {{.Field}} placeholders of the message
+func T(messageID string, data ...map[string]any) string {
+	lc := &i18n.LocalizeConfig{
 		MessageID: messageID,
+	}
+	if len(data) > 0 {
+		lc.TemplateData = data[0]
+	}
+	return localize(lc)
+}
+
+// TN Translation function for messages with plural forms (one, few, many, other),
+// count selects the form and is available to the message as {{.Count}}
+func TN(messageID string, count int, data ...map[string]any) string {
+	templateData := map[string]any{}
+	if len(data) > 0 {
+		maps.Copy(templateData, data[0])
+	}
+	templateData["Count"] = count
+
+	return localize(&i18n.LocalizeConfig{
+		MessageID:    messageID,
+		PluralCount:  count,
+		TemplateData: templateData,
 	})
-	if err != nil {
-		return messageID
+}
+
+// localize resolves lc with the current localizer, falling back to the message ID
+func localize(lc *i18n.LocalizeConfig) string {
+	ensureDefault()
+	if localizer == nil {
+		return lc.MessageID
+	}
+
+	// A message missing from the selected language still comes back in English along with the error
+	msg, err := localizer.Localize(lc)
+	if err != nil && msg == "" {
+		return lc.MessageID
 	}
 	return msg
 }
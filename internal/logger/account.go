@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// accountLogs appends every log line carrying an account field to accounts/<account>.log
+// in the log directory, so one file holds the logins, session events, schedules and task
+// runs of an account
+type accountLogs struct {
+	dir    string
+	format string
+
+	mu    sync.Mutex
+	files map[string]io.Writer
+}
+
+// accounts is set up by SetupLoggerWithFile, task loggers created afterwards write to it too
+var accounts *accountLogs
+
+func newAccountLogs(logDir, format string) (*accountLogs, error) {
+	dir := filepath.Join(logDir, "accounts")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create account log directory: %w", err)
+	}
+	return &accountLogs{dir: dir, format: format, files: make(map[string]io.Writer)}, nil
+}
+
+// writer returns the writer of the account log files fed by one log output. Task runs are
+// logged in detail to the task logs, the summary lines of the main log carrying a request
+// ID are skipped with skipTaskRuns so runs don't show up twice.
+func (a *accountLogs) writer(skipTaskRuns bool) io.Writer {
+	return accountWriter{logs: a, skipTaskRuns: skipTaskRuns}
+}
+
+// file returns the writer of an account's log file, opened on first use and kept open
+func (a *accountLogs) file(account string) (io.Writer, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if w, ok := a.files[account]; ok {
+		return w, nil
+	}
+	f, err := os.OpenFile(filepath.Join(a.dir, SanitizeFilename(account)+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	var w io.Writer = f
+	if a.format != "json" {
+		w = zerolog.ConsoleWriter{Out: f, TimeFormat: "2006/01/02 15:04:05", NoColor: true}
+	}
+	a.files[account] = w
+	return w, nil
+}
+
+type accountWriter struct {
+	logs         *accountLogs
+	skipTaskRuns bool
+}
+
+// Write receives one JSON encoded event from zerolog, events without an account are dropped
+func (w accountWriter) Write(p []byte) (int, error) {
+	var fields struct {
+		Account   string `json:"account"`
+		RequestID string `json:"request_id"`
+	}
+	if json.Unmarshal(p, &fields) != nil || fields.Account == "" || (w.skipTaskRuns && fields.RequestID != "") {
+		return len(p), nil
+	}
+	out, err := w.logs.file(fields.Account)
+	if err != nil {
+		// A broken account log must not break the main log
+		return len(p), nil
+	}
+	w.logs.mu.Lock()
+	out.Write(p)
+	w.logs.mu.Unlock()
+	return len(p), nil
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -9,13 +10,23 @@ import (
 	"time"
 
 	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/i18n"
 )
 
+// consoleOutput is where console logs are written, stdout by default
+var consoleOutput io.Writer = os.Stdout
+
+// SetConsoleOutput redirects console logs, e.g. to stderr when stdout carries machine-readable output
+func SetConsoleOutput(w io.Writer) {
+	consoleOutput = w
+}
+
 // SetupLogger sets up basic console logger
 func SetupLogger(levelStr string) zerolog.Logger {
 	zerolog.TimeFieldFormat = time.RFC3339
 	output := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
+		Out:        consoleOutput,
 		TimeFormat: "2006/01/02 15:04:05",
 	}
 	logger := zerolog.New(output).With().Timestamp().Logger()
@@ -32,14 +43,32 @@ func SetupLogger(levelStr string) zerolog.Logger {
 	zerolog.SetGlobalLevel(level)
 
 	if level == zerolog.DebugLevel {
-		logger.Debug().Msg("Debug mode enabled")
+		logger.Debug().Msg(i18n.T("debug_enabled"))
 	}
 
 	return logger
 }
 
-// SetupLoggerWithFile sets up logger with console and file output
-func SetupLoggerWithFile(levelStr string, logDir string, format string) (zerolog.Logger, error) {
+// Output is a destination of the main log, see SetupLoggerWithFile
+type Output struct {
+	Type    string            // console | file | syslog | journald | loki | http
+	Format  string            // text | json, default: the format of the log
+	Level   string            // Minimum level written to this output, default: the level of the log
+	Address string            // syslog: remote server as udp://host:514 or tcp://host:514, default: the local daemon
+	Tag     string            // syslog and journald: program identifier, loki: job label, default: telegram-auto-checkin
+	URL     string            // loki: Loki base URL; http: collector receiving JSON arrays of log lines
+	Headers map[string]string // loki and http: extra request headers
+}
+
+// defaultOutputs keep the console and app.log when no outputs are configured
+var defaultOutputs = []Output{{Type: "console"}, {Type: "file"}}
+
+// defaultTag identifies the program in syslog and the journal
+const defaultTag = "telegram-auto-checkin"
+
+// SetupLoggerWithFile sets up the logger writing to outputs, the console and app.log when
+// none are given. Lines of an account are also collected in accounts/<account>.log.
+func SetupLoggerWithFile(levelStr string, logDir string, format string, outputs ...Output) (zerolog.Logger, error) {
 	// Set default log directory
 	if logDir == "" {
 		logDir = "./log"
@@ -49,6 +78,9 @@ func SetupLoggerWithFile(levelStr string, logDir string, format string) (zerolog
 	if format == "" {
 		format = "text"
 	}
+	if len(outputs) == 0 {
+		outputs = defaultOutputs
+	}
 
 	// Create log directory
 	if err := os.MkdirAll(logDir, 0755); err != nil {
@@ -58,54 +90,64 @@ func SetupLoggerWithFile(levelStr string, logDir string, format string) (zerolog
 	// Set time format
 	zerolog.TimeFieldFormat = time.RFC3339
 
-	// File output - app.log (append mode)
-	appLogPath := filepath.Join(logDir, "app.log")
-	appLogFile, err := os.OpenFile(appLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return zerolog.Logger{}, fmt.Errorf("failed to open app.log: %w", err)
-	}
-
-	// Console output (based on format)
-	var consoleWriter io.Writer
-	var fileWriter io.Writer
-	if format == "json" {
-		consoleWriter = os.Stdout
-		fileWriter = appLogFile
-	} else {
-		consoleWriter = zerolog.ConsoleWriter{
-			Out:        os.Stdout,
-			TimeFormat: "2006/01/02 15:04:05",
-		}
-		fileWriter = zerolog.ConsoleWriter{
-			Out:        appLogFile,
-			TimeFormat: "2006/01/02 15:04:05",
-			NoColor:    true, // No color in file
-		}
-	}
-
-	// Multiple outputs: console + file
-	multiWriter := io.MultiWriter(consoleWriter, fileWriter)
-	logger := zerolog.New(multiWriter).With().Timestamp().Logger()
-
 	// Set log level
 	level := zerolog.InfoLevel
+	invalidLevel := false
 	if strings.TrimSpace(levelStr) != "" {
 		parsed, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(levelStr)))
 		if err == nil {
 			level = parsed
 		} else {
-			logger.Warn().Str("invalid_level", levelStr).Str("fallback", level.String()).Msg("Invalid log level")
+			invalidLevel = true
 		}
 	}
-	zerolog.SetGlobalLevel(level)
 
+	// Lines of an account are also collected in its own file
+	accountLogs, err := newAccountLogs(logDir, format)
+	if err != nil {
+		return zerolog.Logger{}, err
+	}
+	accounts = accountLogs
+
+	// Every output filters its own level, the global level lets through the lowest of them
+	writers := []io.Writer{levelFilter{Writer: accountLogs.writer(true), level: level}}
+	globalLevel := level
+	var targets []string
+	for i, out := range outputs {
+		if out.Format == "" {
+			out.Format = format
+		}
+		if out.Tag == "" {
+			out.Tag = defaultTag
+		}
+		outLevel := level
+		if out.Level != "" {
+			if outLevel, err = zerolog.ParseLevel(strings.ToLower(out.Level)); err != nil {
+				return zerolog.Logger{}, fmt.Errorf("log.outputs[%d]: invalid level %q", i, out.Level)
+			}
+		}
+		w, target, err := openOutput(out, logDir)
+		if err != nil {
+			return zerolog.Logger{}, fmt.Errorf("log.outputs[%d]: %w", i, err)
+		}
+		writers = append(writers, levelFilter{Writer: w, level: outLevel})
+		targets = append(targets, target)
+		globalLevel = min(globalLevel, outLevel)
+	}
+
+	logger := zerolog.New(zerolog.MultiLevelWriter(writers...)).With().Timestamp().Logger()
+	zerolog.SetGlobalLevel(globalLevel)
+
+	if invalidLevel {
+		logger.Warn().Str("invalid_level", levelStr).Str("fallback", level.String()).Msg(i18n.T("invalid_log_level"))
+	}
 	if level == zerolog.DebugLevel {
-		logger.Debug().Msg("Debug mode enabled")
+		logger.Debug().Msg(i18n.T("debug_enabled"))
 	}
 
 	logger.Info().
 		Str("log_dir", logDir).
-		Str("app_log", appLogPath).
+		Strs("outputs", targets).
 		Str("format", format).
 		Str("level", level.String()).
 		Msg("Logging system initialized")
@@ -113,6 +155,73 @@ func SetupLoggerWithFile(levelStr string, logDir string, format string) (zerolog
 	return logger, nil
 }
 
+// openOutput returns the writer of an output and a description of where it writes
+func openOutput(out Output, logDir string) (io.Writer, string, error) {
+	switch out.Type {
+	case "console":
+		if out.Format == "json" {
+			return consoleOutput, "console", nil
+		}
+		return zerolog.ConsoleWriter{Out: consoleOutput, TimeFormat: "2006/01/02 15:04:05"}, "console", nil
+	case "file":
+		// app.log (append mode)
+		appLogPath := filepath.Join(logDir, "app.log")
+		appLogFile, err := os.OpenFile(appLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open app.log: %w", err)
+		}
+		if out.Format == "json" {
+			return appLogFile, appLogPath, nil
+		}
+		return zerolog.ConsoleWriter{
+			Out:        appLogFile,
+			TimeFormat: "2006/01/02 15:04:05",
+			NoColor:    true, // No color in file
+		}, appLogPath, nil
+	case "syslog":
+		w, err := openSyslog(out)
+		return w, "syslog", err
+	case "journald":
+		w, err := openJournal(out)
+		return w, "journald", err
+	case "loki", "http":
+		w, err := openRemote(out)
+		return w, out.Type, err
+	default:
+		return nil, "", fmt.Errorf("unknown output type %q, expected console, file, syslog, journald, loki or http", out.Type)
+	}
+}
+
+// levelFilter drops events below level before they reach Writer
+type levelFilter struct {
+	io.Writer
+	level zerolog.Level
+}
+
+func (f levelFilter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	if l < f.level {
+		return len(p), nil
+	}
+	if lw, ok := f.Writer.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(l, p)
+	}
+	return f.Writer.Write(p)
+}
+
+// plainMessage renders a JSON event for line-oriented sinks: the JSON itself, or the text
+// format without colors and timestamp, the sink records the time itself
+func plainMessage(p []byte, format string) []byte {
+	if format == "json" {
+		return bytes.TrimRight(p, "\n")
+	}
+	var buf bytes.Buffer
+	w := zerolog.ConsoleWriter{Out: &buf, NoColor: true, PartsExclude: []string{zerolog.TimestampFieldName}}
+	if _, err := w.Write(p); err != nil {
+		return bytes.TrimRight(p, "\n")
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
 // CreateTaskLogger creates separate log file for task
 func CreateTaskLogger(logDir string, accountName string, taskName string, triggerType string, format string) (zerolog.Logger, *os.File, error) {
 	if logDir == "" {
@@ -127,8 +236,8 @@ func CreateTaskLogger(logDir string, accountName string, taskName string, trigge
 
 	// File format: account_task_triggerType_timestamp.log
 	timestamp := time.Now().Format("20060102_150405")
-	safeAccountName := sanitizeFilename(accountName)
-	safeTaskName := sanitizeFilename(taskName)
+	safeAccountName := SanitizeFilename(accountName)
+	safeTaskName := SanitizeFilename(taskName)
 
 	filename := fmt.Sprintf("%s_%s_%s_%s.log", safeAccountName, safeTaskName, triggerType, timestamp)
 	logPath := filepath.Join(taskLogDir, filename)
@@ -140,35 +249,30 @@ func CreateTaskLogger(logDir string, accountName string, taskName string, trigge
 	}
 
 	// Select log format based on format config
-	var logger zerolog.Logger
-	if format == "json" {
-		// JSON format
-		logger = zerolog.New(logFile).With().
-			Timestamp().
-			Str("account", accountName).
-			Str("task", taskName).
-			Str("trigger", triggerType).
-			Logger()
-	} else {
+	var out io.Writer = logFile
+	if format != "json" {
 		// Text format (console format)
-		consoleWriter := zerolog.ConsoleWriter{
+		out = zerolog.ConsoleWriter{
 			Out:        logFile,
 			TimeFormat: "2006/01/02 15:04:05",
 			NoColor:    true, // No color in file
 		}
-		logger = zerolog.New(consoleWriter).With().
-			Timestamp().
-			Str("account", accountName).
-			Str("task", taskName).
-			Str("trigger", triggerType).
-			Logger()
 	}
+	if accounts != nil {
+		out = io.MultiWriter(out, accounts.writer(false))
+	}
+	logger := zerolog.New(out).With().
+		Timestamp().
+		Str("account", accountName).
+		Str("task", taskName).
+		Str("trigger", triggerType).
+		Logger()
 
 	return logger, logFile, nil
 }
 
-// sanitizeFilename removes illegal characters from filename
-func sanitizeFilename(name string) string {
+// SanitizeFilename removes illegal characters from filename
+func SanitizeFilename(name string) string {
 	// Remove or replace illegal characters
 	replacer := strings.NewReplacer(
 		"/", "_",
@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Remote outputs send lines in batches of up to remoteBatchSize, at least every remoteFlushInterval.
+// Up to remoteBufferSize lines wait for the collector, further lines are dropped.
+const (
+	remoteBatchSize     = 100
+	remoteFlushInterval = 2 * time.Second
+	remoteBufferSize    = 1000
+	remoteTimeout       = 10 * time.Second
+)
+
+// lokiPushPath is the push API of Grafana Loki, appended to the configured URL
+const lokiPushPath = "/loki/api/v1/push"
+
+// remoteOutput ships JSON log lines to Grafana Loki or a generic HTTP collector in the
+// background. Lines are dropped rather than slowing down the program when the collector
+// is slow or unreachable.
+type remoteOutput struct {
+	loki    bool
+	url     string
+	headers map[string]string
+	tag     string
+	client  *http.Client
+	lines   chan remoteLine
+}
+
+type remoteLine struct {
+	time  time.Time
+	level zerolog.Level
+	data  []byte // The JSON event
+}
+
+func openRemote(out Output) (io.Writer, error) {
+	if out.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	r := &remoteOutput{
+		loki:    out.Type == "loki",
+		url:     out.URL,
+		headers: out.Headers,
+		tag:     out.Tag,
+		client:  &http.Client{Timeout: remoteTimeout},
+		lines:   make(chan remoteLine, remoteBufferSize),
+	}
+	if r.loki && !strings.HasSuffix(r.url, lokiPushPath) {
+		r.url = strings.TrimRight(r.url, "/") + lokiPushPath
+	}
+	go r.run()
+	return r, nil
+}
+
+func (r *remoteOutput) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (r *remoteOutput) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	// zerolog reuses p after Write returns
+	line := remoteLine{time: time.Now(), level: l, data: bytes.Clone(bytes.TrimRight(p, "\n"))}
+	select {
+	case r.lines <- line:
+	default:
+	}
+	return len(p), nil
+}
+
+// run sends the buffered lines in batches, forever
+func (r *remoteOutput) run() {
+	ticker := time.NewTicker(remoteFlushInterval)
+	defer ticker.Stop()
+	batch := make([]remoteLine, 0, remoteBatchSize)
+	failing := false
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := r.send(batch)
+		// The log cannot report its own failures, stderr gets one line per outage
+		switch {
+		case err != nil && !failing:
+			fmt.Fprintf(os.Stderr, "log output %s: %v, dropping lines until it recovers\n", r.url, err)
+		case err == nil && failing:
+			fmt.Fprintf(os.Stderr, "log output %s: recovered\n", r.url)
+		}
+		failing = err != nil
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case line := <-r.lines:
+			batch = append(batch, line)
+			if len(batch) >= remoteBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (r *remoteOutput) send(batch []remoteLine) error {
+	var body []byte
+	var err error
+	if r.loki {
+		body, err = json.Marshal(lokiPush(batch, r.tag))
+	} else {
+		events := make([]json.RawMessage, len(batch))
+		for i, line := range batch {
+			events[i] = line.data
+		}
+		body, err = json.Marshal(events)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiPush groups lines into streams labelled with the job, level, account and task
+func lokiPush(batch []remoteLine, job string) map[string][]lokiStream {
+	var streams []lokiStream
+	index := make(map[string]int)
+	for _, line := range batch {
+		var fields struct {
+			Account string `json:"account"`
+			Task    string `json:"task"`
+		}
+		json.Unmarshal(line.data, &fields)
+		labels := map[string]string{"job": job}
+		if line.level != zerolog.NoLevel {
+			labels["level"] = line.level.String()
+		}
+		if fields.Account != "" {
+			labels["account"] = fields.Account
+		}
+		if fields.Task != "" {
+			labels["task"] = fields.Task
+		}
+
+		key := labels["level"] + "\x00" + fields.Account + "\x00" + fields.Task
+		i, ok := index[key]
+		if !ok {
+			i = len(streams)
+			index[key] = i
+			streams = append(streams, lokiStream{Stream: labels})
+		}
+		ts := strconv.FormatInt(line.time.UnixNano(), 10)
+		streams[i].Values = append(streams[i].Values, [2]string{ts, string(line.data)})
+	}
+	return map[string][]lokiStream{"streams": streams}
+}
@@ -0,0 +1,129 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// journalSocket is where systemd-journald receives native protocol datagrams
+const journalSocket = "/run/systemd/journal/socket"
+
+// syslogOutput writes events to syslog with the severity of their level
+type syslogOutput struct {
+	w      *syslog.Writer
+	format string
+}
+
+func openSyslog(out Output) (io.Writer, error) {
+	var network, addr string
+	if out.Address != "" {
+		u, err := url.Parse(out.Address)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid syslog address %q, expected udp://host:port or tcp://host:port", out.Address)
+		}
+		network, addr = u.Scheme, u.Host
+	}
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, out.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return syslogOutput{w: w, format: out.Format}, nil
+}
+
+func (s syslogOutput) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (s syslogOutput) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	msg := string(plainMessage(p, s.format))
+	var err error
+	switch l {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		err = s.w.Debug(msg)
+	case zerolog.WarnLevel:
+		err = s.w.Warning(msg)
+	case zerolog.ErrorLevel:
+		err = s.w.Err(msg)
+	case zerolog.FatalLevel:
+		err = s.w.Crit(msg)
+	case zerolog.PanicLevel:
+		err = s.w.Emerg(msg)
+	default:
+		err = s.w.Info(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// journalOutput writes events to systemd-journald over its native protocol, so the level
+// becomes the journal priority
+type journalOutput struct {
+	conn   net.Conn
+	tag    string
+	format string
+}
+
+func openJournal(out Output) (io.Writer, error) {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return journalOutput{conn: conn, tag: out.Tag, format: out.Format}, nil
+}
+
+func (j journalOutput) Write(p []byte) (int, error) {
+	return j.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (j journalOutput) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	var buf bytes.Buffer
+	journalField(&buf, "PRIORITY", strconv.Itoa(int(journalPriority(l))))
+	journalField(&buf, "SYSLOG_IDENTIFIER", j.tag)
+	journalField(&buf, "MESSAGE", string(plainMessage(p, j.format)))
+	if _, err := j.conn.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// journalField appends a field in the native journal protocol, values containing a newline
+// are sent with their length
+func journalField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", key, value)
+		return
+	}
+	buf.WriteString(key + "\n")
+	buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(value))))
+	buf.WriteString(value + "\n")
+}
+
+func journalPriority(l zerolog.Level) syslog.Priority {
+	switch l {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return syslog.LOG_DEBUG
+	case zerolog.WarnLevel:
+		return syslog.LOG_WARNING
+	case zerolog.ErrorLevel:
+		return syslog.LOG_ERR
+	case zerolog.FatalLevel:
+		return syslog.LOG_CRIT
+	case zerolog.PanicLevel:
+		return syslog.LOG_EMERG
+	default:
+		return syslog.LOG_INFO
+	}
+}
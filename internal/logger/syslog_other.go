@@ -0,0 +1,16 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"errors"
+	"io"
+)
+
+func openSyslog(Output) (io.Writer, error) {
+	return nil, errors.New("syslog is not available on this platform")
+}
+
+func openJournal(Output) (io.Writer, error) {
+	return nil, errors.New("journald is not available on this platform")
+}
@@ -0,0 +1,64 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotPending is returned when a code is submitted for an account that is not waiting for one
+var ErrNotPending = errors.New("no login code is pending for this account")
+
+var (
+	mu      sync.Mutex
+	pending = make(map[string]chan string)
+)
+
+// RequestCode waits until a login code for account is submitted with SubmitCode, or ctx is done
+func RequestCode(ctx context.Context, account string) (string, error) {
+	ch := make(chan string, 1)
+	mu.Lock()
+	pending[account] = ch
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		if pending[account] == ch {
+			delete(pending, account)
+		}
+		mu.Unlock()
+	}()
+
+	select {
+	case code := <-ch:
+		return code, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// SubmitCode hands a login code to the pending request of account
+func SubmitCode(account, code string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	ch, ok := pending[account]
+	if !ok {
+		return ErrNotPending
+	}
+	delete(pending, account)
+	ch <- strings.TrimSpace(code)
+	return nil
+}
+
+// Pending returns the sorted accounts waiting for a login code
+func Pending() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	accounts := make([]string, 0, len(pending))
+	for account := range pending {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// serverChanNotifier pushes messages through ServerChan (Server酱) to WeChat
+type serverChanNotifier struct {
+	sendKey string
+	client  *http.Client
+}
+
+// serverChan3Key matches the SendKeys of ServerChan³, which have their own host per user
+var serverChan3Key = regexp.MustCompile(`^sctp(\d+)t`)
+
+func (n *serverChanNotifier) Name() string {
+	return "serverchan"
+}
+
+func (n *serverChanNotifier) Notify(ctx context.Context, msg Message) error {
+	endpoint := "https://sctapi.ftqq.com/" + n.sendKey + ".send"
+	if m := serverChan3Key.FindStringSubmatch(n.sendKey); m != nil {
+		endpoint = fmt.Sprintf("https://%s.push.ft07.com/send/%s.send", m[1], n.sendKey)
+	}
+	form := url.Values{"title": {msg.Title}, "desp": {msg.Text}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doAPIRequest(n.client, req, "code", 0)
+}
+
+// pushPlusNotifier pushes messages through PushPlus to WeChat
+type pushPlusNotifier struct {
+	token  string
+	client *http.Client
+}
+
+func (n *pushPlusNotifier) Name() string {
+	return "pushplus"
+}
+
+func (n *pushPlusNotifier) Notify(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]string{
+		"token":    n.token,
+		"title":    msg.Title,
+		"content":  msg.Text,
+		"template": "txt",
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://www.pushplus.plus/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doAPIRequest(n.client, req, "code", 200)
+}
+
+// weComNotifier posts messages to a WeCom (企业微信) group bot webhook
+type weComNotifier struct {
+	url    string // Webhook URL with the bot key
+	client *http.Client
+}
+
+func (n *weComNotifier) Name() string {
+	return "wecom"
+}
+
+func (n *weComNotifier) Notify(ctx context.Context, msg Message) error {
+	text := msg.Text
+	if msg.Title != "" {
+		text = msg.Title + "\n\n" + msg.Text
+	}
+	content := map[string]any{"content": text}
+	if msg.Priority >= PriorityHigh {
+		// Group bots have no priority, mentioning everyone makes the alert stand out
+		content["mentioned_list"] = []string{"@all"}
+	}
+	body, err := json.Marshal(map[string]any{"msgtype": "text", "text": content})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doAPIRequest(n.client, req, "errcode", 0)
+}
+
+// doAPIRequest performs a request to an API answering errors with status 200 and an error
+// code in the JSON body, and fails unless the codeField of the body equals ok
+func doAPIRequest(client *http.Client, req *http.Request, codeField string, ok int) error {
+	content, err := doRequestBody(client, req)
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(content, &resp); err != nil {
+		return fmt.Errorf("invalid response: %s", bytes.TrimSpace(content))
+	}
+	if code, _ := resp[codeField].(float64); int(code) != ok {
+		return fmt.Errorf("%s %v: %s", codeField, resp[codeField], bytes.TrimSpace(content))
+	}
+	return nil
+}
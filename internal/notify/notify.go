@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"telegram-auto-checkin/internal/config"
+)
+
+// Priority of a notification, channels that support it map it to their own levels
+type Priority int
+
+const (
+	PriorityNormal Priority = iota
+	PriorityHigh
+)
+
+// Message is a notification sent to every configured channel
+type Message struct {
+	Title    string
+	Text     string
+	Priority Priority
+}
+
+// Notifier delivers messages to one notification channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// requestTimeout bounds a single notification request
+const requestTimeout = 15 * time.Second
+
+// Multi sends messages to several notifiers
+type Multi []Notifier
+
+// Name lists the channels of the set
+func (m Multi) Name() string {
+	return fmt.Sprintf("%d channel(s)", len(m))
+}
+
+// Notify sends msg to every notifier and joins their errors
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FromConfig creates the configured notifiers. Requests go through the SOCKS5 proxy when set.
+func FromConfig(cfgs []config.NotifierConfig, proxyAddr string) (Multi, error) {
+	httpClient, err := newHTTPClient(proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	notifiers := make(Multi, 0, len(cfgs))
+	for i, c := range cfgs {
+		var n Notifier
+		switch c.Type {
+		case "telegram":
+			if c.BotToken == "" || c.ChatID == "" {
+				return nil, fmt.Errorf("notifications[%d]: telegram requires bot_token and chat_id", i)
+			}
+			n = &telegramNotifier{token: c.BotToken, chatID: c.ChatID, client: httpClient}
+		case "webhook":
+			if c.URL == "" {
+				return nil, fmt.Errorf("notifications[%d]: webhook requires url", i)
+			}
+			n = &webhookNotifier{url: c.URL, headers: c.Headers, client: httpClient}
+		case "gotify":
+			if c.URL == "" || c.Token == "" {
+				return nil, fmt.Errorf("notifications[%d]: gotify requires url and token", i)
+			}
+			n = &gotifyNotifier{url: c.URL, token: c.Token, client: httpClient}
+		case "ntfy":
+			if c.Topic == "" {
+				return nil, fmt.Errorf("notifications[%d]: ntfy requires topic", i)
+			}
+			n = &ntfyNotifier{url: c.URL, topic: c.Topic, token: c.Token, client: httpClient}
+		case "serverchan":
+			if c.Token == "" {
+				return nil, fmt.Errorf("notifications[%d]: serverchan requires token (the SendKey)", i)
+			}
+			n = &serverChanNotifier{sendKey: c.Token, client: httpClient}
+		case "pushplus":
+			if c.Token == "" {
+				return nil, fmt.Errorf("notifications[%d]: pushplus requires token", i)
+			}
+			n = &pushPlusNotifier{token: c.Token, client: httpClient}
+		case "wecom":
+			if c.URL == "" {
+				return nil, fmt.Errorf("notifications[%d]: wecom requires url (the group bot webhook)", i)
+			}
+			n = &weComNotifier{url: c.URL, client: httpClient}
+		default:
+			return nil, fmt.Errorf("notifications[%d]: unknown type %q", i, c.Type)
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+func newHTTPClient(proxyAddr string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyAddr != "" {
+		dialer, err := proxy.SOCKS5("tcp", proxyAddr, nil, proxy.Direct)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy dialer: %w", err)
+		}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.Dial(network, addr)
+		}
+	}
+	return &http.Client{Transport: transport, Timeout: requestTimeout}, nil
+}
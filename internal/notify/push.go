@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// defaultNtfyURL is the public ntfy server, used when a ntfy channel sets no url
+const defaultNtfyURL = "https://ntfy.sh"
+
+// gotifyNotifier pushes messages to a self-hosted Gotify server
+type gotifyNotifier struct {
+	url    string // Server URL, messages go to <url>/message
+	token  string // Application token
+	client *http.Client
+}
+
+func (n *gotifyNotifier) Name() string {
+	return "gotify"
+}
+
+func (n *gotifyNotifier) Notify(ctx context.Context, msg Message) error {
+	// Gotify's Android app makes a sound from priority 4 and pops up from 8
+	priority := 5
+	if msg.Priority >= PriorityHigh {
+		priority = 8
+	}
+	body, err := json.Marshal(map[string]any{
+		"title":    msg.Title,
+		"message":  msg.Text,
+		"priority": priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(n.url, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", n.token)
+	return doRequest(n.client, req)
+}
+
+// ntfyNotifier publishes messages to a topic of ntfy.sh or a self-hosted ntfy server
+type ntfyNotifier struct {
+	url    string // Server URL, default: https://ntfy.sh
+	topic  string
+	token  string // Access token of protected topics, optional
+	client *http.Client
+}
+
+func (n *ntfyNotifier) Name() string {
+	return "ntfy"
+}
+
+func (n *ntfyNotifier) Notify(ctx context.Context, msg Message) error {
+	// ntfy priorities run from 1 (min) to 5 (urgent), 3 is the default
+	priority, tags := 3, []string{"white_check_mark"}
+	if msg.Priority >= PriorityHigh {
+		priority, tags = 4, []string{"warning"}
+	}
+	body, err := json.Marshal(map[string]any{
+		"topic":    n.topic,
+		"title":    msg.Title,
+		"message":  msg.Text,
+		"priority": priority,
+		"tags":     tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := n.url
+	if url == "" {
+		url = defaultNtfyURL
+	}
+	// Publishing JSON goes to the server root, the topic is part of the body
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(url, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	return doRequest(n.client, req)
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// telegramNotifier sends messages through the Telegram Bot API
+type telegramNotifier struct {
+	token  string
+	chatID string
+	client *http.Client
+}
+
+func (n *telegramNotifier) Name() string {
+	return "telegram"
+}
+
+func (n *telegramNotifier) Notify(ctx context.Context, msg Message) error {
+	text := msg.Text
+	if msg.Title != "" {
+		text = msg.Title + "\n\n" + msg.Text
+	}
+	body, err := json.Marshal(map[string]any{
+		"chat_id":              n.chatID,
+		"text":                 text,
+		"disable_notification": msg.Priority < PriorityHigh,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", n.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doRequest(n.client, req)
+}
+
+// doRequest performs a notification request and fails on non-2xx responses
+func doRequest(client *http.Client, req *http.Request) error {
+	_, err := doRequestBody(client, req)
+	return err
+}
+
+// doRequestBody is doRequest returning the start of the response body, for APIs that
+// report errors in a 200 response
+func doRequestBody(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		// Errors can contain the request URL, which may hold a token
+		return nil, fmt.Errorf("request failed: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(content))
+	}
+	return content, nil
+}
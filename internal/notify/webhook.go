@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// webhookNotifier posts messages as JSON: {"title": ..., "text": ..., "priority": "normal"|"high"}
+type webhookNotifier struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (n *webhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, msg Message) error {
+	priority := "normal"
+	if msg.Priority >= PriorityHigh {
+		priority = "high"
+	}
+	body, err := json.Marshal(map[string]string{
+		"title":    msg.Title,
+		"text":     msg.Text,
+		"priority": priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range n.headers {
+		req.Header.Set(name, value)
+	}
+	return doRequest(n.client, req)
+}
+
+// unwrapURLError drops the URL from *url.Error, keeping only the underlying error
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
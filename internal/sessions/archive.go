@@ -0,0 +1,171 @@
+package sessions
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Encrypted archives are laid out as magic | salt | nonce | AES-256-GCM(tar.gz)
+const (
+	archiveMagic     = "TACSESS1"
+	archiveSaltSize  = 16
+	archiveIterCount = 600000
+)
+
+// ErrPassphraseRequired is returned when restoring an encrypted archive without a passphrase
+var ErrPassphraseRequired = errors.New("archive is encrypted, passphrase required")
+
+// Export writes the given session files into a tar.gz archive, encrypted when passphrase is not empty
+func Export(w io.Writer, files []string, passphrase string) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Name:    filepath.Base(file),
+			Mode:    0600,
+			Size:    int64(len(content)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	if passphrase == "" {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	salt := make([]byte, archiveSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := newArchiveCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	out := make([]byte, 0, len(archiveMagic)+len(salt)+len(nonce)+buf.Len()+aead.Overhead())
+	out = append(out, archiveMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	out = aead.Seal(out, nonce, buf.Bytes(), []byte(archiveMagic))
+	_, err = w.Write(out)
+	return err
+}
+
+// Restore extracts session files from an archive created by Export into dir and returns the written paths
+func Restore(r io.Reader, dir string, passphrase string, force bool) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(data, []byte(archiveMagic)) {
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		data = data[len(archiveMagic):]
+		if len(data) < archiveSaltSize {
+			return nil, fmt.Errorf("archive is truncated")
+		}
+		salt := data[:archiveSaltSize]
+		aead, err := newArchiveCipher(passphrase, salt)
+		if err != nil {
+			return nil, err
+		}
+		data = data[archiveSaltSize:]
+		if len(data) < aead.NonceSize() {
+			return nil, fmt.Errorf("archive is truncated")
+		}
+		nonce := data[:aead.NonceSize()]
+		data, err = aead.Open(nil, nonce, data[aead.NonceSize():], []byte(archiveMagic))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt archive, wrong passphrase?")
+		}
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a session archive: %w", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+		// Only flat session files are expected, never trust paths from the archive
+		name := filepath.Base(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || name != hdr.Name || filepath.Ext(name) != ".session" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if !force {
+			if _, err := os.Stat(path); err == nil {
+				return written, fmt.Errorf("session file %s already exists, use --force to overwrite", path)
+			}
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+func newArchiveCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, archiveIterCount, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
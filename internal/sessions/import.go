@@ -0,0 +1,116 @@
+package sessions
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gotd/td/crypto"
+	"github.com/gotd/td/session"
+	"github.com/gotd/td/session/tdesktop"
+	"github.com/gotd/td/telegram/dcs"
+)
+
+// Supported import formats
+const (
+	FormatAuto     = "auto"
+	FormatTelethon = "telethon"
+	FormatPyrogram = "pyrogram"
+	FormatTData    = "tdata"
+)
+
+// DetectFormat guesses the format of a string session.
+// Telethon strings start with the version character "1", Pyrogram strings
+// start with the base64 encoded DC ID byte (always "A" for DCs 1-5).
+func DetectFormat(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "1"):
+		return FormatTelethon, nil
+	case strings.HasPrefix(s, "A"):
+		return FormatPyrogram, nil
+	default:
+		return "", fmt.Errorf("unable to detect string session format, please pass --format")
+	}
+}
+
+// FromTelethon converts a Telethon StringSession
+func FromTelethon(s string) (*session.Data, error) {
+	data, err := session.TelethonSession(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid telethon session: %w", err)
+	}
+	return data, nil
+}
+
+// FromPyrogram converts a Pyrogram string session, struct layouts by decoded size:
+//
+//	271 bytes ">BI?256sQ?": dc_id, api_id, test_mode, auth_key, user_id, is_bot (current)
+//	267 bytes ">B?256sQ?":  dc_id, test_mode, auth_key, user_id, is_bot (legacy, 64-bit user ID)
+//	263 bytes ">B?256sI?":  dc_id, test_mode, auth_key, user_id, is_bot (legacy, 32-bit user ID)
+func FromPyrogram(s string) (*session.Data, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(s), "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pyrogram session: %w", err)
+	}
+
+	var (
+		dcID     int
+		testMode bool
+		key      crypto.Key
+	)
+	switch len(raw) {
+	case 271:
+		dcID = int(raw[0])
+		testMode = raw[5] != 0
+		copy(key[:], raw[6:262])
+	case 267, 263:
+		dcID = int(raw[0])
+		testMode = raw[1] != 0
+		copy(key[:], raw[2:258])
+	default:
+		return nil, fmt.Errorf("invalid pyrogram session: unexpected length %d", len(raw))
+	}
+
+	addr, err := dcAddr(dcID, testMode)
+	if err != nil {
+		return nil, err
+	}
+	id := key.WithID().ID
+	return &session.Data{
+		Config:    session.Config{TestMode: testMode, ThisDC: dcID},
+		DC:        dcID,
+		Addr:      addr,
+		AuthKey:   key[:],
+		AuthKeyID: id[:],
+	}, nil
+}
+
+// FromTData converts one account of a Telegram Desktop tdata directory
+func FromTData(dir string, passcode string, index int) (*session.Data, error) {
+	accounts, err := tdesktop.Read(dir, []byte(passcode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tdata: %w", err)
+	}
+	if index < 0 || index >= len(accounts) {
+		return nil, fmt.Errorf("tdata contains %d account(s), index %d is out of range", len(accounts), index)
+	}
+	return session.TDesktopSession(accounts[index])
+}
+
+// dcAddr finds the address of a primary IPv4 DC from the built-in DC lists
+func dcAddr(dcID int, testMode bool) (string, error) {
+	list := dcs.Prod()
+	if testMode {
+		list = dcs.Test()
+	}
+	for _, opt := range list.Options {
+		if opt.ID != dcID || opt.Ipv6 || opt.MediaOnly || opt.CDN || opt.TCPObfuscatedOnly {
+			continue
+		}
+		return net.JoinHostPort(opt.IPAddress, strconv.Itoa(opt.Port)), nil
+	}
+	return "", fmt.Errorf("unknown DC %d", dcID)
+}
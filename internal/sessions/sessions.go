@@ -0,0 +1,41 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gotd/td/session"
+)
+
+// Dir is the directory session files are stored in
+const Dir = "session"
+
+// Path returns the session file path for a session name, e.g. session/+1234567890.session
+func Path(sessionName string) string {
+	return filepath.Join(Dir, sessionName+".session")
+}
+
+// Load reads session data from a gotd session file
+func Load(ctx context.Context, path string) (*session.Data, error) {
+	loader := session.Loader{Storage: &session.FileStorage{Path: path}}
+	return loader.Load(ctx)
+}
+
+// Save writes session data to a gotd session file, refusing to overwrite unless force is set
+func Save(ctx context.Context, path string, data *session.Data, force bool) error {
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("session file %s already exists, use --force to overwrite", path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	loader := session.Loader{Storage: &session.FileStorage{Path: path}}
+	return loader.Save(ctx, data)
+}
@@ -0,0 +1,57 @@
+package state
+
+import "time"
+
+// SendCount counts the messages sent to a target on one day
+type SendCount struct {
+	Day   string `json:"day"` // 2006-01-02 in local time
+	Count int    `json:"count"`
+}
+
+// sendKey is the key of the send count of target, shared by all accounts when account is ""
+func sendKey(account, target string) string {
+	if account == "" {
+		return target
+	}
+	return taskKey(account, target)
+}
+
+// ReserveSent counts n messages to target on the day of t unless that would take the count
+// above limit, and returns the count before. Checking and counting under one lock keeps
+// concurrent tasks from all passing the check and overshooting the cap together.
+func (s *State) ReserveSent(account, target string, n, limit int, t time.Time) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := sendKey(account, target)
+	c := s.data.Sends[key]
+	if c.Day != t.Format(dayLayout) {
+		c = SendCount{Day: t.Format(dayLayout)}
+	}
+	if c.Count+n > limit {
+		return c.Count, false, nil
+	}
+	return c.Count, true, s.addSent(key, c, n)
+}
+
+// AddSent counts n more messages to target on the day of t, a negative n returns messages
+// reserved by ReserveSent that were not sent
+func (s *State) AddSent(account, target string, n int, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := sendKey(account, target)
+	c := s.data.Sends[key]
+	if c.Day != t.Format(dayLayout) {
+		c = SendCount{Day: t.Format(dayLayout)}
+	}
+	return s.addSent(key, c, n)
+}
+
+// addSent stores c with n more messages, the caller holds s.mu
+func (s *State) addSent(key string, c SendCount, n int) error {
+	c.Count = max(c.Count+n, 0)
+	if s.data.Sends == nil {
+		s.data.Sends = make(map[string]SendCount)
+	}
+	s.data.Sends[key] = c
+	return s.save()
+}
@@ -0,0 +1,26 @@
+package state
+
+import "slices"
+
+// KnownSessions returns the session hashes seen on an account, known is false before the
+// first check of the account
+func (s *State) KnownSessions(account string) (hashes []int64, known bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hashes, known = s.data.Sessions[account]
+	return slices.Clone(hashes), known
+}
+
+// SetKnownSessions replaces the session hashes seen on an account
+func (s *State) SetKnownSessions(account string, hashes []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Sessions == nil {
+		s.data.Sessions = make(map[string][]int64)
+	}
+	s.data.Sessions[account] = slices.Clone(hashes)
+	return s.save()
+}
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"telegram-auto-checkin/internal/config"
+)
+
+// DefaultFile is the runtime state file used when none is configured
+const DefaultFile = "./data/state.json"
+
+// data is the persisted layout of the state file
+type data struct {
+	Accounts map[string]bool      `json:"accounts,omitempty"` // Runtime enable/disable overrides by account key
+	Tasks    map[string]bool      `json:"tasks,omitempty"`    // Runtime enable/disable overrides by "account/task"
+	Streaks  map[string]Streak    `json:"streaks,omitempty"`  // Daily success streaks by "account/task"
+	Sessions map[string][]int64   `json:"sessions,omitempty"` // Hashes of the sessions seen on an account, by account key
+	Sends    map[string]SendCount `json:"sends,omitempty"`    // Messages sent today by "account/target"
+}
+
+// State holds runtime overrides that survive restarts. A nil *State has no overrides.
+type State struct {
+	mu      sync.RWMutex
+	path    string
+	data    data
+	changed chan struct{} // Closed and replaced when an account override changes
+}
+
+// Open loads the state file at path, a missing file is an empty state
+func Open(path string) (*State, error) {
+	if path == "" {
+		path = DefaultFile
+	}
+	s := &State{path: path}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &s.data); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// AccountEnabled returns the runtime override of an account, set is false without one
+func (s *State) AccountEnabled(account string) (enabled bool, set bool) {
+	if s == nil {
+		return false, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	enabled, set = s.data.Accounts[account]
+	return enabled, set
+}
+
+// TaskEnabled returns the runtime override of a task, set is false without one
+func (s *State) TaskEnabled(account, task string) (enabled bool, set bool) {
+	if s == nil {
+		return false, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	enabled, set = s.data.Tasks[taskKey(account, task)]
+	return enabled, set
+}
+
+// SetAccount stores an account override, nil removes it
+func (s *State) SetAccount(account string, enabled *bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Accounts = setOverride(s.data.Accounts, account, enabled)
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+	return s.save()
+}
+
+// AccountChanged returns a channel that is closed the next time an account override is set.
+// A nil *State never changes.
+func (s *State) AccountChanged() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return s.changed
+}
+
+// SetTask stores a task override, nil removes it
+func (s *State) SetTask(account, task string, enabled *bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Tasks = setOverride(s.data.Tasks, taskKey(account, task), enabled)
+	return s.save()
+}
+
+// save writes the state atomically, the caller holds the lock
+func (s *State) save() error {
+	content, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// AccountActive combines the static enabled flag of an account with its runtime override
+func AccountActive(s *State, acc config.AccountConfig) bool {
+	if enabled, ok := s.AccountEnabled(acc.Key()); ok {
+		return enabled
+	}
+	return acc.Enabled == nil || *acc.Enabled
+}
+
+// TaskActive combines the static enabled flag of a task with the runtime overrides:
+// a disabled account disables all its tasks, a task override replaces the config value
+func TaskActive(s *State, acc config.AccountConfig, task config.TaskConfig) bool {
+	if !AccountActive(s, acc) {
+		return false
+	}
+	if enabled, ok := s.TaskEnabled(acc.Key(), task.DisplayName()); ok {
+		return enabled
+	}
+	return task.Enabled == nil || *task.Enabled
+}
+
+func setOverride(m map[string]bool, key string, enabled *bool) map[string]bool {
+	if enabled == nil {
+		delete(m, key)
+		return m
+	}
+	if m == nil {
+		m = make(map[string]bool)
+	}
+	m[key] = *enabled
+	return m
+}
+
+func taskKey(account, task string) string {
+	return account + "/" + task
+}
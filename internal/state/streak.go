@@ -0,0 +1,67 @@
+package state
+
+import "time"
+
+// dayLayout formats the local day of a streak
+const dayLayout = "2006-01-02"
+
+// Streak counts the consecutive days on which a task succeeded at least once
+type Streak struct {
+	Days int    `json:"days"`
+	Last string `json:"last"` // Day of the latest success, 2006-01-02 in local time
+}
+
+// LastDay returns the day of the latest success, the zero time without one
+func (s Streak) LastDay() time.Time {
+	t, _ := time.ParseInLocation(dayLayout, s.Last, time.Local)
+	return t
+}
+
+// Streak returns the streak of a task, the zero Streak without successes
+func (s *State) Streak(account, task string) Streak {
+	if s == nil {
+		return Streak{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.Streaks[taskKey(account, task)]
+}
+
+// RecordSuccess extends the streak of a task by the day of t. When the previous streak
+// ended before the day, broken is its length and the streak starts again at one day.
+func (s *State) RecordSuccess(account, task string, t time.Time) (streak Streak, broken int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := taskKey(account, task)
+	streak = s.data.Streaks[key]
+	day := t.Format(dayLayout)
+	switch streak.Last {
+	case day:
+		return streak, 0, nil
+	case t.AddDate(0, 0, -1).Format(dayLayout):
+		streak.Days++
+	default:
+		broken = streak.Days
+		streak.Days = 1
+	}
+	streak.Last = day
+	if s.data.Streaks == nil {
+		s.data.Streaks = make(map[string]Streak)
+	}
+	s.data.Streaks[key] = streak
+	return streak, broken, s.save()
+}
+
+// ResetStreak ends the streak of a task, keeping the day of its latest success
+func (s *State) ResetStreak(account, task string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := taskKey(account, task)
+	streak, ok := s.data.Streaks[key]
+	if !ok || streak.Days == 0 {
+		return nil
+	}
+	streak.Days = 0
+	s.data.Streaks[key] = streak
+	return s.save()
+}
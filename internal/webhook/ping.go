@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Ping kinds in healthchecks.io format: <url>/start, <url> and <url>/fail
+const (
+	PingStart   = "start"
+	PingSuccess = ""
+	PingFail    = "fail"
+)
+
+// pingBodyLimit keeps ping bodies well below the size healthchecks.io stores
+const pingBodyLimit = 10000
+
+// Ping sends a healthchecks.io style ping, body (e.g. the error message) is attached to the ping
+func Ping(ctx context.Context, baseURL string, kind string, body string) error {
+	url := strings.TrimRight(baseURL, "/")
+	if kind != PingSuccess {
+		url += "/" + kind
+	}
+	if len(body) > pingBodyLimit {
+		body = body[:pingBodyLimit]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid ping URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("ping returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"telegram-auto-checkin/internal/config"
+)
+
+// requestTimeout bounds a single webhook call
+const requestTimeout = 15 * time.Second
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// funcs are available in URL, header and body templates
+var funcs = template.FuncMap{
+	// json encodes a value as JSON, e.g. "reply": {{json .Reply}}
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ShouldSend reports whether the webhook is configured to fire for the given outcome
+func ShouldSend(cfg config.WebhookConfig, success bool) bool {
+	if cfg.URL == "" {
+		return false
+	}
+	switch strings.ToLower(cfg.On) {
+	case "success":
+		return success
+	case "failure":
+		return !success
+	default:
+		return true
+	}
+}
+
+// Send renders the webhook templates with data and performs the request.
+// Without a body template, data is sent as JSON.
+func Send(ctx context.Context, cfg config.WebhookConfig, data any) error {
+	url, err := render("url", cfg.URL, data)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if cfg.Body != "" {
+		rendered, err := render("body", cfg.Body, data)
+		if err != nil {
+			return err
+		}
+		body = []byte(rendered)
+	} else if body, err = json.Marshal(data); err != nil {
+		return err
+	}
+
+	method := strings.ToUpper(cfg.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	var reader io.Reader
+	if method != http.MethodGet && method != http.MethodHead {
+		reader = bytes.NewReader(body)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("invalid webhook request: %w", err)
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range cfg.Headers {
+		rendered, err := render("header", value, data)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(name, rendered)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func render(name, text string, data any) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render webhook %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,9 @@
+// Package locales embeds the translation files, used when no locales directory is found on disk
+package locales
+
+import "embed"
+
+// FS holds en.yaml and zh.yaml
+//
+//go:embed *.yaml
+var FS embed.FS
@@ -4,30 +4,69 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/viper"
 
 	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/control"
+	"telegram-auto-checkin/internal/diag"
 	"telegram-auto-checkin/internal/i18n"
 	"telegram-auto-checkin/internal/logger"
-	"telegram-auto-checkin/internal/scheduler"
+	"telegram-auto-checkin/internal/state"
+	"telegram-auto-checkin/pkg/scheduler"
 )
 
 var (
 	runOnce    = flag.Bool("once", false, "Run all tasks once and exit")
 	logLevel   = flag.String("log-level", "", "Log level: debug|info|warn|error (default: info)")
-	configPath = flag.String("config", "config.yaml", "Path to main config file (YAML)")
+	configPath = flag.String("config", "config.yaml", "Path or https://, s3:// URL of the main config file (YAML)")
+	refresh    = flag.Duration("config-refresh", 5*time.Minute, "How often the daemon checks a remote --config for changes and restarts with it, 0 disables")
+	outputMode = flag.String("output", "text", "Result output for --once: text|json (json writes one object per task to stdout, logs go to stderr)")
+	onlyAcc    = flag.String("account", "", "Only run these accounts in --once mode (comma-separated names or phones)")
+	onlyTag    = flag.String("tag", "", "Only run tasks tagged (directly or via their account) with one of these tags in --once mode (comma-separated)")
+	onlyTask   = flag.String("task", "", "Only run these tasks in --once mode (comma-separated names)")
+	workDir    = flag.String("workdir", "", "Change to this directory before loading the config (set by service install)")
+	unsafe     = flag.Bool("unsafe", false, "Run tasks whose target is not in the allowed_targets of their account, and fetch a remote --config over plain http://")
+	remoteCmds = flag.Bool("allow-remote-scripts", false, "Run script tasks and login_code commands of a remote --config")
 
 	log zerolog.Logger
 )
 
 func main() {
+	// Subcommands are dispatched before the daemon flags are parsed
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "session":
+			os.Exit(runSessionCommand(os.Args[2:]))
+		case "init":
+			os.Exit(runInitCommand(os.Args[2:]))
+		case "schedule":
+			os.Exit(runScheduleCommand(os.Args[2:]))
+		case "service":
+			os.Exit(runServiceCommand(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctorCommand(os.Args[2:]))
+		case "history":
+			os.Exit(runHistoryCommand(os.Args[2:]))
+		}
+	}
+
 	flag.Parse()
 
+	if *workDir != "" {
+		if err := os.Chdir(*workDir); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --workdir: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
 	// Initialize viper
 	v := viper.New()
 
@@ -36,27 +75,43 @@ func main() {
 		v.Set("log.level", *logLevel)
 	}
 
+	// Keep stdout clean for machine-readable results
+	if *outputMode != "text" && *outputMode != "json" {
+		fmt.Fprintf(os.Stderr, "invalid --output=%q, expected text or json\n", *outputMode)
+		os.Exit(exitError)
+	}
+	if *outputMode == "json" {
+		logger.SetConsoleOutput(os.Stderr)
+	}
+
 	// Use default console logger first, initialize file logger after loading config
 	log = logger.SetupLogger(*logLevel)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
+	ctx = serviceContext(ctx)
 
+	// Without --config, a config mounted at /config/config.yaml wins over the bundled one
+	explicitConfig := false
+	flag.Visit(func(f *flag.Flag) { explicitConfig = explicitConfig || f.Name == "config" })
+	*configPath = config.ResolvePath(*configPath, explicitConfig)
+
+	config.SetAllowPlainHTTP(*unsafe)
 	cfg, err := config.LoadConfig(*configPath, v)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to load configuration")
+		log.Error().Err(err).Msg(i18n.T("config_load_failed"))
 		os.Exit(1)
 	}
 
-	// Initialize internationalization
+	// Initialize internationalization, the configured language wins over the OS locale
 	lang := cfg.Language
 	if lang == "" {
-		lang = "en"
+		lang = i18n.DetectLanguage()
 	}
 	if err := i18n.Init(lang); err != nil {
-		log.Warn().Err(err).Str("language", lang).Msg("Failed to initialize i18n, using default")
+		log.Warn().Err(err).Str("language", lang).Msg(i18n.T("i18n_init_failed"))
 	} else {
-		log.Info().Str("language", lang).Msg("Language initialized")
+		log.Info().Str("language", lang).Msg(i18n.T("language_initialized"))
 	}
 
 	// Reinitialize logging system with config directory
@@ -65,9 +120,13 @@ func main() {
 	if *logLevel != "" {
 		effectiveLogLevel = *logLevel
 	}
-	fileLogger, err := logger.SetupLoggerWithFile(effectiveLogLevel, cfg.Log.Dir, cfg.Log.Format)
+	outputs := make([]logger.Output, len(cfg.Log.Outputs))
+	for i, out := range cfg.Log.Outputs {
+		outputs[i] = logger.Output(out)
+	}
+	fileLogger, err := logger.SetupLoggerWithFile(effectiveLogLevel, cfg.Log.Dir, cfg.Log.Format, outputs...)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to initialize file logging system")
+		log.Error().Err(err).Msg(i18n.T("file_logging_failed"))
 		os.Exit(1)
 	}
 	log = fileLogger
@@ -75,7 +134,7 @@ func main() {
 	// Print configuration info for verification
 	appEnv := os.Getenv("APP_ENV")
 	if appEnv != "" {
-		log.Info().Str("environment", appEnv).Msg("Using environment-specific config")
+		log.Info().Str("environment", appEnv).Msg(i18n.T("env_config_used"))
 	}
 
 	log.Info().
@@ -87,28 +146,257 @@ func main() {
 		Str("proxy", cfg.Proxy).
 		Msg("Configuration loaded successfully")
 
+	// The clock check must not delay a --once run, which only needs it for clock_correction.
+	// The correction applies to clients as soon as it is measured, connected ones included.
+	if !*runOnce || cfg.ClockCorrection {
+		go checkClock(ctx, cfg)
+	}
+
+	// Runtime toggles survive restarts, without them only the config applies
+	st, err := state.Open(cfg.StateFile)
+	if err != nil {
+		log.Warn().Err(err).Msg(i18n.T("state_load_failed"))
+	}
+
+	filter := config.TaskFilter{
+		Accounts: splitList(*onlyAcc),
+		Tags:     splitList(*onlyTag),
+		Tasks:    splitList(*onlyTask),
+	}
+
 	if *runOnce {
-		if err := scheduler.RunTasksOnce(ctx, cfg, log); err != nil {
-			if errors.Is(err, context.Canceled) {
-				log.Info().Msg("Tasks cancelled")
-				os.Exit(0)
+		if !filter.Empty() {
+			cfg = filter.Apply(cfg)
+			if cfg.TaskCount() == 0 {
+				log.Error().Msg(i18n.T("filters_no_match"))
+				os.Exit(exitError)
 			}
-			log.Error().Err(err).Msg("Task execution failed")
-			os.Exit(1)
+			log.Info().Int("accounts", len(cfg.Accounts)).Int("tasks", cfg.TaskCount()).Msg(i18n.T("filters_applied"))
 		}
-		log.Info().Msg("All tasks completed, exiting")
-		return
+		checkAllowedTargets(cfg)
+		checkRemoteCommands(cfg)
+
+		opts := scheduler.OnceOptions{State: st}
+		var reporter *jsonReporter
+		if *outputMode == "json" {
+			reporter = newJSONReporter(os.Stdout)
+			opts.OnResult = reporter.Report
+		}
+
+		err := scheduler.RunTasksOnce(ctx, cfg, log, opts)
+		code := exitCodeFor(err)
+		if errors.Is(err, context.Canceled) {
+			code = exitOK
+		}
+		if reporter != nil {
+			reporter.Summary(code, err)
+		}
+		switch {
+		case err == nil:
+			log.Info().Msg(i18n.T("once_completed"))
+		case errors.Is(err, context.Canceled):
+			log.Info().Msg(i18n.T("once_cancelled"))
+		default:
+			log.Error().Err(err).Int("exit_code", code).Msg(i18n.T("once_failed"))
+		}
+		os.Exit(code)
+	}
+
+	if *outputMode == "json" {
+		log.Warn().Msg(i18n.T("output_json_ignored"))
+	}
+	if !filter.Empty() {
+		log.Warn().Msg(i18n.T("filters_ignored"))
+	}
+	checkAllowedTargets(cfg)
+	checkRemoteCommands(cfg)
+
+	// A changed remote config drains and stops the daemon, which then restarts with it
+	daemonCtx, stopDaemon := context.WithCancel(ctx)
+	defer stopDaemon()
+	changed := make(chan struct{})
+	if config.IsRemote(*configPath) && *refresh > 0 {
+		go watchRemoteConfig(daemonCtx, *configPath, cfg.RemoteVersion(), *refresh, changed)
+	}
+
+	if cfg.Control.Listen != "" {
+		if st == nil {
+			log.Warn().Msg(i18n.T("control_no_state"))
+		} else {
+			if cfg.Control.Token == "" {
+				log.Warn().Msg(i18n.T("control_no_token"))
+			}
+			go func() {
+				if err := control.NewServer(cfg, st, log).Run(daemonCtx, cfg.Control.Listen); err != nil {
+					log.Error().Err(err).Msg(i18n.T("control_stopped"))
+				}
+			}()
+		}
+	}
+
+	if cfg.Diagnostics.Listen != "" {
+		go func() {
+			if err := diag.Serve(daemonCtx, cfg.Diagnostics.Listen, log); err != nil {
+				log.Error().Err(err).Msg(i18n.T("diag_stopped"))
+			}
+		}()
+	}
+	if cfg.Diagnostics.ReportSeconds > 0 {
+		go diag.Report(daemonCtx, time.Duration(cfg.Diagnostics.ReportSeconds)*time.Second, log)
 	}
 
-	if err := scheduler.RunTasks(ctx, cfg, log); err != nil {
+	daemon, err := scheduler.StartTasks(daemonCtx, cfg, log, st)
+	if err != nil {
 		if errors.Is(err, context.Canceled) {
-			log.Info().Msg("Scheduled tasks cancelled")
+			log.Info().Msg(i18n.T("scheduled_tasks_cancelled"))
 			os.Exit(0)
 		}
-		log.Error().Err(err).Msg("Failed to initialize scheduled tasks")
+		log.Error().Err(err).Msg(i18n.T("scheduler_init_failed"))
 		os.Exit(1)
 	}
 
-	<-ctx.Done()
-	log.Info().Msg("Received exit signal, shutting down...")
+	select {
+	case <-ctx.Done():
+	case <-changed:
+		restartDaemon(ctx, daemon, stopDaemon)
+	}
+	log.Info().Msg(i18n.T("exit_signal"))
+}
+
+// Bounds of the shutdown before a restart with a changed remote config
+const (
+	restartDrainTimeout = 5 * time.Minute  // Wait for running and queued tasks
+	restartStopTimeout  = 30 * time.Second // Wait for the sessions to close
+)
+
+// restartDaemon lets the running and queued tasks of daemon finish, closes its sessions and
+// replaces the process with one started with the changed remote config. It returns when a
+// signal arrives in the meantime, so the daemon shuts down instead.
+func restartDaemon(ctx context.Context, daemon *scheduler.Daemon, stopDaemon context.CancelFunc) {
+	log.Info().Msg(i18n.T("remote_config_draining"))
+	drainCtx, cancel := context.WithTimeout(ctx, restartDrainTimeout)
+	err := daemon.Drain(drainCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg(i18n.T("restart_tasks_running"))
+	}
+
+	stopDaemon()
+	stopCtx, cancel := context.WithTimeout(ctx, restartStopTimeout)
+	err = daemon.Wait(stopCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg(i18n.T("restart_sessions_open"))
+	}
+
+	log.Info().Msg(i18n.T("restarting_remote_config"))
+	if err := restartSelf(); err != nil {
+		log.Error().Err(err).Msg(i18n.T("restart_failed"))
+		os.Exit(exitRestart)
+	}
+}
+
+// watchRemoteConfig checks the remote config at path every interval and closes changed once a
+// changed version loads and passes the startup checks. A broken config only logs an error,
+// the daemon keeps running with the config it has.
+func watchRemoteConfig(ctx context.Context, path, version string, interval time.Duration, changed chan<- struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		newVersion, updated, err := config.RemoteChanged(ctx, path, version)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn().Err(err).Msg(i18n.T("remote_check_failed"))
+			}
+			continue
+		}
+		if !updated {
+			continue
+		}
+		cfg, err := config.LoadConfig(path, viper.New())
+		if err == nil && !*unsafe {
+			err = config.CheckAllowedTargets(cfg)
+		}
+		if err == nil && !*remoteCmds {
+			err = config.CheckRemoteCommands(cfg)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("version", newVersion).Msg(i18n.T("remote_config_invalid"))
+			version = newVersion
+			continue
+		}
+		log.Info().Str("version", cfg.RemoteVersion()).Msg(i18n.T("remote_config_changed"))
+		close(changed)
+		return
+	}
+}
+
+// clockCheckTimeout bounds the clock skew measurement at startup
+const clockCheckTimeout = 5 * time.Second
+
+// checkClock warns about a skewed local clock and, with clock_correction, makes the
+// Telegram clients compensate for it
+func checkClock(ctx context.Context, cfg *config.Config) {
+	ctx, cancel := context.WithTimeout(ctx, clockCheckTimeout)
+	defer cancel()
+	skew, err := diag.ClockSkew(ctx, cfg.Proxy)
+	if err != nil {
+		log.Debug().Err(err).Msg(i18n.T("clock_check_failed"))
+		return
+	}
+	if skew.Abs() >= diag.ClockSkewWarn {
+		log.Warn().Dur("skew", skew).Msg(i18n.T("clock_skewed"))
+	}
+	if cfg.ClockCorrection && skew != 0 {
+		scheduler.SetClockOffset(-skew)
+		log.Info().Dur("offset", -skew).Msg(i18n.T("clock_correcting"))
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// checkAllowedTargets exits when a task of cfg sends outside the allowed_targets of its
+// account, or only warns with --unsafe. Filtered out tasks are not checked.
+func checkAllowedTargets(cfg *config.Config) {
+	err := config.CheckAllowedTargets(cfg)
+	if err == nil {
+		return
+	}
+	if !*unsafe {
+		log.Error().Err(err).Msg(i18n.T("allowed_targets_refused"))
+		os.Exit(exitError)
+	}
+	log.Warn().Err(err).Msg(i18n.T("allowed_targets_unsafe"))
+}
+
+// checkRemoteCommands exits when a remote cfg runs commands on the host, unless
+// --allow-remote-scripts is set. Filtered out tasks are not checked.
+func checkRemoteCommands(cfg *config.Config) {
+	if !config.IsRemote(*configPath) || *remoteCmds {
+		return
+	}
+	if err := config.CheckRemoteCommands(cfg); err != nil {
+		log.Error().Err(err).Msg(i18n.T("remote_commands_refused"))
+		os.Exit(exitError)
+	}
 }
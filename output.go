@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"telegram-auto-checkin/pkg/executor"
+	"telegram-auto-checkin/pkg/scheduler"
+)
+
+// Exit codes returned by --once runs, stable for scripts and cron jobs
+const (
+	exitOK          = 0 // All tasks succeeded (or were cancelled by signal)
+	exitError       = 1 // Configuration, login or other fatal error
+	exitTasksFailed = 2 // Every account ran, but at least one task failed
+	exitRestart     = 3 // The daemon stopped to apply a changed remote config but could not restart itself
+)
+
+// exitCodeFor maps a RunTasksOnce error to the process exit code
+func exitCodeFor(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, e := range errs {
+		if !errors.Is(e, scheduler.ErrTasksFailed) {
+			return exitError
+		}
+	}
+	return exitTasksFailed
+}
+
+// resultRecord is the JSON line emitted for every task result in --output json mode
+type resultRecord struct {
+	Type       string             `json:"type"`
+	Account    string             `json:"account"`
+	Task       string             `json:"task"`
+	Target     string             `json:"target"`
+	Method     string             `json:"method"`
+	Trigger    string             `json:"trigger"`
+	RequestID  string             `json:"request_id"`
+	Success    bool               `json:"success"`
+	Error      string             `json:"error,omitempty"`
+	ErrorClass string             `json:"error_class,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	DurationMS int64              `json:"duration_ms"`
+	Extracted  map[string]float64 `json:"extracted,omitempty"`
+}
+
+// summaryRecord is the final JSON line emitted in --output json mode
+type summaryRecord struct {
+	Type       string `json:"type"`
+	Total      int    `json:"total"`
+	Succeeded  int    `json:"succeeded"`
+	Failed     int    `json:"failed"`
+	DurationMS int64  `json:"duration_ms"`
+	ExitCode   int    `json:"exit_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+// jsonReporter writes one JSON object per line, safe for concurrent use
+type jsonReporter struct {
+	mu        sync.Mutex
+	enc       *json.Encoder
+	startedAt time.Time
+	succeeded int
+	failed    int
+}
+
+func newJSONReporter(w io.Writer) *jsonReporter {
+	return &jsonReporter{
+		enc:       json.NewEncoder(w),
+		startedAt: time.Now(),
+	}
+}
+
+// Report emits a single task result
+func (r *jsonReporter) Report(res executor.TaskResult) {
+	rec := resultRecord{
+		Type:       "result",
+		Account:    res.Account,
+		Task:       res.Task,
+		Target:     res.Target,
+		Method:     res.Method,
+		Trigger:    res.Trigger,
+		RequestID:  res.RequestID,
+		Success:    res.Success(),
+		StartedAt:  res.StartedAt,
+		DurationMS: res.Duration.Milliseconds(),
+		Extracted:  res.Extracted,
+	}
+	if res.Err != nil {
+		rec.Error = res.Err.Error()
+		rec.ErrorClass = res.ErrorClass
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rec.Success {
+		r.succeeded++
+	} else {
+		r.failed++
+	}
+	_ = r.enc.Encode(rec)
+}
+
+// Summary emits the final summary line
+func (r *jsonReporter) Summary(exitCode int, runErr error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := summaryRecord{
+		Type:       "summary",
+		Total:      r.succeeded + r.failed,
+		Succeeded:  r.succeeded,
+		Failed:     r.failed,
+		DurationMS: time.Since(r.startedAt).Milliseconds(),
+		ExitCode:   exitCode,
+	}
+	if runErr != nil {
+		rec.Error = runErr.Error()
+	}
+	_ = r.enc.Encode(rec)
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotd/td/fileid"
+	"github.com/gotd/td/telegram/uploader"
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// Media types of MediaOptions.Type
+const (
+	MediaPhoto   = "photo"
+	MediaSticker = "sticker"
+	MediaGIF     = "gif"
+)
+
+// MediaOptions select the attachment of the media method, exactly one of File, FileID
+// and StickerSet is set
+type MediaOptions struct {
+	Type       string // photo | sticker | gif
+	File       string // Local file to upload
+	FileID     string // Bot API file_id of an already uploaded file
+	StickerSet string // Short name of a sticker set
+	Sticker    string // Emoji picking the sticker of StickerSet, empty for the first one
+}
+
+// SendMediaInRunWithLogger sends a photo, sticker or GIF to the target, with caption in the given
+// payload format for photos and GIFs (with task logger)
+func (c *Client) SendMediaInRunWithLogger(ctx context.Context, target string, media MediaOptions, caption string, format string, taskLogger zerolog.Logger) error {
+	taskLog := taskLogger.With().Str("target", target).Str("media", media.Type).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Str("media", media.Type).Logger()
+
+	text, entities, err := formatMessage(caption, format)
+	if err != nil {
+		return err
+	}
+
+	taskLog.Info().Msg(i18n.T("sending_media"))
+	mainLog.Info().Msg(i18n.T("sending_media"))
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	input, err := c.inputMedia(ctx, media)
+	if err != nil {
+		return err
+	}
+	req := &tg.MessagesSendMediaRequest{
+		Peer:     peer,
+		Media:    input,
+		Message:  text,
+		Entities: entities,
+	}
+	updates, err := c.sendWithHandshake(ctx, peer, target, func() (tg.UpdatesClass, error) {
+		req.RandomID = randInt64()
+		return c.api.MessagesSendMedia(ctx, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	messageID := 0
+	if msg := findSentMessage(updates); msg != nil {
+		messageID = msg.ID
+		ReportFrom(ctx).addSent(msg.ID)
+	}
+	for _, lg := range []zerolog.Logger{taskLog, mainLog} {
+		lg.Info().Int("message_id", messageID).Msg(i18n.T("media_sent"))
+	}
+	return nil
+}
+
+// inputMedia turns the media options into the media of a send request, uploading a local file
+func (c *Client) inputMedia(ctx context.Context, media MediaOptions) (tg.InputMediaClass, error) {
+	switch {
+	case media.StickerSet != "":
+		doc, err := c.stickerFromSet(ctx, media.StickerSet, media.Sticker)
+		if err != nil {
+			return nil, err
+		}
+		return &tg.InputMediaDocument{ID: doc}, nil
+	case media.FileID != "":
+		return mediaFromFileID(media.FileID)
+	case media.File != "":
+		return c.uploadMedia(ctx, media)
+	default:
+		return nil, fmt.Errorf("media needs a file, file_id or sticker_set")
+	}
+}
+
+// uploadMedia uploads a local photo, sticker or GIF
+func (c *Client) uploadMedia(ctx context.Context, media MediaOptions) (tg.InputMediaClass, error) {
+	file, err := uploader.NewUploader(c.api).FromPath(ctx, media.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", media.File, err)
+	}
+	name := &tg.DocumentAttributeFilename{FileName: filepath.Base(media.File)}
+	switch media.Type {
+	case MediaPhoto:
+		return &tg.InputMediaUploadedPhoto{File: file}, nil
+	case MediaSticker:
+		return &tg.InputMediaUploadedDocument{
+			File:       file,
+			MimeType:   "image/webp",
+			Attributes: []tg.DocumentAttributeClass{name, &tg.DocumentAttributeSticker{Stickerset: &tg.InputStickerSetEmpty{}}},
+		}, nil
+	case MediaGIF:
+		mimeType := "video/mp4"
+		if strings.EqualFold(filepath.Ext(media.File), ".gif") {
+			mimeType = "image/gif"
+		}
+		return &tg.InputMediaUploadedDocument{
+			File:       file,
+			MimeType:   mimeType,
+			Attributes: []tg.DocumentAttributeClass{name, &tg.DocumentAttributeAnimated{}},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown media type %q, expected photo, sticker or gif", media.Type)
+	}
+}
+
+// mediaFromFileID references a file by its Bot API file_id. The file reference in it may have
+// expired, Telegram then rejects the request with FILE_REFERENCE_EXPIRED.
+func mediaFromFileID(id string) (tg.InputMediaClass, error) {
+	f, err := fileid.DecodeFileID(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file_id: %w", err)
+	}
+	if f.Type == fileid.Photo {
+		return &tg.InputMediaPhoto{ID: &tg.InputPhoto{ID: f.ID, AccessHash: f.AccessHash, FileReference: f.FileReference}}, nil
+	}
+	return &tg.InputMediaDocument{ID: &tg.InputDocument{ID: f.ID, AccessHash: f.AccessHash, FileReference: f.FileReference}}, nil
+}
+
+// stickerFromSet returns the first sticker of the set matching emoji, or the first sticker
+// when emoji is empty
+func (c *Client) stickerFromSet(ctx context.Context, shortName, emoji string) (*tg.InputDocument, error) {
+	res, err := c.api.MessagesGetStickerSet(ctx, &tg.MessagesGetStickerSetRequest{
+		Stickerset: &tg.InputStickerSetShortName{ShortName: shortName},
+	})
+	if err != nil {
+		return nil, errs.Classify(err)
+	}
+	set, ok := res.(*tg.MessagesStickerSet)
+	if !ok || len(set.Documents) == 0 {
+		return nil, fmt.Errorf("sticker set %q is empty", shortName)
+	}
+
+	wantID := int64(0)
+	if emoji != "" {
+		for _, pack := range set.Packs {
+			if pack.Emoticon == emoji && len(pack.Documents) > 0 {
+				wantID = pack.Documents[0]
+				break
+			}
+		}
+		if wantID == 0 {
+			return nil, fmt.Errorf("sticker set %q has no sticker for %s", shortName, emoji)
+		}
+	}
+	for _, d := range set.Documents {
+		doc, ok := d.(*tg.Document)
+		if ok && (wantID == 0 || doc.ID == wantID) {
+			return doc.AsInput(), nil
+		}
+	}
+	return nil, fmt.Errorf("sticker set %q has no sticker for %s", shortName, emoji)
+}
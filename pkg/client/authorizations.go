@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"telegram-auto-checkin/internal/errs"
+)
+
+// Authorization is a session logged in to the account, as listed under
+// Settings > Devices in the official apps
+type Authorization struct {
+	Hash       int64 // Identifies the session, 0 for the current one
+	Current    bool  // The session of this client
+	Device     string
+	Platform   string
+	App        string // App name and version
+	IP         string
+	Location   string // Country and region of the IP
+	Created    time.Time
+	LastActive time.Time
+}
+
+// AuthorizationsInRun lists the sessions logged in to the account
+func (c *Client) AuthorizationsInRun(ctx context.Context) ([]Authorization, error) {
+	res, err := c.api.AccountGetAuthorizations(ctx)
+	if err != nil {
+		return nil, errs.Classify(err)
+	}
+	auths := make([]Authorization, 0, len(res.Authorizations))
+	for _, a := range res.Authorizations {
+		location := a.Country
+		if a.Region != "" {
+			location = a.Region + ", " + a.Country
+		}
+		auths = append(auths, Authorization{
+			Hash:       a.Hash,
+			Current:    a.Current,
+			Device:     a.DeviceModel,
+			Platform:   a.Platform,
+			App:        a.AppName + " " + a.AppVersion,
+			IP:         a.IP,
+			Location:   location,
+			Created:    time.Unix(int64(a.DateCreated), 0),
+			LastActive: time.Unix(int64(a.DateActive), 0),
+		})
+	}
+	return auths, nil
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// Actions for a matched button that is neither a callback nor a web app button
+const (
+	OtherButtonFail = "fail" // Fail the task
+	OtherButtonSkip = "skip" // Log the button and report success
+	OtherButtonOpen = "open" // Follow URL buttons and send the text of reply keyboard buttons, fail otherwise
+)
+
+// buttonPollInterval is how often waitButton looks for the button again
+const buttonPollInterval = 3 * time.Second
+
+// latestButton returns the latest message of the chat and its button with the given text,
+// along with the history response holding the users the message refers to
+func (c *Client) latestButton(ctx context.Context, peer tg.InputPeerClass, text string) (*tg.Message, tg.KeyboardButtonClass, tg.MessagesMessagesClass, error) {
+	history, err := c.api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+		Peer:  peer,
+		Limit: 1,
+	})
+	if err != nil {
+		return nil, nil, nil, errs.Classify(err)
+	}
+	msgs, err := extractMessages(history)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: no messages found", errs.ErrButtonNotFound)
+	}
+
+	msg, ok := msgs[0].(*tg.Message)
+	if !ok || msg.ReplyMarkup == nil {
+		return nil, nil, nil, fmt.Errorf("%w: latest message has no buttons", errs.ErrButtonNotFound)
+	}
+	btn := findButton(msg.ReplyMarkup, text)
+	if btn == nil {
+		return nil, nil, nil, fmt.Errorf("%w: no button with text %q", errs.ErrButtonNotFound, text)
+	}
+	return msg, btn, history, nil
+}
+
+// waitButton polls the latest message until it carries the button, for bots posting the
+// check-in keyboard only after a trigger or at a set time. It gives up after timeout with
+// the last ErrButtonNotFound.
+func (c *Client) waitButton(ctx context.Context, peer tg.InputPeerClass, text string, timeout time.Duration) (*tg.Message, tg.KeyboardButtonClass, tg.MessagesMessagesClass, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(buttonPollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			return nil, nil, nil, fmt.Errorf("gave up waiting %s for the button: %w", timeout, lastErr)
+		case <-ticker.C:
+		}
+		msg, btn, history, err := c.latestButton(ctx, peer, text)
+		if err != nil && ctx.Err() != nil {
+			continue
+		}
+		if !errors.Is(err, errs.ErrButtonNotFound) {
+			// Found, or a request error other than the button missing
+			return msg, btn, history, err
+		}
+		lastErr = err
+	}
+}
+
+// findButton returns the first inline or reply keyboard button with the given text
+func findButton(markup tg.ReplyMarkupClass, text string) tg.KeyboardButtonClass {
+	var rows []tg.KeyboardButtonRow
+	switch m := markup.(type) {
+	case *tg.ReplyInlineMarkup:
+		rows = m.Rows
+	case *tg.ReplyKeyboardMarkup:
+		rows = m.Rows
+	}
+	for _, row := range rows {
+		for _, btn := range row.Buttons {
+			if btn.GetText() == text {
+				return btn
+			}
+		}
+	}
+	return nil
+}
+
+// describeButton returns a short kind of a button and its most relevant detail for logging
+func describeButton(btn tg.KeyboardButtonClass) (kind string, detail string) {
+	switch b := btn.(type) {
+	case *tg.KeyboardButtonCallback:
+		return "callback", ""
+	case *tg.KeyboardButtonURL:
+		return "url", b.URL
+	case *tg.KeyboardButtonURLAuth:
+		return "url_auth", b.URL
+	case *tg.KeyboardButtonWebView:
+		return "webapp", b.URL
+	case *tg.KeyboardButtonSimpleWebView:
+		return "webapp", b.URL
+	case *tg.KeyboardButtonSwitchInline:
+		return "switch_inline", b.Query
+	case *tg.KeyboardButtonBuy:
+		return "buy", ""
+	case *tg.KeyboardButtonGame:
+		return "game", ""
+	case *tg.KeyboardButton:
+		return "keyboard", ""
+	default:
+		return strings.TrimPrefix(fmt.Sprintf("%T", btn), "*tg.KeyboardButton"), ""
+	}
+}
+
+// handleOtherButton applies the configured action to a matched non-callback, non-web-app button
+func (c *Client) handleOtherButton(ctx context.Context, peer tg.InputPeerClass, btn tg.KeyboardButtonClass, action string, loggers []zerolog.Logger) error {
+	kind, detail := describeButton(btn)
+	for _, lg := range loggers {
+		lg.Info().Str("button_type", kind).Str("detail", detail).Str("action", action).Msg(i18n.T("button_not_callback"))
+	}
+
+	switch action {
+	case "", OtherButtonFail:
+		return fmt.Errorf("button %q is a %s button, not a callback button (set other_button to skip or open)", btn.GetText(), kind)
+	case OtherButtonSkip:
+		return nil
+	case OtherButtonOpen:
+	default:
+		return fmt.Errorf("unknown other_button action %q, expected fail, skip or open", action)
+	}
+
+	switch b := btn.(type) {
+	case *tg.KeyboardButtonURL, *tg.KeyboardButtonURLAuth:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, detail, nil)
+		if err != nil {
+			return fmt.Errorf("invalid button URL: %w", err)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return errs.Wrap(errs.ErrNetwork, err)
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webAppReplyLimit))
+		for _, lg := range loggers {
+			lg.Info().Str("url", detail).Int("status", resp.StatusCode).Msg(i18n.T("button_url_opened"))
+		}
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("button URL returned status %d", resp.StatusCode)
+		}
+		return nil
+	case *tg.KeyboardButton:
+		// Pressing a reply keyboard button just sends its text
+		updates, err := c.api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
+			Peer:     peer,
+			Message:  b.Text,
+			RandomID: randInt64(),
+		})
+		if err != nil {
+			return errs.Classify(err)
+		}
+		_, messageID := parseSendMessageResult(updates)
+		for _, lg := range loggers {
+			lg.Info().Int("sent_message_id", messageID).Msg(i18n.T("keyboard_button_pressed"))
+		}
+		return nil
+	default:
+		return fmt.Errorf("button %q is a %s button and cannot be opened", btn.GetText(), kind)
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"telegram-auto-checkin/internal/errs"
+)
+
+// defaultMaxRedirects matches the limit of net/http
+const defaultMaxRedirects = 10
+
+// CallbackURLOptions configure what happens when a callback answer carries a URL
+type CallbackURLOptions struct {
+	Open         bool // Open the URL, following redirects, and fail on an error status
+	MaxRedirects int  // Redirects to follow, default: 10
+}
+
+// openCallbackURL opens the URL of a callback answer through the client's proxy and returns
+// the status and URL of the final response after redirects
+func (c *Client) openCallbackURL(ctx context.Context, rawURL string, opts CallbackURLOptions) (int, string, error) {
+	maxRedirects := opts.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	httpClient := *c.httpClient
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid callback URL: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, "", errs.Wrap(errs.ErrNetwork, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webAppReplyLimit))
+
+	return resp.StatusCode, resp.Request.URL.String(), nil
+}
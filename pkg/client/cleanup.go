@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gotd/td/tg"
+
+	"telegram-auto-checkin/internal/errs"
+)
+
+// DeleteMessagesInRun deletes messages of the target chat for everyone
+func (c *Client) DeleteMessagesInRun(ctx context.Context, target string, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+	// Supergroups and channels number their messages separately and need their own call
+	if channel, ok := peer.(*tg.InputPeerChannel); ok {
+		_, err = c.api.ChannelsDeleteMessages(ctx, &tg.ChannelsDeleteMessagesRequest{
+			Channel: &tg.InputChannel{ChannelID: channel.ChannelID, AccessHash: channel.AccessHash},
+			ID:      ids,
+		})
+	} else {
+		_, err = c.api.MessagesDeleteMessages(ctx, &tg.MessagesDeleteMessagesRequest{
+			Revoke: true,
+			ID:     ids,
+		})
+	}
+	return errs.Classify(err)
+}
+
+// ClearHistoryInRun clears the account's history of a private chat, e.g. with a check-in bot
+func (c *Client) ClearHistoryInRun(ctx context.Context, target string) error {
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+	if _, ok := peer.(*tg.InputPeerChannel); ok {
+		return fmt.Errorf("cannot clear the history of %s, only private chats are supported", target)
+	}
+	_, err = c.api.MessagesDeleteHistory(ctx, &tg.MessagesDeleteHistoryRequest{
+		Peer:      peer,
+		JustClear: true,
+	})
+	return errs.Classify(err)
+}
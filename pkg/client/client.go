@@ -0,0 +1,732 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/clock"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/telegram/auth/qrlogin"
+	"github.com/gotd/td/telegram/dcs"
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/history"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/sessions"
+)
+
+type Client struct {
+	tgClient       *telegram.Client
+	api            *tg.Client
+	appID          int
+	appHash        string
+	log            zerolog.Logger
+	httpClient     *http.Client
+	edits          *editWatcher  // Bot edits of messages tasks are waiting on
+	testDC         bool          // Connected to Telegram's test data centers
+	nonInteractive bool          // Fail instead of prompting when a login is needed
+	codePrompt     CodePrompt    // Source of login codes other than stdin, nil to read stdin
+	started        sync.Map      // Bots already started with a deep-link parameter, see ensureStarted
+	peers          sync.Map      // Targets resolved during the current Run, see resolvePeer
+	keepalive      time.Duration // Interval of keepalive requests during Run, 0 disables them
+}
+
+// httpTimeout bounds follow-up HTTP requests made on behalf of a task
+const httpTimeout = 30 * time.Second
+
+// DeviceOptions is the device reported to Telegram when the session connects, empty fields use gotd defaults
+type DeviceOptions struct {
+	Model          string
+	SystemVersion  string
+	AppVersion     string
+	LangCode       string
+	SystemLangCode string
+}
+
+// ConnectOptions configures how a client reaches Telegram
+type ConnectOptions struct {
+	ProxyAddr      string               // SOCKS5 proxy address, empty for a direct connection
+	ProxyFallbacks []string             // SOCKS5 proxies tried in order when ProxyAddr fails
+	FallbackDirect bool                 // Connect without a proxy when all proxies fail
+	Device         DeviceOptions        // Device reported when the session connects
+	TestDC         bool                 // Use Telegram's test data centers, sessions are stored apart from production ones
+	NonInteractive bool                 // Fail with errs.ErrAuthRequired instead of prompting for a login code or QR scan
+	Retry          RetryOptions         // Retries of requests failing with a flood wait or a transient error
+	LoginCode      LoginCodeOptions     // Source of the login code of a phone login, also used with NonInteractive
+	CodePrompt     CodePrompt           // Asks for the login code instead of LoginCode, e.g. through the control API
+	OnMigrate      MigrateHandler       // Called when Telegram moves the account to another data center, optional
+	ClockOffset    func() time.Duration // Added to the local time in MTProto messages, corrects a skewed clock; nil for none
+	Network        NetworkOptions       // Transport, keepalive and reconnection tuning
+	DebugMTProto   bool                 // Log gotd's MTProto internals (auth key, transport, RPC) to the client log
+}
+
+// CodePrompt returns the login code Telegram sent for phone
+type CodePrompt func(ctx context.Context, phone string) (string, error)
+
+// Test server application credentials published by Telegram, used with TestDC when no app is configured
+const (
+	TestAppID   = telegram.TestAppID
+	TestAppHash = telegram.TestAppHash
+)
+
+// testDCID is the test data center clients connect to, login codes there are the DC ID repeated five times
+const testDCID = 2
+
+func NewClient(appID int, appHash string, sessionFile string, conn ConnectOptions, log zerolog.Logger) (*Client, error) {
+	device := conn.Device
+	// Ensure session directory exists
+	sessionDir := sessions.Dir
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	// Adjust session file path to session directory
+	if sessionFile != "" && !strings.Contains(sessionFile, string(os.PathSeparator)) {
+		sessionFile = filepath.Join(sessionDir, sessionFile)
+	}
+	// Test DC authorizations are not valid in production and vice versa
+	if conn.TestDC {
+		ext := filepath.Ext(sessionFile)
+		sessionFile = strings.TrimSuffix(sessionFile, ext) + ".test" + ext
+	}
+
+	// telegram.FileSessionStorage supports specifying full path
+	// Session file will be saved to the specified path
+	// Message edits are the only updates handled, bots may edit a message instead of replying
+	edits := newEditWatcher()
+	dispatcher := tg.NewUpdateDispatcher()
+	edits.register(dispatcher)
+
+	clientLog := log.With().Int("app_id", appID).Logger()
+
+	opts := telegram.Options{
+		SessionStorage: &dcWatchStorage{
+			SessionStorage: &telegram.FileSessionStorage{Path: sessionFile},
+			log:            clientLog,
+			onMigrate:      conn.OnMigrate,
+		},
+		UpdateHandler:       dispatcher,
+		Middlewares:         []telegram.Middleware{retryMiddleware(clientLog, conn.Retry)},
+		ReconnectionBackoff: reconnectBackoff(time.Duration(conn.Network.ReconnectMaxSeconds) * time.Second),
+		DialTimeout:         time.Duration(conn.Network.DialTimeoutSeconds) * time.Second,
+		Device: telegram.DeviceConfig{
+			DeviceModel:    device.Model,
+			SystemVersion:  device.SystemVersion,
+			AppVersion:     device.AppVersion,
+			LangCode:       device.LangCode,
+			SystemLangCode: device.SystemLangCode,
+		},
+		OnDead: func() {
+			clientLog.Warn().Msg(i18n.T("connection_lost"))
+		},
+	}
+
+	// Output session file path (debug level)
+	absPath, _ := filepath.Abs(sessionFile)
+	clientLog.Debug().Str("session_file", sessionFile).Str("abs_path", absPath).Msg(i18n.T("session_file_path"))
+
+	// HTTP client for follow-up web requests (mini apps, redirect URLs), shares the proxy
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+
+	protocol, err := transportProtocol(conn.Network.Transport)
+	if err != nil {
+		return nil, err
+	}
+	resolver := dcs.PlainOptions{Protocol: protocol}
+	if conn.ProxyAddr != "" {
+		clientLog.Info().Str("proxy", conn.ProxyAddr).Strs("fallbacks", conn.ProxyFallbacks).Msg(i18n.T("using_proxy"))
+		pool, err := sharedProxyPool(append([]string{conn.ProxyAddr}, conn.ProxyFallbacks...), conn.FallbackDirect, log)
+		if err != nil {
+			return nil, err
+		}
+		resolver.Dial = pool.DialContext
+		httpTransport.Proxy = nil
+		httpTransport.DialContext = pool.DialContext
+	}
+	opts.Resolver = dcs.Plain(resolver)
+
+	if conn.DebugMTProto {
+		opts.Logger = newMTProtoLogger(clientLog)
+	}
+
+	if conn.ClockOffset != nil {
+		opts.Clock = offsetClock{Clock: clock.System, offset: conn.ClockOffset}
+	}
+
+	if conn.TestDC {
+		clientLog.Warn().Msg(i18n.T("test_dc"))
+		opts.DCList = dcs.Test()
+		opts.DC = testDCID
+	}
+
+	codePrompt := conn.CodePrompt
+	if codePrompt == nil {
+		var err error
+		if codePrompt, err = newCodePrompt(conn.LoginCode); err != nil {
+			return nil, err
+		}
+	}
+
+	client := telegram.NewClient(appID, appHash, opts)
+
+	return &Client{
+		tgClient:       client,
+		api:            tg.NewClient(client),
+		appID:          appID,
+		appHash:        appHash,
+		log:            clientLog,
+		httpClient:     &http.Client{Transport: httpTransport, Timeout: httpTimeout},
+		edits:          edits,
+		testDC:         conn.TestDC,
+		nonInteractive: conn.NonInteractive,
+		codePrompt:     codePrompt,
+		keepalive:      time.Duration(conn.Network.KeepaliveSeconds) * time.Second,
+	}, nil
+}
+
+// NewClientWithInvoker returns a client sending its requests to invoker instead of
+// connecting to Telegram, through the same retry middleware. Run calls fn directly and
+// the session is authorized if invoker answers the authorization check, it is meant for
+// tests against an in-memory server such as internal/fake.
+func NewClientWithInvoker(invoker tg.Invoker, retry RetryOptions, log zerolog.Logger) *Client {
+	return &Client{
+		api:        tg.NewClient(retryMiddleware(log, retry).Handle(invoker)),
+		log:        log,
+		httpClient: &http.Client{Timeout: httpTimeout},
+		edits:      newEditWatcher(),
+	}
+}
+
+func (c *Client) Auth(ctx context.Context, phone, password string) error {
+	return c.Run(ctx, func(ctx context.Context) error {
+		return c.AuthInRun(ctx, phone, password)
+	})
+}
+
+// Run connects to Telegram and calls fn, check-in methods must be called within fn.
+// Targets are resolved once per Run, tasks sharing a target reuse the peer.
+func (c *Client) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	c.peers.Clear()
+	if c.tgClient == nil {
+		return fn(ctx)
+	}
+	return c.tgClient.Run(ctx, func(ctx context.Context) error {
+		if c.keepalive > 0 {
+			kaCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go c.keepAlive(kaCtx, c.keepalive)
+		}
+		return fn(ctx)
+	})
+}
+
+// auth returns the authorization client of the session
+func (c *Client) auth() *auth.Client {
+	return auth.NewClient(c.api, rand.Reader, c.appID, c.appHash)
+}
+
+// AuthorizedInRun reports whether the session is logged in, without starting a login
+func (c *Client) AuthorizedInRun(ctx context.Context) (bool, error) {
+	status, err := c.auth().Status(ctx)
+	if err != nil {
+		return false, errs.Classify(err)
+	}
+	return status.Authorized, nil
+}
+
+func (c *Client) AuthInRun(ctx context.Context, phone, password string) error {
+	status, err := c.auth().Status(ctx)
+	if err != nil {
+		return errs.Classify(err)
+	}
+	if status.Authorized {
+		c.log.Debug().Msg(i18n.T("already_authorized"))
+		return nil
+	}
+
+	if c.testDC && phone == "" {
+		// Test DC: sign up a random test user (99966XYYYY) instead of QR login
+		c.log.Info().Msg(i18n.T("test_dc_login"))
+		flow := auth.NewFlow(auth.Test(rand.Reader, testDCID), auth.SendCodeOptions{})
+		return errs.Classify(c.auth().IfNecessary(ctx, flow))
+	}
+
+	// Without stdin the login would wait forever, fail so the problem is visible instead
+	if c.nonInteractive && (c.codePrompt == nil || phone == "") {
+		return fmt.Errorf("%w: session is not logged in and non_interactive is set, configure login_code for a phone login or log in once with the init or session import command", errs.ErrAuthRequired)
+	}
+
+	if phone != "" {
+		c.log.Info().Msg(i18n.T("phone_login"))
+		flow := auth.NewFlow(
+			auth.Constant(phone, password, auth.CodeAuthenticatorFunc(func(ctx context.Context, sentCode *tg.AuthSentCode) (string, error) {
+				if c.testDC {
+					return strings.Repeat(strconv.Itoa(testDCID), 5), nil
+				}
+				if c.codePrompt != nil {
+					return c.codePrompt(ctx, phone)
+				}
+				fmt.Print(i18n.T("enter_code", map[string]any{"Phone": phone}))
+				code, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				return strings.TrimSpace(code), nil
+			})),
+			auth.SendCodeOptions{},
+		)
+		return errs.Classify(c.auth().IfNecessary(ctx, flow))
+	}
+
+	// QR code login
+	c.log.Info().Msg(i18n.T("qrcode_login"))
+	qr := qrlogin.NewQR(c.api, c.appID, c.appHash, qrlogin.Options{})
+	token, err := qr.Export(ctx)
+	if err != nil {
+		return errs.Classify(err)
+	}
+
+	c.log.Info().Str("url", token.URL()).Msg(i18n.T("scan_qr"))
+
+	authorization, err := qr.Accept(ctx, token)
+	if err != nil {
+		return errs.Classify(err)
+	}
+
+	if authorization.PasswordPending {
+		return fmt.Errorf("%w: 2FA password is required but not supported via QR login in this tool yet, please use phone login", errs.ErrAuthRequired)
+	}
+
+	c.log.Info().Msg(i18n.T("login_success"))
+	return nil
+}
+
+// resolvePeer returns the peer of target, from the cache when it was resolved before in this Run
+func (c *Client) resolvePeer(ctx context.Context, target string) (tg.InputPeerClass, error) {
+	if peer, ok := c.peers.Load(target); ok {
+		return peer.(tg.InputPeerClass), nil
+	}
+	peer, err := c.lookupPeer(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	c.peers.Store(target, peer)
+	return peer, nil
+}
+
+func (c *Client) lookupPeer(ctx context.Context, target string) (tg.InputPeerClass, error) {
+	username, start := parseTarget(target)
+	peer, err := c.api.ContactsResolveUsername(ctx, &tg.ContactsResolveUsernameRequest{
+		Username: username,
+	})
+	if err != nil {
+		return nil, errs.Classify(err)
+	}
+
+	if len(peer.Users) > 0 {
+		user := peer.Users[0].(*tg.User)
+		if start != "" && user.Bot {
+			if err := c.ensureStarted(ctx, user, start); err != nil {
+				return nil, err
+			}
+		}
+		return &tg.InputPeerUser{
+			UserID:     user.ID,
+			AccessHash: user.AccessHash,
+		}, nil
+	}
+
+	if len(peer.Chats) > 0 {
+		chat := peer.Chats[0].(*tg.Channel)
+		return &tg.InputPeerChannel{
+			ChannelID:  chat.ID,
+			AccessHash: chat.AccessHash,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: could not resolve %s", errs.ErrPeerNotFound, target)
+}
+
+func randInt64() int64 {
+	var b [8]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		panic(err)
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+// CheckInMessage sends a text message for check-in and waits for the bot reply, within Run.
+// Progress is logged to taskLogger and the client log.
+func (c *Client) CheckInMessage(ctx context.Context, target string, message string, opts MessageOptions, taskLogger zerolog.Logger) (Result, error) {
+	var res Result
+	start := time.Now()
+	err := c.sendMessage(ctx, target, message, opts, taskLogger, &res)
+	res.finish(start, err)
+	return res, err
+}
+
+// CheckInButton clicks the button of the latest message for check-in, within Run.
+// Progress is logged to taskLogger and the client log.
+func (c *Client) CheckInButton(ctx context.Context, target string, buttonText string, opts ButtonOptions, taskLogger zerolog.Logger) (Result, error) {
+	var res Result
+	start := time.Now()
+	err := c.pressButton(ctx, target, buttonText, opts, taskLogger, &res)
+	res.finish(start, err)
+	return res, err
+}
+
+func (c *Client) sendMessage(ctx context.Context, target string, message string, opts MessageOptions, taskLogger zerolog.Logger, res *Result) error {
+	taskLog := taskLogger.With().Str("target", target).Str("payload", message).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Str("payload", message).Logger()
+
+	taskLog.Info().Msg(i18n.T("sending_message"))
+	mainLog.Info().Msg(i18n.T("sending_message"))
+	text, entities, err := formatMessage(message, opts.Format)
+	if err != nil {
+		return err
+	}
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	req := &tg.MessagesSendMessageRequest{
+		Peer:     peer,
+		Message:  text,
+		RandomID: randInt64(),
+		Silent:   opts.Silent,
+		Entities: entities,
+	}
+	if opts.ReplyTo != "" {
+		replyMsg, err := c.findMessage(ctx, peer, opts.ReplyTo)
+		if err != nil {
+			return fmt.Errorf("failed to find message to reply to: %w", err)
+		}
+		req.ReplyTo = &tg.InputReplyToMessage{ReplyToMsgID: replyMsg.ID}
+		taskLog.Debug().Int("reply_to_message_id", replyMsg.ID).Msg(i18n.T("replying_to_message"))
+	}
+
+	if opts.Typing {
+		if err := c.simulateTyping(ctx, peer, text); err != nil {
+			return err
+		}
+	}
+
+	updates, err := c.sendWithHandshake(ctx, peer, target, func() (tg.UpdatesClass, error) {
+		req.RandomID = randInt64()
+		return c.api.MessagesSendMessage(ctx, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	responseType, messageID := parseSendMessageResult(updates)
+
+	// Find the message ID we sent
+	var sentMsgID int
+	switch u := updates.(type) {
+	case *tg.Updates:
+		if len(u.Updates) > 0 {
+			for _, upd := range u.Updates {
+				if msgUpdate, ok := upd.(*tg.UpdateMessageID); ok {
+					sentMsgID = msgUpdate.ID
+					break
+				}
+				if newMsg, ok := upd.(*tg.UpdateNewMessage); ok {
+					if m, ok := newMsg.Message.(*tg.Message); ok && m.Out {
+						sentMsgID = m.ID
+						break
+					}
+				}
+				if newMsg, ok := upd.(*tg.UpdateNewChannelMessage); ok {
+					if m, ok := newMsg.Message.(*tg.Message); ok && m.Out {
+						sentMsgID = m.ID
+						break
+					}
+				}
+			}
+		}
+	case *tg.UpdateShortSentMessage:
+		sentMsgID = u.ID
+	}
+	res.SentMessageID = sentMsgID
+	sentAt := sentDate(updates)
+	ReportFrom(ctx).addSent(sentMsgID)
+	ReportFrom(ctx).addTranscript(history.TranscriptEntry{Time: time.Now(), Kind: history.TranscriptSent, MessageID: sentMsgID, Text: text})
+
+	// Wait for bot reply
+	wait := opts.Reply.wait()
+	taskLog.Info().Int("wait_seconds", int(wait.Seconds())).Msg(i18n.T("waiting_for_reply"))
+	if err := waitReply(ctx, wait); err != nil {
+		return err
+	}
+	hist, err := c.api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+		Peer:  peer,
+		Limit: opts.Reply.limit(),
+	})
+	if err != nil {
+		// A shutdown must not pass for a sent message without reply
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		taskLog.Warn().Err(err).Msg(i18n.T("get_history_failed"))
+		return nil // Don't block main flow
+	}
+
+	var msgs []tg.MessageClass
+	switch h := hist.(type) {
+	case *tg.MessagesMessages:
+		msgs = h.Messages
+	case *tg.MessagesMessagesSlice:
+		msgs = h.Messages
+	case *tg.MessagesChannelMessages:
+		msgs = h.Messages
+	}
+	var users []tg.UserClass
+	if modified, ok := hist.AsModified(); ok {
+		users = modified.GetUsers()
+	}
+	isReply := func(msg *tg.Message) bool {
+		return !msg.Out && (sentMsgID == 0 || msg.ID > sentMsgID) && opts.Reply.inWindow(msg, sentAt) && opts.Reply.fromSender(msg, users)
+	}
+
+	// Extract bot's reply (find latest message not sent by us)
+	var (
+		botReply string
+		replyMsg *tg.Message
+	)
+	for _, m := range msgs {
+		if msg, ok := m.(*tg.Message); ok {
+			if isReply(msg) {
+				botReply = msg.Message
+				replyMsg = msg
+				break
+			}
+		}
+	}
+	report := ReportFrom(ctx)
+	// History is newest first, the transcript gets every reply in the order it arrived
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg, ok := msgs[i].(*tg.Message)
+		if !ok || !isReply(msg) || (sentMsgID == 0 && msg != replyMsg) {
+			continue
+		}
+		report.addTranscript(history.TranscriptEntry{Time: time.Unix(int64(msg.Date), 0), Kind: history.TranscriptReceived, MessageID: msg.ID, Text: msg.Message})
+	}
+
+	if replyMsg != nil && replyMsg.ReplyMarkup == nil && opts.KeyboardWaitSeconds > 0 {
+		for _, lg := range []zerolog.Logger{taskLog, mainLog} {
+			lg.Info().Str("reply", botReply).Int("wait_seconds", opts.KeyboardWaitSeconds).Msg(i18n.T("waiting_for_buttons"))
+		}
+		edited := c.waitKeyboard(ctx, peer, replyMsg, time.Duration(opts.KeyboardWaitSeconds)*time.Second)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if edited != nil {
+			report.addTranscript(history.TranscriptEntry{Time: time.Now(), Kind: history.TranscriptEdited, MessageID: edited.ID, Text: edited.Message})
+			botReply, replyMsg = edited.Message, edited
+		} else {
+			taskLog.Warn().Msg(i18n.T("buttons_timeout"))
+		}
+	}
+	if replyMsg != nil {
+		res.Reply = botReply
+		res.ReplyMessageID = replyMsg.ID
+	}
+	report.setReply(botReply)
+
+	if replyMsg != nil && opts.MediaDir != "" {
+		path, err := c.saveReplyMedia(ctx, replyMsg, opts.MediaDir)
+		if err != nil {
+			taskLog.Warn().Err(err).Msg(i18n.T("reply_media_save_failed"))
+		} else if path != "" {
+			report.addArtifact(path)
+			taskLog.Info().Str("path", path).Msg(i18n.T("reply_media_saved"))
+		}
+	}
+
+	if botReply != "" {
+		combined := []zerolog.Logger{
+			taskLog.With().Str("response_type", responseType).Int("message_id", messageID).Logger(),
+			mainLog.With().Str("response_type", responseType).Int("message_id", messageID).Logger(),
+		}
+		for _, lg := range combined {
+			lg.Info().Str("reply", botReply).Msg(i18n.T("message_completed"))
+		}
+	} else {
+		combined := []zerolog.Logger{
+			taskLog.With().Str("response_type", responseType).Int("message_id", messageID).Logger(),
+			mainLog.With().Str("response_type", responseType).Int("message_id", messageID).Logger(),
+		}
+		for _, lg := range combined {
+			lg.Info().Msg(i18n.T("message_completed_no_reply"))
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) pressButton(ctx context.Context, target string, buttonText string, opts ButtonOptions, taskLogger zerolog.Logger, res *Result) error {
+	taskLog := taskLogger.With().Str("target", target).Str("button_text", buttonText).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Str("button_text", buttonText).Logger()
+
+	taskLog.Info().Msg(i18n.T("clicking_button"))
+	mainLog.Info().Msg(i18n.T("clicking_button"))
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	msg, btn, hist, err := c.latestButton(ctx, peer, buttonText)
+	if errors.Is(err, errs.ErrButtonNotFound) && opts.WaitSeconds > 0 {
+		for _, lg := range []zerolog.Logger{taskLog, mainLog} {
+			lg.Info().Int("wait_seconds", opts.WaitSeconds).Msg(i18n.T("waiting_for_button"))
+		}
+		msg, btn, hist, err = c.waitButton(ctx, peer, buttonText, time.Duration(opts.WaitSeconds)*time.Second)
+	}
+	if err != nil {
+		return err
+	}
+
+	combined := []zerolog.Logger{
+		taskLog.With().Int("message_id", msg.ID).Logger(),
+		mainLog.With().Int("message_id", msg.ID).Logger(),
+	}
+
+	switch b := btn.(type) {
+	case *tg.KeyboardButtonCallback:
+		var edits <-chan *tg.Message
+		if opts.WaitEdit {
+			ch, stop := c.edits.watch(peer, msg.ID)
+			defer stop()
+			edits = ch
+		}
+
+		ReportFrom(ctx).addTranscript(history.TranscriptEntry{Time: time.Now(), Kind: history.TranscriptButton, MessageID: msg.ID, Text: buttonText})
+		answer, err := c.api.MessagesGetBotCallbackAnswer(ctx, &tg.MessagesGetBotCallbackAnswerRequest{
+			Peer:  peer,
+			MsgID: msg.ID,
+			Data:  b.Data,
+			Game:  false,
+		})
+		if err != nil {
+			return errs.Classify(err)
+		}
+
+		replyText, url := parseCallbackAnswer(answer)
+		reply := ""
+		if answer != nil {
+			reply = answer.Message
+		}
+		res.CallbackAnswer, res.CallbackURL = reply, url
+		if answer != nil && (answer.Message != "" || url != "") {
+			text := answer.Message
+			if text == "" {
+				text = url
+			}
+			ReportFrom(ctx).addTranscript(history.TranscriptEntry{Time: time.Now(), Kind: history.TranscriptCallback, MessageID: msg.ID, Text: text})
+		}
+		if opts.WaitEdit {
+			if edited := c.waitEdit(ctx, peer, msg, edits, opts.Reply.wait()); edited != nil {
+				ReportFrom(ctx).addTranscript(history.TranscriptEntry{Time: time.Now(), Kind: history.TranscriptEdited, MessageID: edited.ID, Text: edited.Message})
+				res.ReplyMessageID = edited.ID
+				reply, replyText = edited.Message, edited.Message
+				for _, lg := range combined {
+					lg.Info().Str("edited_text", edited.Message).Msg(i18n.T("bot_edited_message"))
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		res.Reply = reply
+		ReportFrom(ctx).setReply(reply)
+		for _, lg := range combined {
+			lg.Info().
+				Str("reply", replyText).
+				Str("url", url).
+				Msg(i18n.T("button_click_completed"))
+		}
+		if url == "" || !opts.CallbackURL.Open {
+			return nil
+		}
+		status, finalURL, err := c.openCallbackURL(ctx, url, opts.CallbackURL)
+		if err != nil {
+			return err
+		}
+		for _, lg := range combined {
+			lg.Info().Str("final_url", finalURL).Int("status", status).Msg(i18n.T("callback_url_opened"))
+		}
+		if status >= 400 {
+			return fmt.Errorf("callback URL returned status %d", status)
+		}
+		return nil
+	case *tg.KeyboardButtonWebView, *tg.KeyboardButtonSimpleWebView:
+		bot, err := botInputUser(peer, msg, hist)
+		if err != nil {
+			return err
+		}
+		webviewURL, err := c.requestWebView(ctx, peer, bot, b)
+		if err != nil {
+			return err
+		}
+		for _, lg := range combined {
+			lg.Info().Str("webview_url", webviewURL).Msg(i18n.T("web_app_opened"))
+		}
+		if !opts.WebApp.Request {
+			return nil
+		}
+		status, body, err := c.callWebApp(ctx, webviewURL, opts.WebApp)
+		if err != nil {
+			return err
+		}
+		for _, lg := range combined {
+			lg.Info().Int("status", status).Str("reply", body).Msg(i18n.T("web_app_completed"))
+		}
+		if status >= 400 {
+			return fmt.Errorf("web app request failed with status %d", status)
+		}
+		return nil
+	default:
+		return c.handleOtherButton(ctx, peer, btn, opts.OtherButton, combined)
+	}
+}
+
+func parseSendMessageResult(updates tg.UpdatesClass) (responseType string, messageID int) {
+	switch u := updates.(type) {
+	case *tg.UpdateShortSentMessage:
+		return "updateShortSentMessage", u.ID
+	case *tg.Updates:
+		return "updates", 0
+	case *tg.UpdatesCombined:
+		return "updatesCombined", 0
+	default:
+		return fmt.Sprintf("%T", updates), 0
+	}
+}
+
+func parseCallbackAnswer(answer *tg.MessagesBotCallbackAnswer) (replyText string, url string) {
+	if answer == nil {
+		return "Button clicked (no reply)", ""
+	}
+
+	if answer.Message != "" {
+		return answer.Message, ""
+	}
+	if answer.URL != "" {
+		return "", answer.URL
+	}
+	return "Button clicked", ""
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"time"
+
+	"github.com/gotd/td/clock"
+)
+
+// offsetClock is the system clock shifted by an offset, used as MTProto time on hosts whose
+// clock is off. The offset is read on every use, so a skew measured after the client was
+// created still applies.
+type offsetClock struct {
+	clock.Clock
+	offset func() time.Duration
+}
+
+func (c offsetClock) Now() time.Time {
+	return c.Clock.Now().Add(c.offset())
+}
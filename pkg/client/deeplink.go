@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gotd/td/tg"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// parseTarget splits a task target into the username to resolve and the deep-link start parameter.
+// Accepted forms: @bot, bot, t.me/bot, https://t.me/bot?start=ref123 and @bot?start=ref123.
+func parseTarget(target string) (username string, start string) {
+	target = strings.TrimSpace(target)
+	for _, prefix := range []string{"https://", "http://"} {
+		target = strings.TrimPrefix(target, prefix)
+	}
+	for _, host := range []string{"t.me/", "telegram.me/"} {
+		target = strings.TrimPrefix(target, host)
+	}
+
+	username, query, _ := strings.Cut(target, "?")
+	if query != "" {
+		if values, err := url.ParseQuery(query); err == nil {
+			start = values.Get("start")
+		}
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(username, "/"), "@"), start
+}
+
+// ensureStarted sends /start with the deep-link parameter when the chat with the bot is still empty,
+// so bots that bind accounts through a referral payload see it as the first interaction
+func (c *Client) ensureStarted(ctx context.Context, user *tg.User, start string) error {
+	key := strconv.FormatInt(user.ID, 10) + "?start=" + start
+	if _, done := c.started.Load(key); done {
+		return nil
+	}
+
+	peer := &tg.InputPeerUser{UserID: user.ID, AccessHash: user.AccessHash}
+	history, err := c.api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+		Peer:  peer,
+		Limit: 1,
+	})
+	if err != nil {
+		return errs.Classify(err)
+	}
+	msgs, err := extractMessages(history)
+	if err != nil {
+		return err
+	}
+
+	if len(msgs) == 0 {
+		if _, err := c.api.MessagesStartBot(ctx, &tg.MessagesStartBotRequest{
+			Bot:        &tg.InputUser{UserID: user.ID, AccessHash: user.AccessHash},
+			Peer:       peer,
+			RandomID:   randInt64(),
+			StartParam: start,
+		}); err != nil {
+			return errs.Classify(err)
+		}
+		runLog(ctx, c.log).Info().Str("bot", user.Username).Str("start", start).Msg(i18n.T("deeplink_started"))
+	}
+	c.started.Store(key, struct{}{})
+	return nil
+}
+
+// sendWithHandshake calls send, and when Telegram refuses it because the account never
+// contacted the target bot (USER_PRIVACY_RESTRICTED, USER_IS_BLOCKED), starts the bot the way
+// the Telegram apps do and calls send once more. The handshake is attempted once per bot.
+func (c *Client) sendWithHandshake(ctx context.Context, peer tg.InputPeerClass, target string, send func() (tg.UpdatesClass, error)) (tg.UpdatesClass, error) {
+	updates, err := send()
+	err = errs.Classify(err)
+	user, ok := peer.(*tg.InputPeerUser)
+	if !ok || !errors.Is(err, errs.ErrPeerRestricted) {
+		return updates, err
+	}
+	key := strconv.FormatInt(user.UserID, 10) + "#handshake"
+	if _, done := c.started.LoadOrStore(key, struct{}{}); done {
+		return updates, err
+	}
+
+	log := runLog(ctx, c.log).With().Str("target", target).Logger()
+	log.Warn().Err(err).Msg(i18n.T("message_refused_starting_bot"))
+	_, start := parseTarget(target)
+	if start != "" {
+		_, err = c.api.MessagesStartBot(ctx, &tg.MessagesStartBotRequest{
+			Bot:        &tg.InputUser{UserID: user.UserID, AccessHash: user.AccessHash},
+			Peer:       peer,
+			RandomID:   randInt64(),
+			StartParam: start,
+		})
+	} else {
+		_, err = c.api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
+			Peer:     peer,
+			Message:  "/start",
+			RandomID: randInt64(),
+			Entities: botCommandEntities("/start"),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the bot after the message was refused: %w", errs.Classify(err))
+	}
+	log.Info().Msg(i18n.T("bot_started_retrying"))
+	updates, err = send()
+	return updates, errs.Classify(err)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// defaultDice is sent when the task payload is empty
+const defaultDice = "🎲"
+
+// SendDiceInRunWithLogger sends an animated dice/emoji game to the target (with task logger)
+func (c *Client) SendDiceInRunWithLogger(ctx context.Context, target string, emoji string, taskLogger zerolog.Logger) error {
+	if emoji == "" {
+		emoji = defaultDice
+	}
+	taskLog := taskLogger.With().Str("target", target).Str("dice", emoji).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Str("dice", emoji).Logger()
+
+	taskLog.Info().Msg(i18n.T("sending_dice"))
+	mainLog.Info().Msg(i18n.T("sending_dice"))
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	req := &tg.MessagesSendMediaRequest{
+		Peer:  peer,
+		Media: &tg.InputMediaDice{Emoticon: emoji},
+	}
+	updates, err := c.sendWithHandshake(ctx, peer, target, func() (tg.UpdatesClass, error) {
+		req.RandomID = randInt64()
+		return c.api.MessagesSendMedia(ctx, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The rolled value is decided by Telegram and returned with the sent message
+	value := 0
+	messageID := 0
+	if msg := findSentMessage(updates); msg != nil {
+		messageID = msg.ID
+		ReportFrom(ctx).addSent(msg.ID)
+		if dice, ok := msg.Media.(*tg.MessageMediaDice); ok {
+			value = dice.Value
+		}
+	}
+	for _, lg := range []zerolog.Logger{taskLog, mainLog} {
+		lg.Info().Int("message_id", messageID).Int("value", value).Msg(i18n.T("dice_completed"))
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tg"
+)
+
+// editKey identifies a message across chats, message IDs are only unique per channel
+type editKey struct {
+	peerID int64
+	msgID  int
+}
+
+// editWatcher delivers message edits received through updates to waiting tasks
+type editWatcher struct {
+	mu      sync.Mutex
+	waiters map[editKey]chan *tg.Message
+}
+
+func newEditWatcher() *editWatcher {
+	return &editWatcher{waiters: make(map[editKey]chan *tg.Message)}
+}
+
+// register subscribes the watcher to edit updates of the dispatcher
+func (w *editWatcher) register(d tg.UpdateDispatcher) {
+	d.OnEditMessage(func(ctx context.Context, e tg.Entities, u *tg.UpdateEditMessage) error {
+		w.handle(u.Message)
+		return nil
+	})
+	d.OnEditChannelMessage(func(ctx context.Context, e tg.Entities, u *tg.UpdateEditChannelMessage) error {
+		w.handle(u.Message)
+		return nil
+	})
+}
+
+// watch starts collecting edits of a message, the returned function stops watching
+func (w *editWatcher) watch(peer tg.InputPeerClass, msgID int) (<-chan *tg.Message, func()) {
+	key := editKey{peerID: inputPeerID(peer), msgID: msgID}
+	ch := make(chan *tg.Message, 1)
+
+	w.mu.Lock()
+	w.waiters[key] = ch
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		if w.waiters[key] == ch {
+			delete(w.waiters, key)
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *editWatcher) handle(m tg.MessageClass) {
+	msg, ok := m.(*tg.Message)
+	if !ok || msg.Out {
+		return
+	}
+	key := editKey{peerID: peerID(msg.PeerID), msgID: msg.ID}
+
+	w.mu.Lock()
+	ch, ok := w.waiters[key]
+	w.mu.Unlock()
+	if !ok {
+		return
+	}
+	// Keep only the latest edit
+	select {
+	case <-ch:
+	default:
+	}
+	select {
+	case ch <- msg:
+	default:
+	}
+}
+
+// waitEdit waits up to wait for the bot to edit the original message.
+// Edit updates may be missed, so the message is fetched again if none arrived.
+func (c *Client) waitEdit(ctx context.Context, peer tg.InputPeerClass, original *tg.Message, edits <-chan *tg.Message, wait time.Duration) *tg.Message {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case msg := <-edits:
+		return msg
+	case <-ctx.Done():
+		return nil
+	case <-timer.C:
+	}
+
+	current, err := c.findMessage(ctx, peer, strconv.Itoa(original.ID))
+	if err != nil {
+		return nil
+	}
+	before, _ := original.GetEditDate()
+	if after, ok := current.GetEditDate(); ok && after > before {
+		return current
+	}
+	return nil
+}
+
+// waitKeyboard waits up to timeout for the bot to edit buttons into msg and returns the edited
+// message, nil when none arrived. Edits without buttons are skipped, and the message is also
+// fetched again every buttonPollInterval since the edit may predate the watch or its update
+// may be missed.
+func (c *Client) waitKeyboard(ctx context.Context, peer tg.InputPeerClass, msg *tg.Message, timeout time.Duration) *tg.Message {
+	edits, stop := c.edits.watch(peer, msg.ID)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(buttonPollInterval)
+	defer ticker.Stop()
+	for {
+		if current, err := c.findMessage(ctx, peer, strconv.Itoa(msg.ID)); err == nil && current.ReplyMarkup != nil {
+			return current
+		}
+		select {
+		case edited := <-edits:
+			if edited.ReplyMarkup != nil {
+				return edited
+			}
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func inputPeerID(peer tg.InputPeerClass) int64 {
+	switch p := peer.(type) {
+	case *tg.InputPeerUser:
+		return p.UserID
+	case *tg.InputPeerChat:
+		return p.ChatID
+	case *tg.InputPeerChannel:
+		return p.ChannelID
+	default:
+		return 0
+	}
+}
+
+func peerID(peer tg.PeerClass) int64 {
+	switch p := peer.(type) {
+	case *tg.PeerUser:
+		return p.UserID
+	case *tg.PeerChat:
+		return p.ChatID
+	case *tg.PeerChannel:
+		return p.ChannelID
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gotd/td/telegram/message/entity"
+	tghtml "github.com/gotd/td/telegram/message/html"
+	"github.com/gotd/td/tg"
+)
+
+// Payload formats of the message method
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// formatMessage parses message in the given format into the text and entities sent to
+// Telegram. Plain messages are sent as they are, with a leading /command marked as such.
+func formatMessage(message, format string) (string, []tg.MessageEntityClass, error) {
+	var source string
+	switch format {
+	case "", FormatPlain:
+		return message, botCommandEntities(message), nil
+	case FormatHTML:
+		source = message
+	case FormatMarkdown:
+		source = markdownToHTML(message)
+	default:
+		return "", nil, fmt.Errorf("unknown payload format %q, expected plain, markdown or html", format)
+	}
+
+	var b entity.Builder
+	if err := tghtml.HTML(strings.NewReader(source), &b, tghtml.Options{}); err != nil {
+		return "", nil, fmt.Errorf("invalid %s payload: %w", format, err)
+	}
+	text, entities := b.Complete()
+	// Entities are sorted by offset, a leading command goes first
+	return text, append(botCommandEntities(text), entities...), nil
+}
+
+// markdownMarkers are the inline markers of markdown payloads and the HTML tags they become,
+// longer markers first so ** is not read as two *
+var markdownMarkers = []markdownMarker{
+	{"**", "b"},
+	{"__", "u"},
+	{"~~", "s"},
+	{"||", "tg-spoiler"},
+	{"*", "i"},
+	{"_", "i"},
+}
+
+// markdownToHTML converts the markdown subset of payloads to the HTML understood by Telegram:
+// **bold**, *italic* or _italic_, __underline__, ~~strike~~, ||spoiler||, `code`,
+// ```pre``` with an optional language on the first line and [text](url) links. A backslash
+// escapes the next character, markers without a closing counterpart are kept as text so
+// names like some_bot stay intact.
+func markdownToHTML(s string) string {
+	var out strings.Builder
+	open := make(map[string]bool)
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1:
+			_, size := utf8.DecodeRuneInString(rest[1:])
+			out.WriteString(html.EscapeString(rest[1 : 1+size]))
+			i += 1 + size
+			continue
+		case strings.HasPrefix(rest, "```"):
+			if end := strings.Index(rest[3:], "```"); end >= 0 {
+				code := rest[3 : 3+end]
+				lang := ""
+				if nl := strings.IndexByte(code, '\n'); nl >= 0 && !strings.ContainsAny(code[:nl], " \t") {
+					lang, code = code[:nl], code[nl+1:]
+				}
+				if lang != "" {
+					fmt.Fprintf(&out, `<pre><code class="language-%s">%s</code></pre>`, html.EscapeString(lang), html.EscapeString(code))
+				} else {
+					fmt.Fprintf(&out, "<pre>%s</pre>", html.EscapeString(code))
+				}
+				i += 3 + end + 3
+				continue
+			}
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				fmt.Fprintf(&out, "<code>%s</code>", html.EscapeString(rest[1:1+end]))
+				i += 1 + end + 1
+				continue
+			}
+		case rest[0] == '[':
+			if text, url, n, ok := markdownLink(rest); ok {
+				fmt.Fprintf(&out, `<a href="%s">%s</a>`, html.EscapeString(url), markdownToHTML(text))
+				i += n
+				continue
+			}
+		}
+
+		if m, ok := findMarker(rest); ok {
+			switch {
+			case open[m.marker]:
+				fmt.Fprintf(&out, "</%s>", m.tag)
+				open[m.marker] = false
+				i += len(m.marker)
+				continue
+			case strings.Contains(rest[len(m.marker):], m.marker):
+				fmt.Fprintf(&out, "<%s>", m.tag)
+				open[m.marker] = true
+				i += len(m.marker)
+				continue
+			}
+		}
+
+		// Copy up to the next character that could start markup
+		n := 1
+		if j := strings.IndexAny(rest[1:], "\\`[*_~|"); j >= 0 {
+			n += j
+		} else {
+			n = len(rest)
+		}
+		out.WriteString(html.EscapeString(rest[:n]))
+		i += n
+	}
+	return out.String()
+}
+
+type markdownMarker struct {
+	marker string
+	tag    string
+}
+
+// findMarker returns the inline marker s starts with
+func findMarker(s string) (markdownMarker, bool) {
+	for _, m := range markdownMarkers {
+		if strings.HasPrefix(s, m.marker) {
+			return m, true
+		}
+	}
+	return markdownMarker{}, false
+}
+
+// markdownLink parses a [text](url) link at the start of s and returns its length
+func markdownLink(s string) (text, url string, n int, ok bool) {
+	closeText := strings.Index(s, "](")
+	if closeText < 0 || strings.ContainsAny(s[1:closeText], "\n") {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(s[closeText+2:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	url = s[closeText+2 : closeText+2+closeURL]
+	if url == "" || strings.ContainsAny(url, " \n") {
+		return "", "", 0, false
+	}
+	return s[1:closeText], url, closeText + 2 + closeURL + 1, true
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// ForwardInRunWithLogger forwards the latest, pinned or given message of the from chat to the
+// target, from Saved Messages when from is empty or "me" (with task logger)
+func (c *Client) ForwardInRunWithLogger(ctx context.Context, target string, from string, selector string, taskLogger zerolog.Logger) error {
+	taskLog := taskLogger.With().Str("target", target).Str("forward_from", from).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Str("forward_from", from).Logger()
+
+	taskLog.Info().Msg(i18n.T("forwarding_message"))
+	mainLog.Info().Msg(i18n.T("forwarding_message"))
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	var source tg.InputPeerClass = &tg.InputPeerSelf{}
+	if from = strings.TrimSpace(from); from != "" && !strings.EqualFold(from, "me") {
+		if source, err = c.resolvePeer(ctx, from); err != nil {
+			return err
+		}
+	}
+
+	msg, err := c.findMessage(ctx, source, selector)
+	if err != nil {
+		return err
+	}
+
+	updates, err := c.api.MessagesForwardMessages(ctx, &tg.MessagesForwardMessagesRequest{
+		FromPeer: source,
+		ID:       []int{msg.ID},
+		RandomID: []int64{randInt64()},
+		ToPeer:   peer,
+	})
+	if err != nil {
+		return errs.Classify(err)
+	}
+
+	forwardedID := 0
+	if sent := findSentMessage(updates); sent != nil {
+		forwardedID = sent.ID
+		ReportFrom(ctx).addSent(sent.ID)
+	}
+	for _, lg := range []zerolog.Logger{taskLog, mainLog} {
+		lg.Info().Int("message_id", msg.ID).Int("forwarded_id", forwardedID).Msg(i18n.T("forward_completed"))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// metersPerDegree is the length of a degree of latitude, and of longitude at the equator
+const metersPerDegree = 111320
+
+// LocationOptions are the geo point sent by the location method
+type LocationOptions struct {
+	Latitude       float64 // Degrees, -90 to 90
+	Longitude      float64 // Degrees, -180 to 180
+	JitterMeters   float64 // Move the point randomly by up to this distance on every run, 0 to send it as is
+	AccuracyMeters int     // Accuracy radius shown with the point, 0 to leave it out
+}
+
+// point returns the coordinates to send, moved in a random direction by up to JitterMeters
+func (l LocationOptions) point() (lat, long float64) {
+	if l.JitterMeters <= 0 {
+		return l.Latitude, l.Longitude
+	}
+	// The square root spreads the points evenly over the circle instead of around its center
+	distance := l.JitterMeters * math.Sqrt(rand.Float64())
+	angle := 2 * math.Pi * rand.Float64()
+	lat = l.Latitude + distance*math.Cos(angle)/metersPerDegree
+	// Degrees of longitude shrink towards the poles, capped so the poles don't divide by zero
+	scale := math.Max(math.Cos(l.Latitude*math.Pi/180), 0.01)
+	long = l.Longitude + distance*math.Sin(angle)/(metersPerDegree*scale)
+	return math.Max(-90, math.Min(90, lat)), math.Remainder(long, 360)
+}
+
+// SendLocationInRunWithLogger shares a geo point with the target, for bots taking attendance by
+// location (with task logger)
+func (c *Client) SendLocationInRunWithLogger(ctx context.Context, target string, location LocationOptions, taskLogger zerolog.Logger) error {
+	lat, long := location.point()
+	taskLog := taskLogger.With().Str("target", target).Float64("latitude", lat).Float64("longitude", long).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Logger()
+
+	taskLog.Info().Msg(i18n.T("sending_location"))
+	mainLog.Info().Msg(i18n.T("sending_location"))
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	point := &tg.InputGeoPoint{Lat: lat, Long: long}
+	if location.AccuracyMeters > 0 {
+		point.SetAccuracyRadius(location.AccuracyMeters)
+	}
+	req := &tg.MessagesSendMediaRequest{
+		Peer:  peer,
+		Media: &tg.InputMediaGeoPoint{GeoPoint: point},
+	}
+	updates, err := c.sendWithHandshake(ctx, peer, target, func() (tg.UpdatesClass, error) {
+		req.RandomID = randInt64()
+		return c.api.MessagesSendMedia(ctx, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	messageID := 0
+	if msg := findSentMessage(updates); msg != nil {
+		messageID = msg.ID
+		ReportFrom(ctx).addSent(msg.ID)
+	}
+	for _, lg := range []zerolog.Logger{taskLog, mainLog} {
+		lg.Info().Int("message_id", messageID).Msg(i18n.T("location_sent"))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Login code sources
+const (
+	CodeSourceStdin   = "stdin"   // Prompt on the terminal (default)
+	CodeSourceFile    = "file"    // Read a file written after the code was requested, or a named pipe
+	CodeSourceEnv     = "env"     // Read an environment variable
+	CodeSourceCommand = "command" // Run a command and use its output
+)
+
+// defaultCodeTimeout bounds the wait for a code from a file or command
+const defaultCodeTimeout = 5 * time.Minute
+
+// codePollInterval is how often a code file is checked
+const codePollInterval = time.Second
+
+// LoginCodeOptions configure where the login code of a phone login comes from
+type LoginCodeOptions struct {
+	Source  string   // stdin (default) | file | env | command
+	Path    string   // file: file or named pipe holding the code
+	Env     string   // env: variable holding the code, default: TG_LOGIN_CODE
+	Command string   // command: executable printing the code on stdout
+	Args    []string // command: arguments
+	Timeout int      // Seconds to wait for the code, default: 300
+}
+
+// newCodePrompt returns the prompt reading login codes from the configured source,
+// nil for stdin so the client prompts on the terminal
+func newCodePrompt(opts LoginCodeOptions) (CodePrompt, error) {
+	timeout := defaultCodeTimeout
+	if opts.Timeout > 0 {
+		timeout = time.Duration(opts.Timeout) * time.Second
+	}
+
+	switch opts.Source {
+	case "", CodeSourceStdin:
+		return nil, nil
+	case CodeSourceFile:
+		if opts.Path == "" {
+			return nil, fmt.Errorf("login_code source file requires path")
+		}
+		return func(ctx context.Context, phone string) (string, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return readCodeFile(ctx, opts.Path)
+		}, nil
+	case CodeSourceEnv:
+		name := opts.Env
+		if name == "" {
+			name = "TG_LOGIN_CODE"
+		}
+		return func(ctx context.Context, phone string) (string, error) {
+			code := strings.TrimSpace(os.Getenv(name))
+			if code == "" {
+				return "", fmt.Errorf("login code variable %s is not set", name)
+			}
+			return code, nil
+		}, nil
+	case CodeSourceCommand:
+		if opts.Command == "" {
+			return nil, fmt.Errorf("login_code source command requires command")
+		}
+		return func(ctx context.Context, phone string) (string, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			cmd := exec.CommandContext(ctx, opts.Command, opts.Args...)
+			cmd.Env = append(os.Environ(), "CHECKIN_PHONE="+phone)
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			out, err := cmd.Output()
+			if err != nil {
+				return "", fmt.Errorf("login code command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+			}
+			code := strings.TrimSpace(string(out))
+			if code == "" {
+				return "", fmt.Errorf("login code command printed nothing")
+			}
+			return code, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown login_code source %q", opts.Source)
+	}
+}
+
+// readCodeFile waits for the code in path. A named pipe is read once a writer opens it, a regular
+// file is polled until it was written after the code was requested, so a stale code is not reused.
+func readCodeFile(ctx context.Context, path string) (string, error) {
+	requested := time.Now()
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return readCodePipe(ctx, path)
+	}
+
+	ticker := time.NewTicker(codePollInterval)
+	defer ticker.Stop()
+	for {
+		if info, err := os.Stat(path); err == nil && !info.ModTime().Before(requested) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			if code := strings.TrimSpace(string(data)); code != "" {
+				return code, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("no login code written to %s: %w", path, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// readCodePipe reads the code from a named pipe, opening it blocks until a writer appears
+func readCodePipe(ctx context.Context, path string) (string, error) {
+	type result struct {
+		code string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		f, err := os.Open(path)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer f.Close()
+		data, err := io.ReadAll(io.LimitReader(f, 256))
+		done <- result{code: strings.TrimSpace(string(data)), err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err == nil && r.code == "" {
+			r.err = fmt.Errorf("empty login code read from %s", path)
+		}
+		return r.code, r.err
+	case <-ctx.Done():
+		// The goroutine stays blocked in open until a writer shows up, there is no portable way to interrupt it
+		return "", fmt.Errorf("no login code written to %s: %w", path, ctx.Err())
+	}
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/telegram/downloader"
+	"github.com/gotd/td/tg"
+
+	"telegram-auto-checkin/internal/errs"
+)
+
+// saveReplyMedia downloads the photo (or image document) of a bot reply into dir.
+// It returns an empty path when the message has no image.
+func (c *Client) saveReplyMedia(ctx context.Context, msg *tg.Message, dir string) (string, error) {
+	var (
+		loc tg.InputFileLocationClass
+		ext string
+	)
+	switch m := msg.Media.(type) {
+	case *tg.MessageMediaPhoto:
+		photo, ok := m.Photo.(*tg.Photo)
+		if !ok {
+			return "", nil
+		}
+		size := largestPhotoSize(photo.Sizes)
+		if size == "" {
+			return "", nil
+		}
+		loc = &tg.InputPhotoFileLocation{
+			ID:            photo.ID,
+			AccessHash:    photo.AccessHash,
+			FileReference: photo.FileReference,
+			ThumbSize:     size,
+		}
+		ext = ".jpg"
+	case *tg.MessageMediaDocument:
+		doc, ok := m.Document.(*tg.Document)
+		if !ok || !strings.HasPrefix(doc.MimeType, "image/") {
+			return "", nil
+		}
+		loc = &tg.InputDocumentFileLocation{
+			ID:            doc.ID,
+			AccessHash:    doc.AccessHash,
+			FileReference: doc.FileReference,
+		}
+		if exts, _ := mime.ExtensionsByType(doc.MimeType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	default:
+		return "", nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s_%d%s", time.Now().Format("20060102_150405"), msg.ID, ext))
+	if _, err := downloader.NewDownloader().Download(c.api, loc).ToPath(ctx, path); err != nil {
+		return "", errs.Classify(err)
+	}
+	return path, nil
+}
+
+// largestPhotoSize returns the type of the biggest downloadable photo size
+func largestPhotoSize(sizes []tg.PhotoSizeClass) string {
+	var (
+		best string
+		area int
+	)
+	for _, s := range sizes {
+		switch size := s.(type) {
+		case *tg.PhotoSize:
+			if size.W*size.H > area {
+				best, area = size.Type, size.W*size.H
+			}
+		case *tg.PhotoSizeProgressive:
+			if size.W*size.H > area {
+				best, area = size.Type, size.W*size.H
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/gotd/td/tg"
+
+	"telegram-auto-checkin/internal/errs"
+)
+
+// Message selectors used by task options that act on an existing message
+const (
+	SelectLatest = "latest"
+	SelectPinned = "pinned"
+)
+
+// MessageOptions are optional settings of the message check-in method
+type MessageOptions struct {
+	ReplyTo  string // Message to reply to: latest | pinned | message ID, empty to send a plain message
+	Silent   bool   // Send without notification
+	Typing   bool   // Show "typing..." for a randomized, length-based interval before sending
+	MediaDir string // Download a photo in the bot reply into this directory, empty to skip
+	Format   string // Payload format: plain (default) | markdown | html
+	Reply    ReplyOptions
+
+	// Wait this long for the bot to edit buttons into a reply that has none yet, e.g. after
+	// "processing...", and use the edited message as the reply; 0 takes the reply as it is
+	KeyboardWaitSeconds int
+}
+
+// botCommandEntities marks a leading /command (optionally /command@bot) as a bot command entity,
+// some bots ignore commands that arrive without it
+func botCommandEntities(message string) []tg.MessageEntityClass {
+	if !strings.HasPrefix(message, "/") {
+		return nil
+	}
+	command := message
+	if i := strings.IndexAny(message, " \n\t"); i >= 0 {
+		command = message[:i]
+	}
+	if len(command) < 2 {
+		return nil
+	}
+	return []tg.MessageEntityClass{&tg.MessageEntityBotCommand{
+		Offset: 0,
+		Length: len(utf16.Encode([]rune(command))),
+	}}
+}
+
+// extractMessages returns the messages contained in a history/search response
+func extractMessages(res tg.MessagesMessagesClass) ([]tg.MessageClass, error) {
+	switch h := res.(type) {
+	case *tg.MessagesMessages:
+		return h.Messages, nil
+	case *tg.MessagesMessagesSlice:
+		return h.Messages, nil
+	case *tg.MessagesChannelMessages:
+		return h.Messages, nil
+	default:
+		return nil, fmt.Errorf("unexpected history type: %T", res)
+	}
+}
+
+// findMessage returns the message selected by "latest", "pinned" or a numeric message ID
+func (c *Client) findMessage(ctx context.Context, peer tg.InputPeerClass, selector string) (*tg.Message, error) {
+	var (
+		res tg.MessagesMessagesClass
+		err error
+	)
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		selector = SelectLatest
+	}
+	switch selector {
+	case SelectLatest:
+		res, err = c.api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+			Peer:  peer,
+			Limit: 1,
+		})
+	case SelectPinned:
+		res, err = c.api.MessagesSearch(ctx, &tg.MessagesSearchRequest{
+			Peer:   peer,
+			Filter: &tg.InputMessagesFilterPinned{},
+			Limit:  1,
+		})
+	default:
+		id, convErr := strconv.Atoi(selector)
+		if convErr != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid message selector %q, expected latest, pinned or a message ID", selector)
+		}
+		// History returns messages older than OffsetID, so the first one is the requested message
+		res, err = c.api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+			Peer:     peer,
+			OffsetID: id + 1,
+			Limit:    1,
+		})
+		if err == nil {
+			msgs, extractErr := extractMessages(res)
+			if extractErr != nil {
+				return nil, extractErr
+			}
+			if len(msgs) == 0 || msgs[0].GetID() != id {
+				return nil, fmt.Errorf("message %d not found", id)
+			}
+		}
+	}
+	if err != nil {
+		return nil, errs.Classify(err)
+	}
+
+	msgs, err := extractMessages(res)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("no %s message found", selector)
+	}
+	msg, ok := msgs[0].(*tg.Message)
+	if !ok {
+		return nil, fmt.Errorf("selected message is a service message")
+	}
+	return msg, nil
+}
+
+// findSentMessage returns the outgoing message contained in a send response, if any
+func findSentMessage(updates tg.UpdatesClass) *tg.Message {
+	var list []tg.UpdateClass
+	switch u := updates.(type) {
+	case *tg.Updates:
+		list = u.Updates
+	case *tg.UpdatesCombined:
+		list = u.Updates
+	}
+	for _, upd := range list {
+		var m tg.MessageClass
+		switch v := upd.(type) {
+		case *tg.UpdateNewMessage:
+			m = v.Message
+		case *tg.UpdateNewChannelMessage:
+			m = v.Message
+		default:
+			continue
+		}
+		if msg, ok := m.(*tg.Message); ok && msg.Out {
+			return msg
+		}
+	}
+	return nil
+}
+
+// sentDate returns the server time of the message sent with updates in Unix seconds, or the
+// local time when the updates don't carry it
+func sentDate(updates tg.UpdatesClass) int {
+	if u, ok := updates.(*tg.UpdateShortSentMessage); ok {
+		return u.Date
+	}
+	if msg := findSentMessage(updates); msg != nil {
+		return msg.Date
+	}
+	return int(time.Now().Unix())
+}
+
+// MarkReadInRun marks the whole target dialog as read
+func (c *Client) MarkReadInRun(ctx context.Context, target string) error {
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if ch, ok := peer.(*tg.InputPeerChannel); ok {
+		_, err = c.api.ChannelsReadHistory(ctx, &tg.ChannelsReadHistoryRequest{
+			Channel: &tg.InputChannel{ChannelID: ch.ChannelID, AccessHash: ch.AccessHash},
+		})
+	} else {
+		_, err = c.api.MessagesReadHistory(ctx, &tg.MessagesReadHistoryRequest{Peer: peer})
+	}
+	return errs.Classify(err)
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+const (
+	// defaultMaxFloodWait is the longest FLOOD_WAIT slept through before the error is returned
+	defaultMaxFloodWait = 60 * time.Second
+	// defaultTransientRetries bounds retries of a request failing with a transient error
+	defaultTransientRetries = 3
+)
+
+// RetryOptions configure how failed requests are retried, zero fields use the defaults
+type RetryOptions struct {
+	MaxFloodWaitSeconds int // Longest FLOOD_WAIT slept through before the request fails, default: 60
+	TransientRetries    int // Retries of a request failing with a network or server error, default: 3
+}
+
+// retryMiddleware retries requests that fail with a short FLOOD_WAIT or a transient
+// network/server error, so a blip while the session idles between tasks does not fail the task
+func retryMiddleware(log zerolog.Logger, opts RetryOptions) telegram.Middleware {
+	maxFloodWait := defaultMaxFloodWait
+	if opts.MaxFloodWaitSeconds > 0 {
+		maxFloodWait = time.Duration(opts.MaxFloodWaitSeconds) * time.Second
+	}
+	maxTransientRetries := defaultTransientRetries
+	if opts.TransientRetries > 0 {
+		maxTransientRetries = opts.TransientRetries
+	}
+	return telegram.MiddlewareFunc(func(next tg.Invoker) telegram.InvokeFunc {
+		return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+			retries := 0
+			for {
+				err := next.Invoke(ctx, input, output)
+				if err == nil || ctx.Err() != nil {
+					return err
+				}
+
+				var wait time.Duration
+				if d, ok := errs.FloodWait(err); ok && d <= maxFloodWait {
+					wait = d + time.Second
+					runLog(ctx, log).Warn().Dur("wait", wait).Msg(i18n.T("flood_wait_retrying"))
+				} else if errs.Transient(err) && retries < maxTransientRetries {
+					retries++
+					wait = time.Duration(retries) * 2 * time.Second
+					runLog(ctx, log).Warn().Err(err).Int("attempt", retries).Dur("wait", wait).Msg(i18n.T("transient_retrying"))
+				} else {
+					return err
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				}
+			}
+		}
+	})
+}
+
+// defaultReconnectMax is the longest wait between attempts to re-establish a dropped connection
+const defaultReconnectMax = time.Minute
+
+// reconnectBackoff is used by gotd to re-establish a dropped connection: it retries
+// forever, backing off up to maxInterval (default: a minute) between attempts
+func reconnectBackoff(maxInterval time.Duration) func() backoff.BackOff {
+	if maxInterval <= 0 {
+		maxInterval = defaultReconnectMax
+	}
+	return func() backoff.BackOff {
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = 500 * time.Millisecond
+		b.MaxInterval = maxInterval
+		b.MaxElapsedTime = 0
+		return b
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/gotd/td/telegram"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// MigrateHandler is called when Telegram moved the account to another data center
+type MigrateHandler func(fromDC, toDC int)
+
+// dcWatchStorage is the session storage of a client that notices data center migrations.
+// gotd follows USER_MIGRATE/PHONE_MIGRATE by switching the primary DC and storing the session
+// again (FILE_MIGRATE only opens a temporary connection), so a stored session with another
+// DC than the loaded one means the account was moved.
+type dcWatchStorage struct {
+	telegram.SessionStorage
+	log       zerolog.Logger
+	onMigrate MigrateHandler
+
+	mu sync.Mutex
+	dc int // DC of the stored session, 0 before it is known
+}
+
+func (s *dcWatchStorage) LoadSession(ctx context.Context) ([]byte, error) {
+	data, err := s.SessionStorage.LoadSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dc := sessionDC(data); dc != 0 {
+		s.mu.Lock()
+		s.dc = dc
+		s.mu.Unlock()
+		s.log.Debug().Int("dc", dc).Msg(i18n.T("session_loaded"))
+	}
+	return data, nil
+}
+
+func (s *dcWatchStorage) StoreSession(ctx context.Context, data []byte) error {
+	if err := s.SessionStorage.StoreSession(ctx, data); err != nil {
+		return err
+	}
+	dc := sessionDC(data)
+	if dc == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	prev := s.dc
+	s.dc = dc
+	s.mu.Unlock()
+
+	if prev != 0 && prev != dc {
+		s.log.Warn().Int("from_dc", prev).Int("to_dc", dc).Msg(i18n.T("dc_migrated_session"))
+		if s.onMigrate != nil {
+			s.onMigrate(prev, dc)
+		}
+	}
+	return nil
+}
+
+// sessionDC returns the data center of gotd session data, 0 when it cannot be read
+func sessionDC(data []byte) int {
+	var stored struct {
+		Data struct {
+			DC int `json:"DC"`
+		} `json:"Data"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return 0
+	}
+	return stored.Data.DC
+}
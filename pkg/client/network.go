@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gotd/td/telegram/dcs"
+	"github.com/gotd/td/transport"
+
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// NetworkOptions tune the MTProto connection for unstable networks, zero fields use the defaults
+type NetworkOptions struct {
+	Transport           string // intermediate (default) | abridged | padded_intermediate
+	KeepaliveSeconds    int    // Interval of a request keeping an idle connection alive, 0 relies on gotd's ping every minute
+	DialTimeoutSeconds  int    // Timeout of opening a connection, default: 35
+	ReconnectMaxSeconds int    // Longest wait between reconnection attempts, default: 60
+}
+
+// transports are the MTProto transports selectable by NetworkOptions.Transport
+var transports = map[string]dcs.Protocol{
+	"":                    transport.Intermediate,
+	"intermediate":        transport.Intermediate,
+	"abridged":            transport.Abridged,
+	"padded_intermediate": transport.PaddedIntermediate,
+}
+
+func transportProtocol(name string) (dcs.Protocol, error) {
+	proto, ok := transports[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown transport %q, expected intermediate, abridged or padded_intermediate", name)
+	}
+	return proto, nil
+}
+
+// keepAlive sends a cheap request at every interval until ctx ends, so NAT gateways and mobile
+// networks don't drop a connection idling between scheduled tasks
+func (c *Client) keepAlive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.api.HelpGetNearestDC(ctx); err != nil && ctx.Err() == nil {
+				c.log.Debug().Err(err).Msg(i18n.T("keepalive_failed"))
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package client
+
+import (
+	"context"
+
+	"telegram-auto-checkin/internal/errs"
+)
+
+// SetOnlineInRun updates the account's online status as seen by contacts.
+// Telegram expires an online status after about five minutes unless it is refreshed.
+func (c *Client) SetOnlineInRun(ctx context.Context, online bool) error {
+	_, err := c.api.AccountUpdateStatus(ctx, !online)
+	return errs.Classify(err)
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/net/proxy"
+
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// Proxies that failed are skipped for proxyCooldown, doubled on every further failure up to proxyMaxCooldown
+const (
+	proxyCooldown    = 30 * time.Second
+	proxyMaxCooldown = 10 * time.Minute
+)
+
+// proxyPool dials through a list of SOCKS5 proxies in order, skipping proxies that failed
+// recently. Clients of the same proxy list share a pool, so one client finding a proxy down
+// spares the others the timeout.
+type proxyPool struct {
+	proxies        []*poolProxy
+	fallbackDirect bool // Connect without a proxy when all proxies fail
+	log            zerolog.Logger
+
+	degraded atomic.Bool // The last connection was made without a proxy
+}
+
+type poolProxy struct {
+	addr   string
+	dialer proxy.Dialer
+
+	mu        sync.Mutex
+	failures  int
+	downUntil time.Time
+}
+
+var (
+	proxyPoolsMu sync.Mutex
+	proxyPools   = make(map[string]*proxyPool)
+)
+
+// sharedProxyPool returns the pool of addrs, created on first use
+func sharedProxyPool(addrs []string, fallbackDirect bool, log zerolog.Logger) (*proxyPool, error) {
+	key := strings.Join(addrs, ",") + ",direct=" + strconv.FormatBool(fallbackDirect)
+	proxyPoolsMu.Lock()
+	defer proxyPoolsMu.Unlock()
+	if p, ok := proxyPools[key]; ok {
+		return p, nil
+	}
+
+	p := &proxyPool{fallbackDirect: fallbackDirect, log: log}
+	for _, addr := range addrs {
+		dialer, err := proxy.SOCKS5("tcp", addr, nil, proxy.Direct)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy dialer for %s: %w", addr, err)
+		}
+		p.proxies = append(p.proxies, &poolProxy{addr: addr, dialer: dialer})
+	}
+	proxyPools[key] = p
+	return p, nil
+}
+
+// DialContext connects through the first healthy proxy, failing over to the next one. When all
+// proxies are cooling down they are tried anyway, a missed check-in is worse than a slow one.
+// With fallbackDirect, a connection without a proxy is the last resort.
+func (p *proxyPool) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	now := time.Now()
+	var healthy, down []*poolProxy
+	for _, px := range p.proxies {
+		if px.isDown(now) {
+			down = append(down, px)
+		} else {
+			healthy = append(healthy, px)
+		}
+	}
+
+	var errs []error
+	for _, px := range append(healthy, down...) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		conn, err := px.dial(ctx, network, addr)
+		if err == nil {
+			if px.recovered() {
+				p.log.Info().Str("proxy", px.addr).Msg(i18n.T("proxy_recovered"))
+			}
+			if p.degraded.Swap(false) {
+				p.log.Info().Str("proxy", px.addr).Msg(i18n.T("proxy_reconnecting"))
+			}
+			return conn, nil
+		}
+		cooldown := px.failed(time.Now())
+		errs = append(errs, fmt.Errorf("%s: %w", px.addr, err))
+		if len(p.proxies) > 1 {
+			p.log.Warn().Err(err).Str("proxy", px.addr).Dur("cooldown", cooldown).Msg(i18n.T("proxy_failed_next"))
+		}
+	}
+	if !p.fallbackDirect {
+		return nil, errors.Join(errs...)
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, errors.Join(append(errs, fmt.Errorf("direct: %w", err))...)
+	}
+	if !p.degraded.Swap(true) {
+		p.log.Warn().Err(errors.Join(errs...)).Msg(i18n.T("proxies_failed_direct"))
+	}
+	return conn, nil
+}
+
+func (px *poolProxy) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if cd, ok := px.dialer.(proxy.ContextDialer); ok {
+		return cd.DialContext(ctx, network, addr)
+	}
+	return px.dialer.Dial(network, addr)
+}
+
+func (px *poolProxy) isDown(now time.Time) bool {
+	px.mu.Lock()
+	defer px.mu.Unlock()
+	return now.Before(px.downUntil)
+}
+
+// failed records a failure and returns how long the proxy is skipped
+func (px *poolProxy) failed(now time.Time) time.Duration {
+	px.mu.Lock()
+	defer px.mu.Unlock()
+	cooldown := proxyCooldown << min(px.failures, 5)
+	if cooldown > proxyMaxCooldown {
+		cooldown = proxyMaxCooldown
+	}
+	px.failures++
+	px.downUntil = now.Add(cooldown)
+	return cooldown
+}
+
+// recovered resets the health of a proxy that connected and reports whether it had failed before
+func (px *poolProxy) recovered() bool {
+	px.mu.Lock()
+	defer px.mu.Unlock()
+	wasDown := px.failures > 0
+	px.failures = 0
+	px.downUntil = time.Time{}
+	return wasDown
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// defaultReaction is sent when the task payload is empty
+const defaultReaction = "👍"
+
+// ReactInRunWithLogger sends an emoji reaction to the latest, pinned or given message (with task logger)
+func (c *Client) ReactInRunWithLogger(ctx context.Context, target string, emoji string, selector string, taskLogger zerolog.Logger) error {
+	if emoji == "" {
+		emoji = defaultReaction
+	}
+	taskLog := taskLogger.With().Str("target", target).Str("reaction", emoji).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Str("reaction", emoji).Logger()
+
+	taskLog.Info().Msg(i18n.T("sending_reaction"))
+	mainLog.Info().Msg(i18n.T("sending_reaction"))
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	msg, err := c.findMessage(ctx, peer, selector)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.api.MessagesSendReaction(ctx, &tg.MessagesSendReactionRequest{
+		Peer:     peer,
+		MsgID:    msg.ID,
+		Reaction: []tg.ReactionClass{&tg.ReactionEmoji{Emoticon: emoji}},
+	})
+	if err != nil {
+		return errs.Classify(err)
+	}
+
+	for _, lg := range []zerolog.Logger{taskLog, mainLog} {
+		lg.Info().Int("message_id", msg.ID).Msg(i18n.T("reaction_completed"))
+	}
+	return nil
+}
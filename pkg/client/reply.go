@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/tg"
+)
+
+// Reply settings used when a call leaves them unset
+const (
+	defaultReplyWaitSeconds  = 3
+	defaultReplyHistoryLimit = 10
+)
+
+// ReplyOptions configure how a check-in call waits for the bot reply, zero fields use the defaults
+type ReplyOptions struct {
+	WaitSeconds  int // Seconds to wait for the bot reply, default: 3
+	HistoryLimit int // Number of historical messages searched for the reply, default: 10
+	// Only messages dated no earlier than the send and at most this many seconds after it
+	// count as the reply, 0 for no time limit
+	WindowSeconds int
+	From          []string // Only messages of these users (@username or user ID) count as the reply, e.g. the bot in a group
+}
+
+// wait returns the time to wait for the bot reply
+func (o ReplyOptions) wait() time.Duration {
+	if o.WaitSeconds > 0 {
+		return time.Duration(o.WaitSeconds) * time.Second
+	}
+	return defaultReplyWaitSeconds * time.Second
+}
+
+// waitReply sleeps for d, returning early with the context error when ctx is cancelled
+func waitReply(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limit returns the number of messages searched for the bot reply
+func (o ReplyOptions) limit() int {
+	if o.HistoryLimit > 0 {
+		return o.HistoryLimit
+	}
+	return defaultReplyHistoryLimit
+}
+
+// inWindow reports whether msg arrived within the reply window of a message sent at sentAt
+// (Unix seconds, server time)
+func (o ReplyOptions) inWindow(msg *tg.Message, sentAt int) bool {
+	if o.WindowSeconds <= 0 {
+		return true
+	}
+	return msg.Date >= sentAt && msg.Date <= sentAt+o.WindowSeconds
+}
+
+// fromSender reports whether msg was written by one of the users of o.From. users are the
+// users of the history response msg came from, needed to match usernames.
+func (o ReplyOptions) fromSender(msg *tg.Message, users []tg.UserClass) bool {
+	if len(o.From) == 0 {
+		return true
+	}
+	var sender int64
+	switch {
+	case msg.FromID != nil:
+		from, ok := msg.FromID.(*tg.PeerUser)
+		if !ok {
+			return false
+		}
+		sender = from.UserID
+	default:
+		// Messages of a private chat carry no sender, they come from the chat partner
+		peer, ok := msg.PeerID.(*tg.PeerUser)
+		if !ok {
+			return false
+		}
+		sender = peer.UserID
+	}
+
+	username := ""
+	for _, u := range users {
+		if user, ok := u.(*tg.User); ok && user.ID == sender {
+			username = user.Username
+			break
+		}
+	}
+	for _, f := range o.From {
+		f = strings.TrimPrefix(strings.TrimSpace(f), "@")
+		if id, err := strconv.ParseInt(f, 10, 64); err == nil {
+			if id == sender {
+				return true
+			}
+			continue
+		}
+		if username != "" && strings.EqualFold(f, username) {
+			return true
+		}
+	}
+	return false
+}
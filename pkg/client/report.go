@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+
+	"telegram-auto-checkin/internal/history"
+)
+
+// Report collects details of a task run besides its error, such as the bot reply.
+// Attach one to the context with WithReport before calling a check-in method.
+type Report struct {
+	Reply     string   // Text of the bot reply or callback answer
+	Artifacts []string // Files saved from the bot reply
+	Sent      []int    // Messages the account sent to the target, e.g. for post_action delete_sent
+
+	Transcript []history.TranscriptEntry // Messages exchanged with the target, in order
+}
+
+type reportKey struct{}
+
+// WithReport returns a context that check-in methods fill the given report through
+func WithReport(ctx context.Context, report *Report) context.Context {
+	return context.WithValue(ctx, reportKey{}, report)
+}
+
+// ReportFrom returns the report attached to ctx, or nil
+func ReportFrom(ctx context.Context) *Report {
+	report, _ := ctx.Value(reportKey{}).(*Report)
+	return report
+}
+
+func (r *Report) setReply(text string) {
+	if r != nil {
+		r.Reply = text
+	}
+}
+
+func (r *Report) addSent(id int) {
+	if r != nil && id > 0 {
+		r.Sent = append(r.Sent, id)
+	}
+}
+
+func (r *Report) addArtifact(path string) {
+	if r != nil {
+		r.Artifacts = append(r.Artifacts, path)
+	}
+}
+
+func (r *Report) addTranscript(entry history.TranscriptEntry) {
+	if r != nil {
+		r.Transcript = append(r.Transcript, entry)
+	}
+}
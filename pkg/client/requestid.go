@@ -0,0 +1,29 @@
+package client
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context tagging the log lines of check-in methods with the request
+// ID of a task run, so the client side of a run can be found next to its task log
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID attached to ctx, or ""
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// runLog returns log tagged with the request ID of the task run in ctx, if any
+func runLog(ctx context.Context, log zerolog.Logger) *zerolog.Logger {
+	if id := RequestIDFrom(ctx); id != "" {
+		log = log.With().Str("request_id", id).Logger()
+	}
+	return &log
+}
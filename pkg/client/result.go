@@ -0,0 +1,37 @@
+package client
+
+import (
+	"time"
+
+	"telegram-auto-checkin/internal/errs"
+)
+
+// Outcomes of a check-in call besides the errs failure classes
+const (
+	OutcomeOK    = "ok"    // The call succeeded
+	OutcomeError = "error" // The call failed with an unclassified error
+)
+
+// Result describes a check-in call, callers log or report the parts they need
+type Result struct {
+	SentMessageID  int           // Message sent by the account, 0 for button presses or when unknown
+	Reply          string        // Text of the bot reply, or of the bot's edit with WaitEdit
+	ReplyMessageID int           // Message holding the reply, 0 without one
+	CallbackAnswer string        // Answer to a callback button press, shown by Telegram as a toast or alert
+	CallbackURL    string        // URL a callback button press answered with
+	Duration       time.Duration // Time the call took, including the wait for the reply
+	Outcome        string        // OutcomeOK, the errs class of the failure or OutcomeError
+}
+
+// finish records the duration and outcome of a call started at start
+func (r *Result) finish(start time.Time, err error) {
+	r.Duration = time.Since(start)
+	switch {
+	case err == nil:
+		r.Outcome = OutcomeOK
+	case errs.Class(err) != "":
+		r.Outcome = errs.Class(err)
+	default:
+		r.Outcome = OutcomeError
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// scheduledGrace is how long a queued message may stay pending past its delivery time
+// before it counts as undelivered
+const scheduledGrace = 5 * time.Minute
+
+// ScheduleMessageInRunWithLogger queues message in Telegram's scheduled messages of the target for
+// every time in at that is not queued yet, so delivery does not depend on the daemon running.
+// format is the payload format of message, see MessageOptions.Format.
+// It fails when a message queued earlier is still pending well past its time (with task logger).
+func (c *Client) ScheduleMessageInRunWithLogger(ctx context.Context, target string, message string, format string, at []time.Time, taskLogger zerolog.Logger) error {
+	taskLog := taskLogger.With().Str("target", target).Str("payload", message).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Str("payload", message).Logger()
+	logs := []zerolog.Logger{taskLog, mainLog}
+
+	text, entities, err := formatMessage(message, format)
+	if err != nil {
+		return err
+	}
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	history, err := c.api.MessagesGetScheduledHistory(ctx, &tg.MessagesGetScheduledHistoryRequest{Peer: peer})
+	if err != nil {
+		return errs.Classify(err)
+	}
+	pending, err := extractMessages(history)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	queued := make(map[int64]bool, len(pending))
+	var overdue []string
+	for _, m := range pending {
+		msg, ok := m.(*tg.Message)
+		if !ok || msg.Message != text {
+			continue
+		}
+		due := time.Unix(int64(msg.Date), 0)
+		if now.Sub(due) > scheduledGrace {
+			overdue = append(overdue, due.Format("2006-01-02 15:04"))
+		}
+		queued[due.Unix()] = true
+	}
+
+	added := 0
+	for _, t := range at {
+		if queued[t.Unix()] {
+			continue
+		}
+		updates, err := c.api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
+			Peer:         peer,
+			Message:      text,
+			RandomID:     randInt64(),
+			Entities:     entities,
+			ScheduleDate: int(t.Unix()),
+		})
+		if err != nil {
+			return errs.Classify(err)
+		}
+		added++
+		messageID := 0
+		if sent := findSentMessage(updates); sent != nil {
+			messageID = sent.ID
+		}
+		for _, lg := range logs {
+			lg.Info().Time("deliver_at", t).Int("message_id", messageID).Msg(i18n.T("message_queued_scheduled"))
+		}
+	}
+	for _, lg := range logs {
+		lg.Info().Int("queued", added).Int("pending", len(queued)+added).Msg(i18n.T("scheduled_up_to_date"))
+	}
+
+	if len(overdue) > 0 {
+		return fmt.Errorf("scheduled messages were not delivered: %s", strings.Join(overdue, ", "))
+	}
+	return nil
+}
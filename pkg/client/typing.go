@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gotd/td/tg"
+
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// Typing simulation timings, the typing status expires on the server after about 5 seconds
+const (
+	typingBaseMin     = 1 * time.Second
+	typingBaseMax     = 3 * time.Second
+	typingPerCharMin  = 50 * time.Millisecond
+	typingPerCharMax  = 150 * time.Millisecond
+	typingMaxDuration = 15 * time.Second
+	typingRefresh     = 4 * time.Second
+)
+
+// typingDuration returns a randomized time a human would need to type text
+func typingDuration(text string) time.Duration {
+	d := randDuration(typingBaseMin, typingBaseMax)
+	for range utf8.RuneCountInString(text) {
+		d += randDuration(typingPerCharMin, typingPerCharMax)
+	}
+	return min(d, typingMaxDuration)
+}
+
+func randDuration(lo, hi time.Duration) time.Duration {
+	return lo + rand.N(hi-lo+1)
+}
+
+// simulateTyping shows the "typing..." status in the chat for as long as typing text would take.
+// Failures to set the status are ignored, only cancellation is returned.
+func (c *Client) simulateTyping(ctx context.Context, peer tg.InputPeerClass, text string) error {
+	deadline := time.Now().Add(typingDuration(text))
+	for {
+		if _, err := c.api.MessagesSetTyping(ctx, &tg.MessagesSetTypingRequest{
+			Peer:   peer,
+			Action: &tg.SendMessageTypingAction{},
+		}); err != nil {
+			runLog(ctx, c.log).Debug().Err(err).Msg(i18n.T("typing_failed"))
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(min(remaining, typingRefresh))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if time.Until(deadline) <= 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gotd/td/tg"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// pollSearchLimit is the number of recent messages scanned for the latest poll
+const pollSearchLimit = 50
+
+// VoteInRunWithLogger votes in the latest open poll of the target chat (with task logger).
+// option is matched against the answer texts first, then used as a 1-based answer index.
+func (c *Client) VoteInRunWithLogger(ctx context.Context, target string, option string, taskLogger zerolog.Logger) error {
+	taskLog := taskLogger.With().Str("target", target).Str("option", option).Logger()
+	mainLog := runLog(ctx, c.log).With().Str("target", target).Str("option", option).Logger()
+
+	taskLog.Info().Msg(i18n.T("voting_in_poll"))
+	mainLog.Info().Msg(i18n.T("voting_in_poll"))
+	peer, err := c.resolvePeer(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	history, err := c.api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+		Peer:  peer,
+		Limit: pollSearchLimit,
+	})
+	if err != nil {
+		return errs.Classify(err)
+	}
+	msgs, err := extractMessages(history)
+	if err != nil {
+		return err
+	}
+
+	// History is ordered newest first, take the first open poll
+	var (
+		msgID int
+		poll  *tg.MessageMediaPoll
+	)
+	for _, m := range msgs {
+		msg, ok := m.(*tg.Message)
+		if !ok {
+			continue
+		}
+		if media, ok := msg.Media.(*tg.MessageMediaPoll); ok && !media.Poll.Closed {
+			msgID, poll = msg.ID, media
+			break
+		}
+	}
+	if poll == nil {
+		return fmt.Errorf("no open poll found in the last %d messages", pollSearchLimit)
+	}
+
+	answer, err := matchPollAnswer(poll.Poll.Answers, option)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.api.MessagesSendVote(ctx, &tg.MessagesSendVoteRequest{
+		Peer:    peer,
+		MsgID:   msgID,
+		Options: [][]byte{answer.Option},
+	})
+	if err != nil {
+		return errs.Classify(err)
+	}
+
+	for _, lg := range []zerolog.Logger{taskLog, mainLog} {
+		lg.Info().
+			Int("message_id", msgID).
+			Str("question", poll.Poll.Question.Text).
+			Str("answer", answer.Text.Text).
+			Msg("Vote completed")
+	}
+	return nil
+}
+
+// matchPollAnswer finds the poll answer by text (exact, then case-insensitive) or 1-based index
+func matchPollAnswer(answers []tg.PollAnswer, option string) (tg.PollAnswer, error) {
+	option = strings.TrimSpace(option)
+	for _, a := range answers {
+		if a.Text.Text == option {
+			return a, nil
+		}
+	}
+	for _, a := range answers {
+		if strings.EqualFold(strings.TrimSpace(a.Text.Text), option) {
+			return a, nil
+		}
+	}
+	if idx, err := strconv.Atoi(option); err == nil && idx >= 1 && idx <= len(answers) {
+		return answers[idx-1], nil
+	}
+	return tg.PollAnswer{}, fmt.Errorf("poll has no option %q", option)
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gotd/td/tg"
+
+	"telegram-auto-checkin/internal/errs"
+)
+
+// webAppPlatform is reported to bots when opening mini apps
+const webAppPlatform = "android"
+
+// webAppReplyLimit caps the response body kept for logging
+const webAppReplyLimit = 4096
+
+// ButtonOptions are optional settings of the button check-in method
+type ButtonOptions struct {
+	OtherButton string // Action when the matched button is not a callback button: fail (default) | skip | open
+	WaitEdit    bool   // Wait for the bot to edit the message and use the edited text as the reply
+	WaitSeconds int    // Poll this long for a message with the button when the latest message lacks it, 0 to fail at once
+	WebApp      WebAppOptions
+	CallbackURL CallbackURLOptions
+	Reply       ReplyOptions
+}
+
+// WebAppOptions configure what happens after a web app (mini app) button was opened.
+// URL, Body and header values may contain {url} (the web view URL) and {init_data}
+// (the signed tgWebAppData passed to the mini app).
+type WebAppOptions struct {
+	Request bool              // Perform an HTTP request once the web view URL is received
+	Method  string            // HTTP method, default: GET
+	URL     string            // Request URL, default: the web view URL
+	Body    string            // Request body
+	Headers map[string]string // Extra request headers
+}
+
+// botInputUser finds the bot that owns a keyboard: the via-bot or sender of the message,
+// or the chat partner in a private chat
+func botInputUser(peer tg.InputPeerClass, msg *tg.Message, history tg.MessagesMessagesClass) (tg.InputUserClass, error) {
+	var botID int64
+	if id, ok := msg.GetViaBotID(); ok {
+		botID = id
+	} else if from, ok := msg.FromID.(*tg.PeerUser); ok {
+		botID = from.UserID
+	} else if user, ok := peer.(*tg.InputPeerUser); ok {
+		return &tg.InputUser{UserID: user.UserID, AccessHash: user.AccessHash}, nil
+	}
+
+	if modified, ok := history.AsModified(); ok {
+		for _, u := range modified.GetUsers() {
+			if user, ok := u.(*tg.User); ok && user.ID == botID {
+				return &tg.InputUser{UserID: user.ID, AccessHash: user.AccessHash}, nil
+			}
+		}
+	}
+	if user, ok := peer.(*tg.InputPeerUser); ok && user.UserID == botID {
+		return &tg.InputUser{UserID: user.UserID, AccessHash: user.AccessHash}, nil
+	}
+	return nil, fmt.Errorf("unable to determine the bot owning the web app button")
+}
+
+// requestWebView asks Telegram for the signed URL of a web app button
+func (c *Client) requestWebView(ctx context.Context, peer tg.InputPeerClass, bot tg.InputUserClass, btn tg.KeyboardButtonClass) (string, error) {
+	switch b := btn.(type) {
+	case *tg.KeyboardButtonWebView:
+		res, err := c.api.MessagesRequestWebView(ctx, &tg.MessagesRequestWebViewRequest{
+			Peer:     peer,
+			Bot:      bot,
+			URL:      b.URL,
+			Platform: webAppPlatform,
+		})
+		if err != nil {
+			return "", errs.Classify(err)
+		}
+		return res.URL, nil
+	case *tg.KeyboardButtonSimpleWebView:
+		res, err := c.api.MessagesRequestSimpleWebView(ctx, &tg.MessagesRequestSimpleWebViewRequest{
+			Bot:      bot,
+			URL:      b.URL,
+			Platform: webAppPlatform,
+		})
+		if err != nil {
+			return "", errs.Classify(err)
+		}
+		return res.URL, nil
+	default:
+		return "", fmt.Errorf("button %T is not a web app button", btn)
+	}
+}
+
+// callWebApp performs the configured HTTP request for an opened web app
+func (c *Client) callWebApp(ctx context.Context, webviewURL string, opts WebAppOptions) (int, string, error) {
+	replacer := strings.NewReplacer("{url}", webviewURL, "{init_data}", webAppInitData(webviewURL))
+
+	method := strings.ToUpper(opts.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	target := webviewURL
+	if opts.URL != "" {
+		target = replacer.Replace(opts.URL)
+	}
+	var body io.Reader
+	if opts.Body != "" {
+		body = strings.NewReader(replacer.Replace(opts.Body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid web app request: %w", err)
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, replacer.Replace(v))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, "", errs.Wrap(errs.ErrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, webAppReplyLimit))
+	return resp.StatusCode, string(content), nil
+}
+
+// webAppInitData extracts tgWebAppData from the URL fragment of a web view URL
+func webAppInitData(webviewURL string) string {
+	u, err := url.Parse(webviewURL)
+	if err != nil {
+		return ""
+	}
+	values, err := url.ParseQuery(u.Fragment)
+	if err != nil {
+		return ""
+	}
+	return values.Get("tgWebAppData")
+}
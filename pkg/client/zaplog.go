@@ -0,0 +1,70 @@
+package client
+
+import (
+	"slices"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newMTProtoLogger returns a zap logger for gotd writing to log, used to see auth key,
+// transport and RPC problems below the client API
+func newMTProtoLogger(log zerolog.Logger) *zap.Logger {
+	return zap.New(zerologCore{log: log.With().Str("component", "mtproto").Logger()})
+}
+
+// zerologCore is a zapcore.Core writing zap entries to a zerolog logger
+type zerologCore struct {
+	log    zerolog.Logger
+	fields []zapcore.Field
+}
+
+func (c zerologCore) Enabled(l zapcore.Level) bool {
+	return zerologLevel(l) >= zerolog.GlobalLevel()
+}
+
+func (c zerologCore) With(fields []zapcore.Field) zapcore.Core {
+	return zerologCore{log: c.log, fields: append(slices.Clip(c.fields), fields...)}
+}
+
+func (c zerologCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c zerologCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	ev := c.log.WithLevel(zerologLevel(e.Level))
+	if e.LoggerName != "" {
+		ev = ev.Str("logger", e.LoggerName)
+	}
+	ev.Fields(enc.Fields).Msg(e.Message)
+	return nil
+}
+
+func (c zerologCore) Sync() error {
+	return nil
+}
+
+// zerologLevel maps zap levels, the panic and fatal levels only log, gotd must not stop the program
+func zerologLevel(l zapcore.Level) zerolog.Level {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return zerolog.DebugLevel
+	case l == zapcore.InfoLevel:
+		return zerolog.InfoLevel
+	case l == zapcore.WarnLevel:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
@@ -0,0 +1,125 @@
+// Package engine embeds the check-in scheduler in another Go program. Accounts and tasks
+// are added in code instead of being read from config.yaml:
+//
+//	eng := engine.New(engine.Config{AppID: 12345, AppHash: "0123456789abcdef"}, log)
+//	if err := eng.AddAccount(engine.Account{Name: "main", Phone: "+8613800000000"}); err != nil {
+//		return err
+//	}
+//	if err := eng.AddTask("main", engine.Task{Name: "daily", Target: "@checkin_bot", Method: "message", Payload: "/checkin", Schedule: "0 9 * * *"}); err != nil {
+//		return err
+//	}
+//	return eng.Run(ctx)
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/pkg/executor"
+	"telegram-auto-checkin/pkg/scheduler"
+)
+
+// Config types, the same as the config.yaml sections they are named after
+type (
+	Config  = config.Config        // Global settings, Accounts may be left empty and added with AddAccount
+	Account = config.AccountConfig // An entry of accounts
+	Task    = config.TaskConfig    // An entry of an account's tasks
+)
+
+// TaskResult is the outcome of a task run by RunOnce
+type TaskResult = executor.TaskResult
+
+// Engine holds the accounts and tasks to run. It is not safe for concurrent use,
+// accounts and tasks added after Run or RunOnce started are not picked up.
+type Engine struct {
+	cfg Config
+	log zerolog.Logger
+}
+
+// New returns an engine with the global settings and accounts of cfg, logging to log
+func New(cfg Config, log zerolog.Logger) *Engine {
+	return &Engine{cfg: cfg, log: log}
+}
+
+// AddAccount adds an account, its name (or phone for unnamed accounts) must be unique
+func (e *Engine) AddAccount(acc Account) error {
+	if e.account(acc.Key()) != nil {
+		return fmt.Errorf("account %q already exists", acc.Key())
+	}
+	e.cfg.Accounts = append(e.cfg.Accounts, acc)
+	return nil
+}
+
+// AddTask adds a task to the account with the given name or phone
+func (e *Engine) AddTask(account string, task Task) error {
+	acc := e.account(account)
+	if acc == nil {
+		return fmt.Errorf("unknown account %q", account)
+	}
+	acc.Tasks = append(acc.Tasks, task)
+	return nil
+}
+
+// account finds an account by key or phone
+func (e *Engine) account(name string) *Account {
+	for i := range e.cfg.Accounts {
+		if acc := &e.cfg.Accounts[i]; acc.Key() == name || (acc.Phone != "" && acc.Phone == name) {
+			return acc
+		}
+	}
+	return nil
+}
+
+// Run connects the accounts and runs their scheduled and run_on_start tasks like the daemon,
+// until ctx is cancelled. It fails when the configuration is invalid.
+func (e *Engine) Run(ctx context.Context) error {
+	cfg, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	if err := scheduler.RunTasks(ctx, cfg, e.log, nil); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// RunOnce runs every enabled task once like --once mode, onResult (optional) receives the
+// outcome of each task, possibly from several goroutines. The error wraps
+// scheduler.ErrTasksFailed when some tasks failed.
+func (e *Engine) RunOnce(ctx context.Context, onResult func(TaskResult)) error {
+	cfg, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	return scheduler.RunTasksOnce(ctx, cfg, e.log, scheduler.OnceOptions{OnResult: onResult})
+}
+
+// prepare returns a copy of the configuration with templates and defaults applied and
+// allowed_targets checked, so the engine can run again after more accounts or tasks were added
+func (e *Engine) prepare() (*config.Config, error) {
+	cfg := e.cfg
+	cfg.Accounts = make([]Account, len(e.cfg.Accounts))
+	for i, acc := range e.cfg.Accounts {
+		acc.Tasks = slices.Clone(acc.Tasks)
+		cfg.Accounts[i] = acc
+	}
+	// Template names are matched in lower case, as viper stores them for config.yaml
+	cfg.TaskTemplates = make(map[string]Task, len(e.cfg.TaskTemplates))
+	for name, tmpl := range e.cfg.TaskTemplates {
+		cfg.TaskTemplates[strings.ToLower(name)] = tmpl
+	}
+	if err := config.Prepare(&cfg); err != nil {
+		return nil, err
+	}
+	if err := config.CheckAllowedTargets(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// dependent is a task waiting on an edge of the dependency graph
+type dependent struct {
+	task  config.TaskConfig
+	delay time.Duration
+	needs []string // Dependencies present in the account's task list
+}
+
+// dependencyGraph tracks which dependencies of every dependent task have succeeded
+// since it last ran
+type dependencyGraph struct {
+	mu         sync.Mutex
+	dependents map[string][]dependent     // Dependency name -> tasks waiting on it
+	succeeded  map[string]map[string]bool // Dependent name -> dependencies done
+	active     func(config.TaskConfig) bool
+}
+
+func newDependencyGraph(tasks []config.TaskConfig, active func(config.TaskConfig) bool) *dependencyGraph {
+	names := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		names[task.DisplayName()] = true
+	}
+	g := &dependencyGraph{
+		dependents: make(map[string][]dependent),
+		succeeded:  make(map[string]map[string]bool),
+		active:     active,
+	}
+	for _, task := range tasks {
+		// A dependency listed twice counts once, complete compares needs with a set
+		var (
+			needs []string
+			edges []config.DependencyConfig
+		)
+		for _, dep := range task.DependsOn {
+			if names[dep.Task] && !slices.Contains(needs, dep.Task) {
+				needs = append(needs, dep.Task)
+				edges = append(edges, dep)
+			}
+		}
+		for _, dep := range edges {
+			delay := time.Duration(dep.DelaySeconds) * time.Second
+			g.dependents[dep.Task] = append(g.dependents[dep.Task], dependent{task: task, delay: delay, needs: needs})
+		}
+	}
+	if len(g.dependents) == 0 {
+		return nil
+	}
+	return g
+}
+
+// complete records the outcome of a task and returns the dependents that became ready.
+// A failure resets the progress of its dependents, so they wait for a fresh success.
+func (g *dependencyGraph) complete(name string, ok bool) (ready, skipped []dependent) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, d := range g.dependents[name] {
+		depName := d.task.DisplayName()
+		if !ok {
+			delete(g.succeeded, depName)
+			skipped = append(skipped, d)
+			continue
+		}
+		done := g.succeeded[depName]
+		if done == nil {
+			done = make(map[string]bool)
+			g.succeeded[depName] = done
+		}
+		done[name] = true
+		if len(done) == len(d.needs) {
+			delete(g.succeeded, depName)
+			ready = append(ready, d)
+		}
+	}
+	return ready, skipped
+}
+
+// runDependents runs the tasks unlocked by the completion of req on the current worker,
+// waiting each edge's delay first
+func (e *TaskExecutor) runDependents(ctx context.Context, req TaskRequest, err error) {
+	if e.deps == nil {
+		return
+	}
+	name := req.Task.DisplayName()
+	ready, skipped := e.deps.complete(name, err == nil)
+	for _, d := range skipped {
+		req.Logger.Warn().Str("task", d.task.DisplayName()).Str("depends_on", name).Msg(i18n.T("dependency_failed"))
+	}
+	for _, d := range ready {
+		if e.deps.active != nil && !e.deps.active(d.task) {
+			req.Logger.Debug().Str("task", d.task.DisplayName()).Msg(i18n.T("dependent_disabled"))
+			continue
+		}
+		if d.delay > 0 {
+			req.Logger.Debug().Str("task", d.task.DisplayName()).Dur("delay", d.delay).Msg(i18n.T("wait_dependent"))
+			timer := time.NewTimer(d.delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-e.ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+		if !e.acquireSlot(ctx) {
+			return
+		}
+		next := TaskRequest{Task: d.task, Logger: req.Logger, TriggerType: "dependency", WorkerID: req.WorkerID, RequestID: newRequestID()}
+		depErr := e.executeTask(ctx, next)
+		e.releaseSlot()
+		e.runDependents(ctx, next, depErr)
+	}
+}
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"slices"
+	"testing"
+
+	"telegram-auto-checkin/internal/config"
+)
+
+// dependentTask returns a task named name depending on deps
+func dependentTask(name string, deps ...string) config.TaskConfig {
+	task := config.TaskConfig{Name: name, Target: "@bot"}
+	for _, dep := range deps {
+		task.DependsOn = append(task.DependsOn, config.DependencyConfig{Task: dep})
+	}
+	return task
+}
+
+// names returns the task names of dependents, sorted
+func names(deps []dependent) []string {
+	var out []string
+	for _, d := range deps {
+		out = append(out, d.task.DisplayName())
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestDependencyGraph(t *testing.T) {
+	// step completes a task and lists the dependents that become ready or are skipped
+	type step struct {
+		task    string
+		ok      bool
+		ready   []string
+		skipped []string
+	}
+	tests := []struct {
+		name  string
+		tasks []config.TaskConfig
+		steps []step
+	}{
+		{
+			name:  "chain",
+			tasks: []config.TaskConfig{dependentTask("a"), dependentTask("b", "a"), dependentTask("c", "b")},
+			steps: []step{{task: "a", ok: true, ready: []string{"b"}}, {task: "b", ok: true, ready: []string{"c"}}, {task: "c", ok: true}},
+		},
+		{
+			name:  "fan out",
+			tasks: []config.TaskConfig{dependentTask("a"), dependentTask("b", "a"), dependentTask("c", "a")},
+			steps: []step{{task: "a", ok: true, ready: []string{"b", "c"}}},
+		},
+		{
+			name:  "join waits for every dependency",
+			tasks: []config.TaskConfig{dependentTask("a"), dependentTask("b"), dependentTask("c", "a", "b")},
+			steps: []step{{task: "a", ok: true}, {task: "a", ok: true}, {task: "b", ok: true, ready: []string{"c"}}},
+		},
+		{
+			name:  "failure resets the progress",
+			tasks: []config.TaskConfig{dependentTask("a"), dependentTask("b"), dependentTask("c", "a", "b")},
+			steps: []step{{task: "a", ok: true}, {task: "b", ok: false, skipped: []string{"c"}}, {task: "b", ok: true}, {task: "a", ok: true, ready: []string{"c"}}},
+		},
+		{
+			name:  "duplicate dependency counts once",
+			tasks: []config.TaskConfig{dependentTask("a"), dependentTask("b", "a", "a")},
+			steps: []step{{task: "a", ok: true, ready: []string{"b"}}, {task: "a", ok: false, skipped: []string{"b"}}},
+		},
+		{
+			name:  "dependency outside the task list is ignored",
+			tasks: []config.TaskConfig{dependentTask("a"), dependentTask("b", "a", "filtered")},
+			steps: []step{{task: "a", ok: true, ready: []string{"b"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newDependencyGraph(tt.tasks, nil)
+			if g == nil {
+				t.Fatal("got no graph")
+			}
+			for i, s := range tt.steps {
+				ready, skipped := g.complete(s.task, s.ok)
+				if got := names(ready); !slices.Equal(got, s.ready) {
+					t.Errorf("step %d (%s): got ready %v, want %v", i, s.task, got, s.ready)
+				}
+				if got := names(skipped); !slices.Equal(got, s.skipped) {
+					t.Errorf("step %d (%s): got skipped %v, want %v", i, s.task, got, s.skipped)
+				}
+			}
+		})
+	}
+
+	if g := newDependencyGraph([]config.TaskConfig{dependentTask("a"), dependentTask("b")}, nil); g != nil {
+		t.Error("got a graph for tasks without dependencies, want nil")
+	}
+}
@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/i18n"
+	tgclient "telegram-auto-checkin/pkg/client"
+)
+
+// maxFollowUpSteps bounds a dialogue so a bot repeating the same prompt can't loop forever
+const maxFollowUpSteps = 5
+
+// runFollowUps answers bot follow-up prompts: while the latest reply matches a follow_ups rule,
+// the rule's message is sent or button clicked, and its reply is evaluated again.
+// The report is updated in place, so it holds the final reply afterwards.
+func runFollowUps(ctx context.Context, client Client, task config.TaskConfig, replyOpts tgclient.ReplyOptions, report *tgclient.Report, taskLogger zerolog.Logger) error {
+	if len(task.FollowUps) == 0 {
+		return nil
+	}
+
+	patterns := make([]*regexp.Regexp, len(task.FollowUps))
+	for i, rule := range task.FollowUps {
+		re, err := regexp.Compile(rule.IfReplyMatches)
+		if err != nil {
+			return fmt.Errorf("invalid follow_ups pattern %q: %w", rule.IfReplyMatches, err)
+		}
+		patterns[i] = re
+	}
+
+	ctx = tgclient.WithReport(ctx, report)
+	for step := 1; step <= maxFollowUpSteps; step++ {
+		matched := -1
+		for i, re := range patterns {
+			if report.Reply != "" && re.MatchString(report.Reply) {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			return nil
+		}
+
+		rule := task.FollowUps[matched]
+		stepLog := taskLogger.With().Int("follow_up_step", step).Str("matched", rule.IfReplyMatches).Logger()
+		reply := report.Reply
+		report.Reply = ""
+
+		var err error
+		switch {
+		case rule.ThenClick != "":
+			stepLog.Info().Str("reply", reply).Str("button_text", rule.ThenClick).Msg(i18n.T("followup_button"))
+			opts := tgclient.ButtonOptions{OtherButton: task.OtherButton, WaitEdit: task.WaitForEdit, Reply: replyOpts}
+			_, err = client.CheckInButton(ctx, task.Target, rule.ThenClick, opts, stepLog)
+		case rule.ThenSend != "":
+			stepLog.Info().Str("reply", reply).Str("message", rule.ThenSend).Msg(i18n.T("followup_message"))
+			_, err = client.CheckInMessage(ctx, task.Target, rule.ThenSend, tgclient.MessageOptions{Reply: replyOpts}, stepLog)
+		default:
+			return fmt.Errorf("follow_ups rule %q has neither then_send nor then_click", rule.IfReplyMatches)
+		}
+		if err != nil {
+			return fmt.Errorf("follow-up step %d failed: %w", step, err)
+		}
+	}
+	taskLogger.Warn().Int("max_steps", maxFollowUpSteps).Msg(i18n.T("followup_too_many_steps"))
+	return nil
+}
@@ -0,0 +1,660 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/crash"
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/events"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/logger"
+	"telegram-auto-checkin/internal/webhook"
+	tgclient "telegram-auto-checkin/pkg/client"
+)
+
+// defaultArtifactsDir is used when no artifacts directory is configured
+const defaultArtifactsDir = "./data/artifacts"
+
+// Presence modes, see SetPresence
+const (
+	PresenceAuto    = "auto"    // Leave the online status to Telegram
+	PresenceOffline = "offline" // Mark the account offline after every task
+	PresenceOnline  = "online"  // Keep the account online while its session is open
+)
+
+// Client is the Telegram client facade used by the executor and task handlers
+type Client interface {
+	CheckInMessage(ctx context.Context, target string, message string, opts tgclient.MessageOptions, taskLogger zerolog.Logger) (tgclient.Result, error)
+	CheckInButton(ctx context.Context, target string, buttonText string, opts tgclient.ButtonOptions, taskLogger zerolog.Logger) (tgclient.Result, error)
+	ReactInRunWithLogger(ctx context.Context, target string, emoji string, selector string, taskLogger zerolog.Logger) error
+	VoteInRunWithLogger(ctx context.Context, target string, option string, taskLogger zerolog.Logger) error
+	SendDiceInRunWithLogger(ctx context.Context, target string, emoji string, taskLogger zerolog.Logger) error
+	SendMediaInRunWithLogger(ctx context.Context, target string, media tgclient.MediaOptions, caption string, format string, taskLogger zerolog.Logger) error
+	SendLocationInRunWithLogger(ctx context.Context, target string, location tgclient.LocationOptions, taskLogger zerolog.Logger) error
+	ForwardInRunWithLogger(ctx context.Context, target string, from string, selector string, taskLogger zerolog.Logger) error
+	ScheduleMessageInRunWithLogger(ctx context.Context, target string, message string, format string, at []time.Time, taskLogger zerolog.Logger) error
+	MarkReadInRun(ctx context.Context, target string) error
+	DeleteMessagesInRun(ctx context.Context, target string, ids []int) error
+	ClearHistoryInRun(ctx context.Context, target string) error
+	SetOnlineInRun(ctx context.Context, online bool) error
+}
+
+// TaskRequest Task request
+type TaskRequest struct {
+	Task        config.TaskConfig
+	Logger      zerolog.Logger
+	TriggerType string // "run_on_start", "scheduled", "once" or "dependency"
+	WorkerID    int
+	RequestID   string
+}
+
+// TaskResult describes the outcome of a single task execution, it is published as the
+// TaskFinished event
+type TaskResult = events.TaskFinished
+
+// TaskExecutor manages concurrent worker pool
+type TaskExecutor struct {
+	client       Client
+	taskQueue    *taskQueue
+	workerCount  int
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	bus          *events.Bus           // Receives task events, nil publishes nothing
+	limiter      chan struct{}         // Optional worker slots shared with other executors
+	taskDelay    time.Duration         // Minimum gap between consecutive tasks of one worker
+	artifactDir  string                // Root directory for media saved from bot replies
+	deps         *dependencyGraph      // Tasks run after others succeed, nil without depends_on
+	overflow     string                // Policy when SubmitTask finds the queue full
+	blockTimeout time.Duration         // Longest wait for a free slot under OverflowBlock
+	onDrop       func(TaskRequest)     // Optional callback invoked for every dropped task
+	onFinish     func(TaskRequest)     // Optional callback invoked after every executed task
+	dropped      atomic.Int64          // Number of tasks dropped so far
+	busy         atomic.Int32          // Tasks taken from the queue and not finished yet
+	presence     string                // Online status handling, see SetPresence
+	onCrash      func(crash.Report)    // Optional callback invoked for every recovered panic
+	reply        tgclient.ReplyOptions // Account reply settings, see SetReplyOptions
+	quota        SendQuota             // Optional daily cap on the messages sent to a target
+	log          zerolog.Logger
+	logDir       string // Log directory
+	logFormat    string // Log format
+	accountName  string // Account name
+}
+
+// NewTaskExecutor creates task executor
+func NewTaskExecutor(client Client, workerCount, queueSize int, log zerolog.Logger, logDir, logFormat, accountName string) *TaskExecutor {
+	if workerCount <= 0 {
+		workerCount = config.DefaultWorkerCount
+	}
+	if queueSize <= 0 {
+		queueSize = config.DefaultTaskQueueSize
+	}
+	if logFormat == "" {
+		logFormat = "text" // default text format
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &TaskExecutor{
+		client:      client,
+		taskQueue:   newTaskQueue(queueSize),
+		workerCount: workerCount,
+		ctx:         ctx,
+		cancel:      cancel,
+		log:         log,
+		logDir:      logDir,
+		logFormat:   logFormat,
+		accountName: accountName,
+		artifactDir: defaultArtifactsDir,
+		overflow:    OverflowDrop,
+	}
+}
+
+// Start starts the worker pool (called within client.Run session)
+func (e *TaskExecutor) Start(ctx context.Context) {
+	e.log.Debug().Int("worker_count", e.workerCount).Msg(i18n.T("executor_starting"))
+	trackRunning(e, true)
+
+	for i := 0; i < e.workerCount; i++ {
+		e.wg.Add(1)
+		go e.worker(ctx, i)
+	}
+}
+
+// worker goroutine, executes tasks concurrently
+func (e *TaskExecutor) worker(ctx context.Context, id int) {
+	defer e.wg.Done()
+	defer e.restartOnPanic(ctx, id)
+
+	workerLog := e.log.With().Int("worker_id", id).Logger()
+	workerLog.Debug().Msg(i18n.T("worker_started"))
+
+	var lastFinished time.Time
+	for {
+		req, ok := e.taskQueue.next(ctx, e.ctx)
+		if !ok {
+			workerLog.Debug().Msg(i18n.T("worker_exiting"))
+			return
+		}
+		// The task is processed in a closure, so busy is released even when a panic after
+		// the task restarts the worker, and Drain does not wait for it
+		started := func() bool {
+			e.busy.Add(1)
+			defer e.busy.Add(-1)
+			// Concurrent task execution is safe within the same client.Run() session
+			req.WorkerID = id
+			if !e.waitTaskDelay(ctx, lastFinished) || !e.acquireSlot(ctx) {
+				return false
+			}
+			err := e.executeTask(ctx, req)
+			e.releaseSlot()
+			if e.onFinish != nil {
+				e.onFinish(req)
+			}
+			e.runDependents(ctx, req, err)
+			e.goOffline(ctx, workerLog)
+			lastFinished = time.Now()
+			return true
+		}()
+		if !started {
+			workerLog.Debug().Msg(i18n.T("worker_exiting"))
+			return
+		}
+	}
+}
+
+// waitTaskDelay keeps the configured gap since the previous task finished, returns false if cancelled
+func (e *TaskExecutor) waitTaskDelay(ctx context.Context, lastFinished time.Time) bool {
+	if e.taskDelay <= 0 || lastFinished.IsZero() {
+		return true
+	}
+	remaining := e.taskDelay - time.Since(lastFinished)
+	if remaining <= 0 {
+		return true
+	}
+	e.log.Debug().Dur("delay", remaining).Msg(i18n.T("wait_next_task"))
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-e.ctx.Done():
+		return false
+	}
+}
+
+// acquireSlot waits for a shared worker slot when a limiter is configured
+func (e *TaskExecutor) acquireSlot(ctx context.Context) bool {
+	if e.limiter == nil {
+		return true
+	}
+	select {
+	case e.limiter <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseSlot returns the shared worker slot taken by acquireSlot
+func (e *TaskExecutor) releaseSlot() {
+	if e.limiter != nil {
+		<-e.limiter
+	}
+}
+
+// executeTask executes a single task and returns its outcome
+func (e *TaskExecutor) executeTask(ctx context.Context, req TaskRequest) error {
+	taskName := req.Task.Name
+	if taskName == "" {
+		taskName = req.Task.Target
+	}
+	trigger := req.TriggerType
+	if trigger == "" {
+		trigger = "unspecified"
+	}
+	requestID := req.RequestID
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	// Client log lines of the run carry the request ID too
+	ctx = tgclient.WithRequestID(ctx, requestID)
+
+	// Create separate log file for task
+	taskLogger, logFile, err := logger.CreateTaskLogger(e.logDir, e.accountName, taskName, req.TriggerType, e.logFormat)
+	if err != nil {
+		e.log.Error().Err(err).Str("task", taskName).Msg(i18n.T("failed_create_task_log"))
+		taskLogger = req.Logger
+	} else {
+		openTaskLogs.Add(1)
+		defer func() {
+			logFile.Close()
+			openTaskLogs.Add(-1)
+		}()
+	}
+
+	e.bus.Publish(events.TaskStarted{
+		Account:   e.accountName,
+		Task:      taskName,
+		Target:    req.Task.Target,
+		Method:    req.Task.Method,
+		Trigger:   trigger,
+		RequestID: requestID,
+		Time:      time.Now(),
+	})
+
+	taskLog := taskLogger.With().
+		Int("thread_id", req.WorkerID).
+		Str("thread_name", taskName).
+		Str("task", taskName).
+		Str("target", req.Task.Target).
+		Str("method", req.Task.Method).
+		Str("trigger", trigger).
+		Str("request_id", requestID).
+		Logger()
+	mainLog := req.Logger.With().
+		Int("thread_id", req.WorkerID).
+		Str("thread_name", taskName).
+		Str("task", taskName).
+		Str("target", req.Task.Target).
+		Str("method", req.Task.Method).
+		Str("trigger", trigger).
+		Str("request_id", requestID).
+		Logger()
+
+	// Display different logs based on trigger type
+	if req.TriggerType == "run_on_start" {
+		taskLog.Info().Msg(i18n.T("task_start_on_start"))
+		mainLog.Info().Msg(i18n.T("account_task_on_start"))
+	} else if req.TriggerType == "scheduled" {
+		taskLog.Info().Msg(i18n.T("task_start_scheduled"))
+		mainLog.Info().Msg(i18n.T("account_task_scheduled"))
+	} else {
+		taskLog.Info().Msg(i18n.T("task_start_normal"))
+		mainLog.Info().Msg(i18n.T("account_task_normal"))
+	}
+
+	if req.Task.PingURL != "" {
+		if pingErr := webhook.Ping(ctx, req.Task.PingURL, webhook.PingStart, ""); pingErr != nil {
+			taskLog.Warn().Err(pingErr).Msg(i18n.T("ping_start_failed"))
+		}
+	}
+
+	// Execute task directly, gotd library handles concurrency safety internally
+	startedAt := time.Now()
+	report := &tgclient.Report{}
+	artifactDir := filepath.Join(e.artifactDir, logger.SanitizeFilename(e.accountName), logger.SanitizeFilename(taskName))
+	var mediaDir string
+	if req.Task.SaveReplyMedia {
+		mediaDir = artifactDir
+	}
+	inv := Invocation{Client: e.client, Account: e.accountName, Task: req.Task, MediaDir: mediaDir, Report: report, Reply: e.replyOptions(req.Task), Logger: taskLog}
+	reserved := 0
+	err = e.recoverTask(taskName, func() error {
+		if e.quota != nil && req.Task.Target != "" {
+			n := maxSends(req.Task)
+			if err := e.quota.Reserve(req.Task.Target, n); err != nil {
+				return err
+			}
+			reserved = n
+		}
+		if err := executeTaskWithLogger(tgclient.WithReport(ctx, report), inv); err != nil {
+			return err
+		}
+		return runFollowUps(ctx, e.client, req.Task, inv.Reply, report, taskLog)
+	})
+	if reserved > 0 && len(report.Sent) != reserved {
+		e.quota.Add(req.Task.Target, len(report.Sent)-reserved)
+	}
+	if err == nil {
+		err = checkSuccessKeywords(report.Reply, req.Task.SuccessKeywords)
+	}
+	if req.Task.MarkRead && err == nil {
+		if readErr := e.client.MarkReadInRun(ctx, req.Task.Target); readErr != nil {
+			taskLog.Warn().Err(readErr).Msg(i18n.T("mark_read_failed"))
+		} else {
+			taskLog.Debug().Msg(i18n.T("dialog_marked_read"))
+		}
+	}
+	if req.Task.PostAction.Action != "" && err == nil {
+		if postErr := e.runPostAction(ctx, req.Task, report.Sent, taskLog); postErr != nil {
+			taskLog.Warn().Err(postErr).Str("post_action", req.Task.PostAction.Action).Msg(i18n.T("post_action_failed"))
+		}
+	}
+	if req.Task.SaveTranscript && len(report.Transcript) > 0 {
+		path, writeErr := writeTranscript(artifactDir, transcriptFile{
+			Account:    e.accountName,
+			Task:       taskName,
+			Target:     req.Task.Target,
+			RequestID:  requestID,
+			StartedAt:  startedAt,
+			Transcript: report.Transcript,
+		})
+		if writeErr != nil {
+			taskLog.Warn().Err(writeErr).Msg(i18n.T("transcript_save_failed"))
+		} else {
+			report.Artifacts = append(report.Artifacts, path)
+			taskLog.Debug().Str("path", path).Msg(i18n.T("transcript_saved"))
+		}
+	}
+	extracted, extractErr := extractValues(report.Reply, req.Task.ReplyExtract)
+	if extractErr != nil {
+		taskLog.Warn().Err(extractErr).Msg(i18n.T("reply_extract_failed"))
+	}
+	if len(extracted) > 0 {
+		taskLog.Info().Interface("extracted", extracted).Msg(i18n.T("extracted_values"))
+		mainLog.Info().Interface("extracted", extracted).Msg(i18n.T("extracted_values"))
+	}
+	result := TaskResult{
+		Account:    e.accountName,
+		Task:       taskName,
+		Target:     req.Task.Target,
+		Method:     req.Task.Method,
+		Trigger:    trigger,
+		RequestID:  requestID,
+		StartedAt:  startedAt,
+		Duration:   time.Since(startedAt),
+		Err:        err,
+		ErrorClass: errs.Class(err),
+		Reply:      report.Reply,
+		Artifacts:  report.Artifacts,
+		Extracted:  extracted,
+		Transcript: report.Transcript,
+	}
+	e.bus.Publish(result)
+	if req.Task.PingURL != "" {
+		kind, body := webhook.PingSuccess, result.Reply
+		if err != nil {
+			kind, body = webhook.PingFail, err.Error()
+		}
+		if pingErr := webhook.Ping(ctx, req.Task.PingURL, kind, body); pingErr != nil {
+			taskLog.Warn().Err(pingErr).Msg(i18n.T("ping_result_failed"))
+			mainLog.Warn().Err(pingErr).Msg(i18n.T("ping_result_failed"))
+		}
+	}
+	if webhook.ShouldSend(req.Task.Webhook, result.Success()) {
+		if hookErr := webhook.Send(ctx, req.Task.Webhook, newWebhookData(result)); hookErr != nil {
+			taskLog.Warn().Err(hookErr).Msg(i18n.T("webhook_failed"))
+			mainLog.Warn().Err(hookErr).Msg(i18n.T("webhook_failed"))
+		} else {
+			taskLog.Debug().Msg(i18n.T("webhook_sent"))
+		}
+	}
+	if err != nil {
+		if class := errs.Class(err); class != "" {
+			taskLog = taskLog.With().Str("error_class", class).Logger()
+			mainLog = mainLog.With().Str("error_class", class).Logger()
+		}
+		if wait, ok := errs.FloodWait(err); ok {
+			taskLog.Warn().Dur("retry_after", wait).Msg(i18n.T("flood_wait"))
+			mainLog.Warn().Dur("retry_after", wait).Msg(i18n.T("flood_wait"))
+			e.bus.Publish(events.FloodWait{Account: e.accountName, Task: taskName, Wait: wait})
+		}
+		if errors.Is(errs.Classify(err), errs.ErrAuthRequired) {
+			e.bus.Publish(events.AuthRequired{Account: e.accountName, Task: taskName, Err: err})
+		}
+		if req.TriggerType == "run_on_start" {
+			taskLog.Error().Err(err).Str("payload", req.Task.Payload).Msg(i18n.T("task_failed_on_start"))
+			mainLog.Error().Err(err).Str("payload", req.Task.Payload).Msg(i18n.T("task_failed_on_start"))
+		} else if req.TriggerType == "scheduled" {
+			taskLog.Error().Err(err).Str("payload", req.Task.Payload).Msg(i18n.T("task_failed_scheduled"))
+			mainLog.Error().Err(err).Str("payload", req.Task.Payload).Msg(i18n.T("task_failed_scheduled"))
+		} else {
+			taskLog.Error().Err(err).Str("payload", req.Task.Payload).Msg(i18n.T("task_failed"))
+			mainLog.Error().Err(err).Str("payload", req.Task.Payload).Msg(i18n.T("task_failed"))
+		}
+	} else {
+		taskLog.Info().Msg(i18n.T("task_success"))
+		mainLog.Info().Msg(i18n.T("task_success"))
+	}
+	return err
+}
+
+// executeTaskWithLogger executes a single task (with task logger) through the method registry
+func executeTaskWithLogger(ctx context.Context, inv Invocation) error {
+	return runHandler(ctx, inv)
+}
+
+// SubmitTask submits task to execution queue, applying the overflow policy when it is full.
+// Tasks not triggered by the schedule and tasks with a higher priority are executed first.
+func (e *TaskExecutor) SubmitTask(task config.TaskConfig, logger zerolog.Logger, triggerType string) bool {
+	req := TaskRequest{Task: task, Logger: logger, TriggerType: triggerType, RequestID: newRequestID()}
+	queued, dropped := e.taskQueue.push(e.ctx, req, e.overflow, e.blockTimeout)
+	if dropped != nil {
+		e.dropTask(*dropped)
+	}
+	if !queued {
+		e.dropTask(req)
+	}
+	return queued
+}
+
+// dropTask records a task dropped because the queue was full
+func (e *TaskExecutor) dropTask(req TaskRequest) {
+	total := e.dropped.Add(1)
+	req.Logger.Warn().Str("task", req.Task.Name).Str("target", req.Task.Target).Str("overflow", e.overflow).Int64("dropped_total", total).Msg("⚠️ " + i18n.T("task_queue_full"))
+	if e.onDrop != nil {
+		e.onDrop(req)
+	}
+}
+
+// SubmitTaskBlocking submits task to execution queue (blocking)
+func (e *TaskExecutor) SubmitTaskBlocking(ctx context.Context, task config.TaskConfig, logger zerolog.Logger, triggerType string) bool {
+	requestID := newRequestID()
+	queued, _ := e.taskQueue.push(ctx, TaskRequest{Task: task, Logger: logger, TriggerType: triggerType, RequestID: requestID}, OverflowBlock, 0)
+	return queued
+}
+
+// SetEventBus publishes the TaskStarted, TaskFinished, FloodWait and AuthRequired events of
+// executed tasks to bus. Must be called before Start; events are published concurrently
+// from several workers.
+func (e *TaskExecutor) SetEventBus(bus *events.Bus) {
+	e.bus = bus
+}
+
+// SetWorkerLimiter shares a worker slot channel between executors so that the total
+// number of tasks running at once across them never exceeds its capacity.
+// Must be called before Start; nil disables the limit.
+func (e *TaskExecutor) SetWorkerLimiter(limiter chan struct{}) {
+	e.limiter = limiter
+}
+
+// SetInterTaskDelay sets the minimum gap between consecutive tasks run by the same worker.
+// Combined with a single worker this runs tasks strictly one-by-one. Must be called before Start.
+func (e *TaskExecutor) SetInterTaskDelay(delay time.Duration) {
+	e.taskDelay = delay
+}
+
+// SetArtifactsDir sets the root directory media saved from bot replies is stored under,
+// as <dir>/<account>/<task>/. Must be called before Start.
+func (e *TaskExecutor) SetArtifactsDir(dir string) {
+	if dir != "" {
+		e.artifactDir = dir
+	}
+}
+
+// SetDependents registers the account's tasks so that tasks with depends_on run after
+// their dependencies succeed. active is checked before a dependent runs and may be nil.
+// Must be called before Start.
+func (e *TaskExecutor) SetDependents(tasks []config.TaskConfig, active func(config.TaskConfig) bool) {
+	e.deps = newDependencyGraph(tasks, active)
+}
+
+// SendQuota caps the messages sent to a target per day
+type SendQuota interface {
+	// Reserve counts n messages to target before a task runs, or returns an error wrapping
+	// errs.ErrQuotaExceeded when they would take target above its cap. Concurrent calls never
+	// overshoot the cap.
+	Reserve(target string, n int) error
+	// Add counts the messages a task sent to target beyond the ones it reserved, negative for
+	// reserved messages it did not send
+	Add(target string, extra int)
+}
+
+// maxSends returns the most messages a run of task can send to its target: its messages,
+// plus one per follow-up step when a follow_ups rule answers with a message
+func maxSends(task config.TaskConfig) int {
+	n := max(len(task.Messages), 1)
+	for _, rule := range task.FollowUps {
+		if rule.ThenSend != "" {
+			return n + maxFollowUpSteps
+		}
+	}
+	return n
+}
+
+// SetSendQuota makes tasks fail without running once their target reached its daily cap,
+// nil removes the cap. Must be called before Start.
+func (e *TaskExecutor) SetSendQuota(quota SendQuota) {
+	e.quota = quota
+}
+
+// Drain stops accepting new tasks and waits until all queued tasks have been executed
+func (e *TaskExecutor) Drain() {
+	e.taskQueue.close()
+	e.wg.Wait()
+}
+
+// Stop stops the executor
+func (e *TaskExecutor) Stop() {
+	e.cancel()
+	e.taskQueue.close()
+	e.wg.Wait()
+	trackRunning(e, false)
+	e.log.Debug().Msg(i18n.T("executor_stopped"))
+}
+
+// SetOverflowPolicy sets what SubmitTask does when the queue is full: OverflowDrop (default),
+// OverflowBlock waiting up to timeout, OverflowDropOldest or OverflowExpand. Must be called before Start.
+func (e *TaskExecutor) SetOverflowPolicy(policy string, timeout time.Duration) {
+	if policy != "" {
+		e.overflow = policy
+	}
+	e.blockTimeout = timeout
+}
+
+// SetPresence sets how the account's online status is handled around tasks: PresenceAuto
+// (default) leaves it to Telegram, PresenceOffline marks the account offline after every task.
+// Must be called before Start.
+func (e *TaskExecutor) SetPresence(mode string) {
+	e.presence = mode
+}
+
+// goOffline marks the account offline after a task when the presence mode asks for it
+func (e *TaskExecutor) goOffline(ctx context.Context, log zerolog.Logger) {
+	if e.presence != PresenceOffline {
+		return
+	}
+	if err := e.client.SetOnlineInRun(ctx, false); err != nil {
+		log.Warn().Err(err).Msg(i18n.T("set_offline_failed"))
+	}
+}
+
+// SetReplyOptions sets the reply wait and history limit of the account's tasks, task-level
+// settings take priority. Must be called before Start.
+func (e *TaskExecutor) SetReplyOptions(opts tgclient.ReplyOptions) {
+	e.reply = opts
+}
+
+// replyOptions resolves the reply settings of a task, priority: task > account
+func (e *TaskExecutor) replyOptions(task config.TaskConfig) tgclient.ReplyOptions {
+	opts := e.reply
+	if task.ReplyWaitSeconds > 0 {
+		opts.WaitSeconds = task.ReplyWaitSeconds
+	}
+	if task.ReplyHistoryLimit > 0 {
+		opts.HistoryLimit = task.ReplyHistoryLimit
+	}
+	if task.ReplyWindowSeconds > 0 {
+		opts.WindowSeconds = task.ReplyWindowSeconds
+	}
+	opts.From = task.ReplyFrom
+	return opts
+}
+
+// SetCrashHandler registers a callback invoked for every panic recovered in a task or worker,
+// after the crash file was written. Must be called before Start.
+func (e *TaskExecutor) SetCrashHandler(fn func(crash.Report)) {
+	e.onCrash = fn
+}
+
+// recoverTask runs fn, turning a panic into the error of the task so its failure is recorded
+// like any other and the worker carries on
+func (e *TaskExecutor) recoverTask(taskName string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			report := crash.Capture("task", e.accountName, taskName, r)
+			e.handleCrash(report)
+			err = report
+		}
+	}()
+	return fn()
+}
+
+// restartOnPanic replaces a worker that panicked outside a task with a new one
+func (e *TaskExecutor) restartOnPanic(ctx context.Context, id int) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	e.handleCrash(crash.Capture("worker", e.accountName, "", r))
+	if ctx.Err() == nil && e.ctx.Err() == nil {
+		e.wg.Add(1)
+		go e.worker(ctx, id)
+	}
+}
+
+// handleCrash writes the crash file and passes the report on to the crash handler
+func (e *TaskExecutor) handleCrash(report crash.Report) {
+	event := e.log.Error().Str("component", report.Component).Str("task", report.Task).Interface("panic", report.Value)
+	if path, err := crash.Write(crash.Dir(e.logDir), report); err != nil {
+		event = event.AnErr("write_error", err)
+	} else {
+		event = event.Str("crash_file", path)
+	}
+	event.Msg(i18n.T("panic_recovered"))
+	if e.onCrash != nil {
+		e.onCrash(report)
+	}
+}
+
+// SetDropHandler registers a callback invoked for every task dropped because the queue was full.
+// It runs on the submitting goroutine. Must be called before Start.
+func (e *TaskExecutor) SetDropHandler(fn func(TaskRequest)) {
+	e.onDrop = fn
+}
+
+// SetFinishHandler registers a callback invoked after every executed task, whatever its outcome,
+// before its dependents run. It runs on the worker goroutine. Must be called before Start.
+func (e *TaskExecutor) SetFinishHandler(fn func(TaskRequest)) {
+	e.onFinish = fn
+}
+
+// Dropped returns the number of tasks dropped because the queue was full
+func (e *TaskExecutor) Dropped() int64 {
+	return e.dropped.Load()
+}
+
+// QueueLen returns the queue length
+func (e *TaskExecutor) QueueLen() int {
+	return e.taskQueue.len()
+}
+
+// newRequestID returns an identifier of a task run for correlating its log lines across
+// app.log, the account log and the task log. The random suffix keeps runs started in the
+// same nanosecond apart.
+func newRequestID() string {
+	return fmt.Sprintf("%x-%04x", time.Now().UnixNano(), rand.N(0x10000))
+}
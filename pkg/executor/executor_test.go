@@ -0,0 +1,196 @@
+package executor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gotd/td/tgerr"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/events"
+	"telegram-auto-checkin/internal/fake"
+	"telegram-auto-checkin/internal/history"
+	"telegram-auto-checkin/pkg/client"
+	"telegram-auto-checkin/pkg/executor"
+)
+
+// checkinBot answers /checkin with the earned points and a keyboard of a callback button
+// and a URL button opening url
+func checkinBot(url string) *fake.Bot {
+	return &fake.Bot{
+		Username: "checkinbot",
+		OnMessage: func(text string) (fake.Reply, bool) {
+			if text != "/checkin" {
+				return fake.Reply{}, false
+			}
+			return fake.Reply{
+				Text: "Checked in! You earned 1,250 points, streak 7 days",
+				Buttons: []fake.Button{
+					{Text: "Claim", OnClick: func() fake.Reply { return fake.Reply{Text: "Bonus claimed"} }},
+					{Text: "Website", URL: url},
+				},
+			}, true
+		},
+	}
+}
+
+// checkinTask sends /checkin to the check-in bot
+var checkinTask = config.TaskConfig{Name: "checkin", Target: "@checkinbot", Method: "message", Payload: "/checkin"}
+
+// runTasks executes tasks one after another on a client of server and returns their results
+func runTasks(t *testing.T, server *fake.Server, tasks ...config.TaskConfig) []executor.TaskResult {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		results []executor.TaskResult
+	)
+	bus := events.NewBus()
+	bus.Subscribe(func(ev events.Event) {
+		if res, ok := ev.(events.TaskFinished); ok {
+			mu.Lock()
+			results = append(results, res)
+			mu.Unlock()
+		}
+	})
+
+	log := zerolog.Nop()
+	exec := executor.NewTaskExecutor(client.NewClientWithInvoker(server, client.RetryOptions{}, log), 1, 10, log, t.TempDir(), "text", "test")
+	exec.SetReplyOptions(client.ReplyOptions{WaitSeconds: 1})
+	exec.SetArtifactsDir(t.TempDir())
+	exec.SetEventBus(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exec.Start(ctx)
+	defer exec.Stop()
+	for _, task := range tasks {
+		if !exec.SubmitTaskBlocking(ctx, task, log, "once") {
+			t.Fatalf("task %s was not queued", task.Name)
+		}
+	}
+	exec.Drain()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(results) != len(tasks) {
+		t.Fatalf("got %d results, want %d", len(results), len(tasks))
+	}
+	return results
+}
+
+func TestRetryTransientError(t *testing.T) {
+	server := fake.NewServer(checkinBot(""))
+	server.FailNext(errs.ErrNetwork)
+
+	res := runTasks(t, server, checkinTask)[0]
+	if res.Err != nil {
+		t.Fatalf("task failed after a transient error: %v", res.Err)
+	}
+	if sent := server.Sent("checkinbot"); len(sent) != 1 {
+		t.Errorf("sent %q, want the check-in sent once", sent)
+	}
+	if tr := res.Transcript; len(tr) != 2 || tr[0].Kind != history.TranscriptSent || tr[1].Kind != history.TranscriptReceived {
+		t.Errorf("got transcript %+v, want the check-in and the bot reply", tr)
+	}
+}
+
+func TestPermanentError(t *testing.T) {
+	server := fake.NewServer(checkinBot(""))
+	server.FailNext(tgerr.New(400, "USERNAME_INVALID"))
+
+	res := runTasks(t, server, checkinTask)[0]
+	if !errors.Is(res.Err, errs.ErrPeerNotFound) {
+		t.Fatalf("got error %v, want peer not found without retries", res.Err)
+	}
+	if res.ErrorClass != "peer_not_found" {
+		t.Errorf("got error class %q, want peer_not_found", res.ErrorClass)
+	}
+	if sent := server.Sent("checkinbot"); len(sent) != 0 {
+		t.Errorf("sent %q, want nothing sent", sent)
+	}
+}
+
+func TestReplyParsing(t *testing.T) {
+	tests := []struct {
+		name     string
+		keywords []string
+		success  bool
+	}{
+		{"no keywords", nil, true},
+		{"keyword matched case-insensitively", []string{"already", "checked in"}, true},
+		{"keyword missing", []string{"already checked in"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := checkinTask
+			task.SuccessKeywords = tt.keywords
+			task.ReplyExtract = []string{`earned (?P<points>[\d,]+) points`, `streak (?P<streak>\d+) days`}
+
+			res := runTasks(t, fake.NewServer(checkinBot("")), task)[0]
+			if res.Success() != tt.success {
+				t.Fatalf("got success %v (error %v), want %v", res.Success(), res.Err, tt.success)
+			}
+			if !strings.HasPrefix(res.Reply, "Checked in!") {
+				t.Errorf("got reply %q, want the bot reply", res.Reply)
+			}
+			if res.Extracted["points"] != 1250 || res.Extracted["streak"] != 7 {
+				t.Errorf("got extracted %v, want points 1250 and streak 7", res.Extracted)
+			}
+		})
+	}
+}
+
+func TestButtonMatching(t *testing.T) {
+	var opened atomic.Int32
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opened.Add(1)
+	}))
+	defer site.Close()
+
+	tests := []struct {
+		name    string
+		button  string
+		other   string
+		wantErr error // nil for success, errAny for any error
+		reply   string
+		opened  bool
+	}{
+		{name: "callback button", button: "Claim", reply: "Bonus claimed"},
+		{name: "missing button", button: "Daily", wantErr: errs.ErrButtonNotFound},
+		{name: "url button fails by default", button: "Website", wantErr: errAny},
+		{name: "url button fails", button: "Website", other: "fail", wantErr: errAny},
+		{name: "url button skipped", button: "Website", other: "skip"},
+		{name: "url button opened", button: "Website", other: "open", opened: true},
+		{name: "unknown action", button: "Website", other: "press", wantErr: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened.Store(0)
+			button := config.TaskConfig{Name: "button", Target: "@checkinbot", Method: "button", Payload: tt.button, OtherButton: tt.other}
+
+			res := runTasks(t, fake.NewServer(checkinBot(site.URL)), checkinTask, button)[1]
+			switch {
+			case tt.wantErr == nil && res.Err != nil:
+				t.Fatalf("task failed: %v", res.Err)
+			case tt.wantErr == errAny && res.Err == nil, tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(res.Err, tt.wantErr):
+				t.Fatalf("got error %v, want %v", res.Err, tt.wantErr)
+			}
+			if tt.wantErr == nil && res.Reply != tt.reply {
+				t.Errorf("got reply %q, want %q", res.Reply, tt.reply)
+			}
+			if got := opened.Load() > 0; got != tt.opened {
+				t.Errorf("got URL opened %v, want %v", got, tt.opened)
+			}
+		})
+	}
+}
+
+// errAny stands for any error in TestButtonMatching
+var errAny = errors.New("any error")
@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberCleaner drops thousands separators and spaces before parsing extracted numbers
+var numberCleaner = strings.NewReplacer(",", "", " ", "", "_", "", "'", "")
+
+// extractValues applies reply_extract patterns to a reply and returns the numeric value of every
+// matched named group, e.g. `earned (?P<points>\d+) points`. Later patterns override earlier ones.
+func extractValues(reply string, patterns []string) (map[string]float64, error) {
+	if reply == "" || len(patterns) == 0 {
+		return nil, nil
+	}
+
+	var (
+		values  map[string]float64
+		invalid []string
+	)
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			invalid = append(invalid, pattern)
+			continue
+		}
+		match := re.FindStringSubmatch(reply)
+		if match == nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if name == "" || match[i] == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(numberCleaner.Replace(match[i]), 64)
+			if err != nil {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]float64)
+			}
+			values[name] = value
+		}
+	}
+	if len(invalid) > 0 {
+		return values, fmt.Errorf("invalid reply_extract pattern(s): %s", strings.Join(invalid, ", "))
+	}
+	return values, nil
+}
+
+// checkSuccessKeywords fails a task whose reply contains none of the configured keywords
+func checkSuccessKeywords(reply string, keywords []string) error {
+	if len(keywords) == 0 {
+		return nil
+	}
+	lower := strings.ToLower(reply)
+	for _, keyword := range keywords {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			return nil
+		}
+	}
+	if reply == "" {
+		return fmt.Errorf("no reply received, expected one of the success keywords")
+	}
+	return fmt.Errorf("reply does not contain any success keyword: %q", reply)
+}
@@ -0,0 +1,149 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/i18n"
+	tgclient "telegram-auto-checkin/pkg/client"
+)
+
+// Built-in task methods
+func init() {
+	Register("message", HandlerFunc(runMessage))
+	Register("button", HandlerFunc(runButton))
+	Register("reaction", HandlerFunc(runReaction))
+	Register("vote", HandlerFunc(runVote))
+	Register("dice", HandlerFunc(runDice))
+	Register("forward", HandlerFunc(runForward))
+	Register("media", HandlerFunc(runMedia))
+	Register("location", HandlerFunc(runLocation))
+}
+
+// runMessage sends the payload as a message, or the messages of the task one after another,
+// then clicks the buttons of the task in the reply. With schedule_ahead the payload is queued
+// in Telegram's scheduled messages instead.
+func runMessage(ctx context.Context, inv Invocation) error {
+	task := inv.Task
+	if task.ScheduleAhead.At != "" {
+		times, err := task.ScheduleAhead.Times(time.Now())
+		if err != nil {
+			return err
+		}
+		return inv.Client.ScheduleMessageInRunWithLogger(ctx, task.Target, task.Payload, task.PayloadFormat, times, inv.Logger)
+	}
+
+	opts := tgclient.MessageOptions{ReplyTo: task.ReplyToMessage, Silent: task.Silent, Typing: task.SimulateTyping, MediaDir: inv.MediaDir, Format: task.PayloadFormat, Reply: inv.Reply, KeyboardWaitSeconds: task.EditWaitSeconds}
+	if len(task.Messages) == 0 {
+		res, err := inv.Client.CheckInMessage(ctx, task.Target, task.Payload, opts, inv.Logger)
+		logResult(inv.Logger, res)
+		if err != nil {
+			return err
+		}
+		return clickButtons(ctx, inv, task.Buttons)
+	}
+
+	for i, step := range task.Messages {
+		stepLog := inv.Logger.With().Int("message_step", i+1).Logger()
+		if step.DelaySeconds > 0 {
+			stepLog.Debug().Int("delay_seconds", step.DelaySeconds).Msg(i18n.T("wait_next_message"))
+			timer := time.NewTimer(time.Duration(step.DelaySeconds) * time.Second)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		res, err := inv.Client.CheckInMessage(ctx, task.Target, step.Text, opts, stepLog)
+		logResult(stepLog, res)
+		if err != nil {
+			return fmt.Errorf("message %d of %d failed: %w", i+1, len(task.Messages), err)
+		}
+	}
+	return clickButtons(ctx, inv, task.Buttons)
+}
+
+// buttonStepWaitSeconds is the least time a button after the first one of a sequence is
+// waited for, as the bot has to update the keyboard after the previous click
+const buttonStepWaitSeconds = 15
+
+// runButton clicks the inline button matching the payload, or the buttons of the task one
+// after another, e.g. a check-in button followed by its confirmation
+func runButton(ctx context.Context, inv Invocation) error {
+	if len(inv.Task.Buttons) == 0 {
+		res, err := inv.Client.CheckInButton(ctx, inv.Task.Target, inv.Task.Payload, buttonOptions(inv), inv.Logger)
+		logResult(inv.Logger, res)
+		return err
+	}
+	return clickButtons(ctx, inv, inv.Task.Buttons)
+}
+
+// buttonOptions returns the button settings of the task
+func buttonOptions(inv Invocation) tgclient.ButtonOptions {
+	task := inv.Task
+	return tgclient.ButtonOptions{OtherButton: task.OtherButton, WaitEdit: task.WaitForEdit, WaitSeconds: task.ButtonWaitSeconds, WebApp: tgclient.WebAppOptions(task.WebApp), CallbackURL: tgclient.CallbackURLOptions(task.CallbackURL), Reply: inv.Reply}
+}
+
+// clickButtons clicks buttons one after another, waiting for each after the first one
+func clickButtons(ctx context.Context, inv Invocation, buttons []string) error {
+	task := inv.Task
+	opts := buttonOptions(inv)
+	for i, text := range buttons {
+		stepLog := inv.Logger.With().Int("button_step", i+1).Logger()
+		if i > 0 {
+			opts.WaitSeconds = max(task.ButtonWaitSeconds, buttonStepWaitSeconds)
+		}
+		res, err := inv.Client.CheckInButton(ctx, task.Target, text, opts, stepLog)
+		logResult(stepLog, res)
+		if err != nil {
+			return fmt.Errorf("button %d of %d failed: %w", i+1, len(buttons), err)
+		}
+	}
+	return nil
+}
+
+// runForward forwards the message selected by the payload from forward_from to the target
+func runForward(ctx context.Context, inv Invocation) error {
+	return inv.Client.ForwardInRunWithLogger(ctx, inv.Task.Target, inv.Task.ForwardFrom, inv.Task.Payload, inv.Logger)
+}
+
+// logResult records the details of a check-in call in the task log
+func logResult(log zerolog.Logger, res tgclient.Result) {
+	log.Debug().
+		Int("sent_message_id", res.SentMessageID).
+		Int("reply_message_id", res.ReplyMessageID).
+		Str("callback_answer", res.CallbackAnswer).
+		Dur("duration", res.Duration).
+		Str("outcome", res.Outcome).
+		Msg("Check-in call finished")
+}
+
+// runReaction reacts to a message with the payload emoji
+func runReaction(ctx context.Context, inv Invocation) error {
+	return inv.Client.ReactInRunWithLogger(ctx, inv.Task.Target, inv.Task.Payload, inv.Task.ReactTo, inv.Logger)
+}
+
+// runVote votes for the poll option named by the payload
+func runVote(ctx context.Context, inv Invocation) error {
+	return inv.Client.VoteInRunWithLogger(ctx, inv.Task.Target, inv.Task.Payload, inv.Logger)
+}
+
+// runMedia sends the photo, sticker or GIF of the task, with the payload as caption
+func runMedia(ctx context.Context, inv Invocation) error {
+	task := inv.Task
+	return inv.Client.SendMediaInRunWithLogger(ctx, task.Target, tgclient.MediaOptions(task.Media), task.Payload, task.PayloadFormat, inv.Logger)
+}
+
+// runLocation shares the geo point of the task
+func runLocation(ctx context.Context, inv Invocation) error {
+	return inv.Client.SendLocationInRunWithLogger(ctx, inv.Task.Target, tgclient.LocationOptions(inv.Task.Location), inv.Logger)
+}
+
+// runDice sends an animated dice
+func runDice(ctx context.Context, inv Invocation) error {
+	return inv.Client.SendDiceInRunWithLogger(ctx, inv.Task.Target, inv.Task.Payload, inv.Logger)
+}
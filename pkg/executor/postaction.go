@@ -0,0 +1,44 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/i18n"
+)
+
+// runPostAction tidies the target chat after a successful run: it deletes the messages the
+// task sent or clears the chat, after the configured delay
+func (e *TaskExecutor) runPostAction(ctx context.Context, task config.TaskConfig, sent []int, log zerolog.Logger) error {
+	if delay := time.Duration(task.PostAction.DelaySeconds) * time.Second; delay > 0 {
+		log.Debug().Dur("delay", delay).Str("post_action", task.PostAction.Action).Msg(i18n.T("wait_post_action"))
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	switch task.PostAction.Action {
+	case config.PostActionDeleteSent:
+		if len(sent) == 0 {
+			log.Debug().Msg(i18n.T("no_sent_messages"))
+			return nil
+		}
+		if err := e.client.DeleteMessagesInRun(ctx, task.Target, sent); err != nil {
+			return err
+		}
+		log.Debug().Ints("message_ids", sent).Msg(i18n.T("sent_messages_deleted"))
+	case config.PostActionClearHistory:
+		if err := e.client.ClearHistoryInRun(ctx, task.Target); err != nil {
+			return err
+		}
+		log.Debug().Msg(i18n.T("history_cleared"))
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Overflow policies applied when a task is submitted to a full queue
+const (
+	OverflowDrop       = "drop"        // Drop the new task (default)
+	OverflowBlock      = "block"       // Wait for a free slot, up to the block timeout, then drop the new task
+	OverflowDropOldest = "drop_oldest" // Drop the oldest queued task to make room
+	OverflowExpand     = "expand"      // Grow the queue beyond its size
+)
+
+// taskQueue is a bounded priority queue of task requests. Requests not triggered by the
+// schedule (run_on_start, manual runs) come first, then higher task priority, then FIFO.
+type taskQueue struct {
+	mu     sync.Mutex
+	items  requestHeap
+	seq    uint64
+	size   int
+	closed bool
+	wake   chan struct{} // Signals a worker that a request is queued
+	freed  chan struct{} // Signals a blocked submitter that a slot is free
+	done   chan struct{} // Closed by close
+}
+
+func newTaskQueue(size int) *taskQueue {
+	return &taskQueue{
+		size:  size,
+		wake:  make(chan struct{}, 1),
+		freed: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+// push adds a request, applying policy when the queue is full. It reports whether req was
+// queued and returns the request dropped to make room for it, if any. A zero timeout
+// blocks until ctx is done under OverflowBlock.
+func (q *taskQueue) push(ctx context.Context, req TaskRequest, policy string, timeout time.Duration) (bool, *TaskRequest) {
+	var deadline <-chan time.Time
+	if policy == OverflowBlock && timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return false, nil
+		}
+		var dropped *TaskRequest
+		if q.items.Len() >= q.size {
+			switch policy {
+			case OverflowExpand:
+			case OverflowDropOldest:
+				oldest := heap.Remove(&q.items, q.items.oldest()).(queuedRequest)
+				dropped = &oldest.req
+			case OverflowBlock:
+				q.mu.Unlock()
+				select {
+				case <-q.freed:
+					continue
+				case <-deadline:
+					return false, nil
+				case <-ctx.Done():
+					return false, nil
+				}
+			default:
+				q.mu.Unlock()
+				return false, nil
+			}
+		}
+		q.seq++
+		heap.Push(&q.items, queuedRequest{req: req, urgent: req.TriggerType != "scheduled", seq: q.seq})
+		if q.items.Len() < q.size {
+			signal(q.freed)
+		}
+		q.mu.Unlock()
+		signal(q.wake)
+		return true, dropped
+	}
+}
+
+// next waits for the most urgent request. Returns false once the queue is closed and
+// empty, or either context is done.
+func (q *taskQueue) next(ctx, stop context.Context) (TaskRequest, bool) {
+	for {
+		q.mu.Lock()
+		if q.items.Len() > 0 {
+			item := heap.Pop(&q.items).(queuedRequest)
+			remaining := q.items.Len()
+			q.mu.Unlock()
+			signal(q.freed)
+			if remaining > 0 {
+				signal(q.wake)
+			}
+			return item.req, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return TaskRequest{}, false
+		}
+		select {
+		case <-q.wake:
+		case <-q.done:
+		case <-ctx.Done():
+			return TaskRequest{}, false
+		case <-stop.Done():
+			return TaskRequest{}, false
+		}
+	}
+}
+
+// close stops accepting requests, queued ones can still be taken by next
+func (q *taskQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+}
+
+func (q *taskQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
+
+// signal does a non-blocking send on a wake-up channel
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+type queuedRequest struct {
+	req    TaskRequest
+	urgent bool
+	seq    uint64
+}
+
+// requestHeap implements heap.Interface ordered by urgency, priority and arrival
+type requestHeap []queuedRequest
+
+func (h requestHeap) Len() int { return len(h) }
+
+func (h requestHeap) Less(i, j int) bool {
+	if h[i].urgent != h[j].urgent {
+		return h[i].urgent
+	}
+	if pi, pj := h[i].req.Task.Priority, h[j].req.Task.Priority; pi != pj {
+		return pi > pj
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h requestHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *requestHeap) Push(x any) { *h = append(*h, x.(queuedRequest)) }
+
+func (h *requestHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// oldest returns the index of the earliest queued request
+func (h requestHeap) oldest() int {
+	idx := 0
+	for i := range h {
+		if h[i].seq < h[idx].seq {
+			idx = i
+		}
+	}
+	return idx
+}
@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"telegram-auto-checkin/internal/config"
+	tgclient "telegram-auto-checkin/pkg/client"
+
+	"github.com/rs/zerolog"
+)
+
+// Invocation carries everything a TaskHandler needs to run one task
+type Invocation struct {
+	Client   Client                // Telegram client facade for the task's account
+	Account  string                // Account name
+	Task     config.TaskConfig     // Task configuration (templates and secrets already resolved)
+	MediaDir string                // Directory for saved reply media, empty when disabled
+	Report   *tgclient.Report      // Reply and artifacts of the run, filled by the client or the handler
+	Reply    tgclient.ReplyOptions // Reply wait and history limit, the task's settings over the account's
+	Logger   zerolog.Logger        // Task logger, also mirrored to the main log by the client
+}
+
+// TaskHandler runs a task method. Handlers should return an error when the
+// check-in did not go through; the reply text and artifacts are collected by
+// the client through the report attached to ctx, handlers that do not talk to
+// Telegram can fill Invocation.Report themselves.
+type TaskHandler interface {
+	Run(ctx context.Context, inv Invocation) error
+}
+
+// HandlerFunc adapts a plain function to TaskHandler
+type HandlerFunc func(ctx context.Context, inv Invocation) error
+
+// Run calls f(ctx, inv)
+func (f HandlerFunc) Run(ctx context.Context, inv Invocation) error {
+	return f(ctx, inv)
+}
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string]TaskHandler)
+)
+
+// Register makes a task method available under name. It is meant to be called
+// from an init function; registering a nil handler or a duplicate name panics.
+func Register(name string, h TaskHandler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	if h == nil {
+		panic("executor: Register handler is nil")
+	}
+	if _, dup := handlers[name]; dup {
+		panic("executor: Register called twice for method " + name)
+	}
+	handlers[name] = h
+}
+
+// Lookup returns the handler registered for a task method
+func Lookup(name string) (TaskHandler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[name]
+	return h, ok
+}
+
+// Methods returns the sorted names of all registered task methods
+func Methods() []string {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runHandler dispatches a task to its registered handler
+func runHandler(ctx context.Context, inv Invocation) error {
+	h, ok := Lookup(inv.Task.Method)
+	if !ok {
+		return fmt.Errorf("unknown method %q", inv.Task.Method)
+	}
+	return h.Run(ctx, inv)
+}
@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"telegram-auto-checkin/internal/i18n"
+)
+
+const defaultScriptTimeout = 60 * time.Second
+
+func init() {
+	Register("script", HandlerFunc(runScript))
+}
+
+// scriptInput is the task context written to the script's stdin
+type scriptInput struct {
+	Account string `json:"account"`
+	Task    string `json:"task"`
+	Target  string `json:"target"`
+	Payload string `json:"payload"`
+}
+
+// runScript runs the task's script; exit code 0 means success and stdout becomes the reply
+func runScript(ctx context.Context, inv Invocation) error {
+	script := inv.Task.Script
+	if script.Command == "" {
+		return errors.New("script method requires script.command")
+	}
+	timeout := defaultScriptTimeout
+	if script.Timeout > 0 {
+		timeout = time.Duration(script.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	input := scriptInput{Account: inv.Account, Task: inv.Task.DisplayName(), Target: inv.Task.Target, Payload: inv.Task.Payload}
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, script.Command, script.Args...)
+	cmd.Dir = script.Dir
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(),
+		"CHECKIN_ACCOUNT="+input.Account,
+		"CHECKIN_TASK="+input.Task,
+		"CHECKIN_TARGET="+input.Target,
+		"CHECKIN_PAYLOAD="+input.Payload,
+	)
+	// Config keys are lowercased on load, environment variable names are conventionally upper case
+	for key, value := range script.Env {
+		cmd.Env = append(cmd.Env, strings.ToUpper(key)+"="+value)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	inv.Logger.Info().Str("command", script.Command).Strs("args", script.Args).Msg(i18n.T("script_running"))
+	err = cmd.Run()
+	reply := strings.TrimSpace(stdout.String())
+	if inv.Report != nil {
+		inv.Report.Reply = reply
+	}
+	if reply != "" {
+		inv.Logger.Info().Str("stdout", reply).Msg(i18n.T("script_output"))
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		inv.Logger.Warn().Str("stderr", msg).Msg(i18n.T("script_stderr"))
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("script timed out after %s", timeout)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("script exited with code %d: %s", exitErr.ExitCode(), lastLine(stderr.String()))
+		}
+		return fmt.Errorf("failed to run script: %w", err)
+	}
+	return nil
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
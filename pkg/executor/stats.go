@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	runningMu sync.Mutex
+	running   = make(map[*TaskExecutor]struct{})
+
+	openTaskLogs atomic.Int64
+)
+
+// QueueStats is a snapshot of a running executor's queue
+type QueueStats struct {
+	Account string `json:"account"`
+	Workers int    `json:"workers"`
+	Queued  int    `json:"queued"`
+	Dropped int64  `json:"dropped"`
+}
+
+// Stats returns the queue statistics of all started executors, sorted by account
+func Stats() []QueueStats {
+	runningMu.Lock()
+	stats := make([]QueueStats, 0, len(running))
+	for e := range running {
+		stats = append(stats, QueueStats{
+			Account: e.accountName,
+			Workers: e.workerCount,
+			Queued:  e.QueueLen(),
+			Dropped: e.Dropped(),
+		})
+	}
+	runningMu.Unlock()
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Account < stats[j].Account })
+	return stats
+}
+
+// Idle reports whether no started executor has a queued task or one being executed
+func Idle() bool {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	for e := range running {
+		if e.QueueLen() > 0 || e.busy.Load() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// OpenTaskLogs returns the number of task log files currently open
+func OpenTaskLogs() int64 {
+	return openTaskLogs.Load()
+}
+
+func trackRunning(e *TaskExecutor, started bool) {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	if started {
+		running[e] = struct{}{}
+	} else {
+		delete(running, e)
+	}
+}
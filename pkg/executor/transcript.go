@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"telegram-auto-checkin/internal/history"
+)
+
+// transcriptFile is the content of a transcript artifact
+type transcriptFile struct {
+	Account    string                    `json:"account"`
+	Task       string                    `json:"task"`
+	Target     string                    `json:"target"`
+	RequestID  string                    `json:"request_id"`
+	StartedAt  time.Time                 `json:"started_at"`
+	Transcript []history.TranscriptEntry `json:"transcript"`
+}
+
+// writeTranscript saves the exchange of a run as <dir>/<start time>_<request ID>_transcript.json
+func writeTranscript(dir string, t transcriptFile) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	content, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s_transcript.json", t.StartedAt.Format("20060102_150405"), t.RequestID))
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,41 @@
+package executor
+
+import "time"
+
+// webhookData is the template data and default JSON body of task webhooks
+type webhookData struct {
+	Account    string             `json:"account"`
+	Task       string             `json:"task"`
+	Target     string             `json:"target"`
+	Method     string             `json:"method"`
+	Trigger    string             `json:"trigger"`
+	RequestID  string             `json:"request_id"`
+	Success    bool               `json:"success"`
+	Error      string             `json:"error,omitempty"`
+	ErrorClass string             `json:"error_class,omitempty"`
+	Reply      string             `json:"reply,omitempty"`
+	Extracted  map[string]float64 `json:"extracted,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	DurationMs int64              `json:"duration_ms"`
+}
+
+func newWebhookData(r TaskResult) webhookData {
+	data := webhookData{
+		Account:    r.Account,
+		Task:       r.Task,
+		Target:     r.Target,
+		Method:     r.Method,
+		Trigger:    r.Trigger,
+		RequestID:  r.RequestID,
+		Success:    r.Success(),
+		ErrorClass: r.ErrorClass,
+		Reply:      r.Reply,
+		Extracted:  r.Extracted,
+		StartedAt:  r.StartedAt,
+		DurationMs: r.Duration.Milliseconds(),
+	}
+	if r.Err != nil {
+		data.Error = r.Err.Error()
+	}
+	return data
+}
@@ -0,0 +1,37 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/pkg/client"
+)
+
+// CheckSession connects the account like a task run would and fails with errs.ErrAuthRequired
+// when its session is not logged in. It never prompts for a login.
+func CheckSession(ctx context.Context, cfg *config.Config, acc config.AccountConfig, log zerolog.Logger) error {
+	appID, appHash, err := resolveAppConfig(cfg, acc)
+	if err != nil {
+		return err
+	}
+	conn := connectOptions(cfg, acc)
+	conn.NonInteractive = true
+	c, err := client.NewClient(appID, appHash, acc.SessionName()+".session", conn, log)
+	if err != nil {
+		return err
+	}
+	return c.Run(ctx, func(ctx context.Context) error {
+		authorized, err := c.AuthorizedInRun(ctx)
+		if err != nil {
+			return err
+		}
+		if !authorized {
+			return fmt.Errorf("%w: session is not logged in", errs.ErrAuthRequired)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"telegram-auto-checkin/pkg/executor"
+)
+
+// drainPollInterval is how often Drain checks whether the executors became idle
+const drainPollInterval = time.Second
+
+// Daemon is the schedule and the account sessions started by StartTasks
+type Daemon struct {
+	scheduler *Scheduler
+	sessions  sync.WaitGroup // Persistent account sessions, done once their session stopped
+	runs      sync.WaitGroup // On-demand runs started outside the scheduler
+}
+
+// Drain stops firing scheduled tasks and waits until the running jobs, on-demand runs and
+// every queued or executing task finished, or ctx is done. The sessions stay connected
+// until the context passed to StartTasks is cancelled.
+func (d *Daemon) Drain(ctx context.Context) error {
+	// Stop returns a context done once the running jobs returned, on-demand runs included
+	select {
+	case <-d.scheduler.cron.Stop().Done():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := waitGroup(ctx, &d.runs); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for !executor.Idle() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Wait waits until the account sessions closed after the context passed to StartTasks was
+// cancelled, or ctx is done
+func (d *Daemon) Wait(ctx context.Context) error {
+	return waitGroup(ctx, &d.sessions)
+}
+
+// waitGroup waits for wg or until ctx is done
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
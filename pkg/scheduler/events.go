@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/events"
+	"telegram-auto-checkin/internal/history"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/notify"
+)
+
+// recordHistory returns a subscriber appending finished tasks to the run history store
+func recordHistory(store *history.Store, log zerolog.Logger) func(events.Event) {
+	return func(ev events.Event) {
+		res, ok := ev.(events.TaskFinished)
+		if !ok {
+			return
+		}
+		if err := store.Append(historyRecord(res)); err != nil {
+			log.Warn().Err(err).Str("account", res.Account).Msg(i18n.T("history_write_failed"))
+		}
+	}
+}
+
+// notifyEvents returns a subscriber sending events that need attention to the notification
+// channels: revoked sessions, flood waits and failed tasks
+func notifyEvents(ctx context.Context, notifier notify.Multi, log zerolog.Logger) func(events.Event) {
+	return func(ev events.Event) {
+		var msg notify.Message
+		switch ev := ev.(type) {
+		case events.AuthRequired:
+			msg = notify.Message{
+				Title:    i18n.T("auth_required_title"),
+				Text:     i18n.T("auth_required_text", map[string]any{"Account": ev.Account, "Error": ev.Err}),
+				Priority: notify.PriorityHigh,
+			}
+		case events.FloodWait:
+			msg = notify.Message{
+				Title: i18n.T("flood_wait_title"),
+				Text:  i18n.T("flood_wait_text", map[string]any{"Account": ev.Account, "Task": ev.Task, "Wait": ev.Wait.Round(time.Second)}),
+			}
+		case events.TaskFinished:
+			if !notifyFailure(ev) {
+				return
+			}
+			msg = notify.Message{
+				Title: i18n.T("task_failed_title"),
+				Text:  i18n.T("task_failed_text", map[string]any{"Account": ev.Account, "Task": ev.Task, "Error": ev.Err}),
+			}
+		default:
+			return
+		}
+		go notifySession(ctx, notifier, log, msg)
+	}
+}
+
+// notifyFailure reports whether a finished task is a failure worth a notification. Revoked
+// sessions and flood waits are sent by their own events, cancelled runs are shutdowns.
+func notifyFailure(ev events.TaskFinished) bool {
+	err := errs.Classify(ev.Err)
+	return err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, errs.ErrAuthRequired) && !errors.Is(err, errs.ErrFloodWait)
+}
+
+// publishAuthRequired publishes the AuthRequired event when err means the account has to log
+// in again, and reports whether it did
+func publishAuthRequired(bus *events.Bus, accountLabel string, err error) bool {
+	if !errors.Is(errs.Classify(err), errs.ErrAuthRequired) {
+		return false
+	}
+	bus.Publish(events.AuthRequired{Account: accountLabel, Err: err})
+	return true
+}
+
+// forwardEvents returns a bus publishing everything to parent as well, so a subscriber can
+// watch a single account while the subscribers of parent see all of them
+func forwardEvents(parent *events.Bus) *events.Bus {
+	bus := events.NewBus()
+	bus.Subscribe(parent.Publish)
+	return bus
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/crash"
+	"telegram-auto-checkin/internal/events"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/notify"
+	"telegram-auto-checkin/internal/state"
+	"telegram-auto-checkin/pkg/executor"
+)
+
+// Connection modes of an account in daemon mode
+const (
+	ConnectionPersistent = "persistent" // One session kept open between tasks (default)
+	ConnectionOnDemand   = "on_demand"  // Connect when a task fires and disconnect after it
+)
+
+// resolveConnectionMode returns the connection mode of an account, the account setting overrides the global one
+func resolveConnectionMode(cfg *config.Config, acc config.AccountConfig) string {
+	if acc.ConnectionMode != "" {
+		return acc.ConnectionMode
+	}
+	if cfg.ConnectionMode != "" {
+		return cfg.ConnectionMode
+	}
+	return ConnectionPersistent
+}
+
+// onDemandRunner runs the tasks of an on_demand account, opening a session per run.
+// Runs are serialized since two sessions must not share the session file.
+type onDemandRunner struct {
+	mu           sync.Mutex
+	cfg          *config.Config
+	acc          config.AccountConfig
+	log          zerolog.Logger
+	accountLabel string
+	bus          *events.Bus
+	state        *state.State
+	notifier     notify.Multi
+	stagger      time.Duration   // Shift of the account's schedules, see StaggerOffset
+	runs         *sync.WaitGroup // Runs started outside the scheduler, waited for by Daemon.Drain
+	connect      func() (taskClient, error)
+}
+
+// schedule adds the account's scheduled tasks to s and starts the run_on_start tasks
+func (r *onDemandRunner) schedule(ctx context.Context, s *Scheduler, runOnStart bool) error {
+	if runOnStart {
+		var tasks []config.TaskConfig
+		for _, task := range r.acc.Tasks {
+			if state.TaskActive(r.state, r.acc, task) && task.RunOnStart && task.IsRoot(r.acc.Tasks) {
+				tasks = append(tasks, task)
+			}
+		}
+		r.runs.Add(1)
+		go func() {
+			defer r.runs.Done()
+			r.run(ctx, tasks, "run_on_start")
+		}()
+	}
+
+	// Disabled tasks are scheduled too, so they can be enabled at runtime
+	for _, task := range r.acc.Tasks {
+		if task.Schedule == "" && task.RunAt == "" {
+			continue
+		}
+		if !task.IsRoot(r.acc.Tasks) {
+			r.log.Warn().Str("task", task.DisplayName()).Msg(i18n.T("depends_on_schedule_ignored"))
+			continue
+		}
+		t := task // copy
+		err := addTaskSchedule(s, t, r.stagger, func() {
+			if ctx.Err() != nil {
+				return
+			}
+			if !state.TaskActive(r.state, r.acc, t) {
+				r.log.Debug().Str("task", t.DisplayName()).Msg(i18n.T("task_disabled_skipped"))
+				return
+			}
+			if skipScheduled(t, r.log) {
+				return
+			}
+			r.run(ctx, []config.TaskConfig{t}, "scheduled")
+		})
+		if err != nil {
+			return fmt.Errorf("account %s: invalid schedule %q: %w", r.accountLabel, t.Schedule, err)
+		}
+		r.log.Debug().Str("schedule", t.Schedule).Int("offset_seconds", t.ScheduleOffset).Dur("stagger", r.stagger).Str("task", t.DisplayName()).Str("target", t.Target).Msg(i18n.T("task_scheduled_on_demand"))
+	}
+	return nil
+}
+
+// run connects, executes tasks (and their dependents) and disconnects
+func (r *onDemandRunner) run(ctx context.Context, tasks []config.TaskConfig, trigger string) {
+	if len(tasks) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, err := r.connect()
+	if err != nil {
+		r.log.Error().Err(err).Msg(i18n.T("client_creation_failed"))
+		r.warnLost(tasks, trigger, "the client could not be created")
+		return
+	}
+	r.log.Debug().Int("task_count", len(tasks)).Msg(i18n.T("on_demand_connecting"))
+	err = runGuarded(ctx, client, r.accountLabel, func(ctx context.Context) error {
+		if err := client.AuthInRun(ctx, r.acc.Phone, r.acc.Password); err != nil {
+			publishAuthRequired(r.bus, r.accountLabel, err)
+			return fmt.Errorf("account authentication failed: %w", err)
+		}
+		exec := newAccountExecutor(client, r.cfg, r.acc, r.log, r.accountLabel, r.bus)
+		exec.SetDependents(r.acc.Tasks, func(t config.TaskConfig) bool { return state.TaskActive(r.state, r.acc, t) })
+		exec.SetSendQuota(sendQuota(r.cfg, r.state, r.accountLabel, r.log))
+		exec.SetFinishHandler(func(req executor.TaskRequest) {
+			if req.TriggerType == "scheduled" {
+				disableOneShot(r.state, r.acc, req.Task, r.log)
+			}
+		})
+		exec.SetCrashHandler(notifyCrash(ctx, r.notifier, r.accountLabel, r.log))
+		exec.Start(ctx)
+		defer exec.Stop()
+		for _, task := range tasks {
+			exec.SubmitTaskBlocking(ctx, task, r.log, trigger)
+		}
+		exec.Drain()
+		return nil
+	})
+	var report crash.Report
+	if errors.As(err, &report) {
+		writeCrash(crash.Dir(r.cfg.Log.Dir), report, r.log)
+		notifyCrash(ctx, r.notifier, r.accountLabel, r.log)(report)
+	}
+	if err != nil && ctx.Err() == nil {
+		r.log.Error().Err(err).Msg(i18n.T("on_demand_failed"))
+		r.warnLost(tasks, trigger, "the on-demand run failed")
+		return
+	}
+	r.log.Debug().Msg(i18n.T("on_demand_disconnected"))
+}
+
+// warnLost warns about the one-time tasks among tasks that are still active, so did not run
+func (r *onDemandRunner) warnLost(tasks []config.TaskConfig, trigger, reason string) {
+	for _, t := range tasks {
+		if state.TaskActive(r.state, r.acc, t) {
+			warnOneShotLost(t, trigger, reason, r.log)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/pkg/executor"
+)
+
+// onlineRefreshInterval keeps an online status alive, Telegram expires it after about five minutes
+const onlineRefreshInterval = 4 * time.Minute
+
+// resolvePresence returns the presence mode of an account, the account setting overrides the global one
+func resolvePresence(cfg *config.Config, acc config.AccountConfig) string {
+	if acc.Presence != "" {
+		return acc.Presence
+	}
+	if cfg.Presence != "" {
+		return cfg.Presence
+	}
+	return executor.PresenceAuto
+}
+
+// applyPresence sets the online status at the start of a persistent session. In online mode
+// the status is refreshed in the background until ctx is done.
+func applyPresence(ctx context.Context, client taskClient, mode string, log zerolog.Logger) {
+	switch mode {
+	case executor.PresenceOffline:
+		if err := client.SetOnlineInRun(ctx, false); err != nil {
+			log.Warn().Err(err).Msg(i18n.T("set_offline_failed"))
+		}
+	case executor.PresenceOnline:
+		go keepOnline(ctx, client, log)
+	case executor.PresenceAuto:
+	default:
+		log.Warn().Str("presence", mode).Msg(i18n.T("presence_unknown"))
+	}
+}
+
+// keepOnline marks the account online and refreshes the status until ctx is done
+func keepOnline(ctx context.Context, client taskClient, log zerolog.Logger) {
+	ticker := time.NewTicker(onlineRefreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := client.SetOnlineInRun(ctx, true); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg(i18n.T("presence_failed"))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/state"
+	"telegram-auto-checkin/pkg/executor"
+)
+
+// stateQuota counts the messages per target in the state file, shared by all accounts
+// unless send_quota.per_account is set
+type stateQuota struct {
+	st      *state.State
+	cfg     config.SendQuotaConfig
+	account string // "" when the count is shared by all accounts
+	log     zerolog.Logger
+}
+
+// sendQuota returns the send quota of an account, nil when send_quota caps nothing. The
+// counts live in the state file, without one the quota cannot be enforced.
+func sendQuota(cfg *config.Config, st *state.State, accountLabel string, log zerolog.Logger) executor.SendQuota {
+	if cfg.SendQuota.Daily == 0 && len(cfg.SendQuota.Targets) == 0 {
+		return nil
+	}
+	if st == nil {
+		log.Warn().Msg(i18n.T("send_quota_no_state"))
+		return nil
+	}
+	q := &stateQuota{st: st, cfg: cfg.SendQuota, log: log}
+	if cfg.SendQuota.PerAccount {
+		q.account = accountLabel
+	}
+	return q
+}
+
+func (q *stateQuota) Reserve(target string, n int) error {
+	limit := q.cfg.Limit(target)
+	if limit == 0 {
+		return nil
+	}
+	sent, ok, err := q.st.ReserveSent(q.account, config.TargetName(target), n, limit, time.Now())
+	if !ok {
+		return errs.Wrap(errs.ErrQuotaExceeded, fmt.Errorf("%d of %d messages to %s sent today, the task may send %d", sent, limit, target, n))
+	}
+	if err != nil {
+		q.log.Warn().Err(err).Str("target", target).Msg(i18n.T("send_count_save_failed"))
+	}
+	return nil
+}
+
+func (q *stateQuota) Add(target string, extra int) {
+	if q.cfg.Limit(target) == 0 {
+		return
+	}
+	if err := q.st.AddSent(q.account, config.TargetName(target), extra, time.Now()); err != nil {
+		q.log.Warn().Err(err).Str("target", target).Msg(i18n.T("send_count_save_failed"))
+	}
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/state"
+)
+
+func TestSendQuota(t *testing.T) {
+	// reserve is a Reserve call of an account, add returns extra messages after it
+	type reserve struct {
+		account string
+		target  string
+		n       int
+		add     int
+		wantErr bool
+	}
+	tests := []struct {
+		name  string
+		quota config.SendQuotaConfig
+		calls []reserve
+	}{
+		{
+			name:  "daily cap",
+			quota: config.SendQuotaConfig{Daily: 2},
+			calls: []reserve{
+				{account: "alice", target: "@bot", n: 1},
+				{account: "alice", target: "@bot", n: 1},
+				{account: "alice", target: "@bot", n: 1, wantErr: true},
+				{account: "alice", target: "@otherbot", n: 1},
+			},
+		},
+		{
+			name:  "task sending several messages",
+			quota: config.SendQuotaConfig{Daily: 3},
+			calls: []reserve{
+				{account: "alice", target: "@bot", n: 2},
+				{account: "alice", target: "@bot", n: 2, wantErr: true},
+				{account: "alice", target: "@bot", n: 1},
+			},
+		},
+		{
+			name:  "unsent messages are returned",
+			quota: config.SendQuotaConfig{Daily: 2},
+			calls: []reserve{
+				{account: "alice", target: "@bot", n: 2, add: -1},
+				{account: "alice", target: "@bot", n: 1},
+				{account: "alice", target: "@bot", n: 1, wantErr: true},
+			},
+		},
+		{
+			name:  "target cap replaces daily",
+			quota: config.SendQuotaConfig{Daily: 1, Targets: map[string]int{"@bot": 3, "@free": 0}},
+			calls: []reserve{
+				{account: "alice", target: "bot", n: 3},
+				{account: "alice", target: "@bot", n: 1, wantErr: true},
+				{account: "alice", target: "@free", n: 100},
+			},
+		},
+		{
+			name:  "accounts share the count",
+			quota: config.SendQuotaConfig{Daily: 1},
+			calls: []reserve{
+				{account: "alice", target: "@bot", n: 1},
+				{account: "bob", target: "@bot", n: 1, wantErr: true},
+			},
+		},
+		{
+			name:  "per account",
+			quota: config.SendQuotaConfig{Daily: 1, PerAccount: true},
+			calls: []reserve{
+				{account: "alice", target: "@bot", n: 1},
+				{account: "bob", target: "@bot", n: 1},
+				{account: "bob", target: "@bot", n: 1, wantErr: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &config.Config{SendQuota: tt.quota}
+			for i, c := range tt.calls {
+				q := sendQuota(cfg, st, c.account, zerolog.Nop())
+				err := q.Reserve(c.target, c.n)
+				if got := err != nil; got != c.wantErr {
+					t.Fatalf("call %d: got error %v, want error %v", i, err, c.wantErr)
+				}
+				if err != nil && !errors.Is(err, errs.ErrQuotaExceeded) {
+					t.Errorf("call %d: got error %v, want quota exceeded", i, err)
+				}
+				if c.add != 0 {
+					q.Add(c.target, c.add)
+				}
+			}
+		})
+	}
+
+	if q := sendQuota(&config.Config{}, nil, "alice", zerolog.Nop()); q != nil {
+		t.Error("got a quota without caps, want nil")
+	}
+	if q := sendQuota(&config.Config{SendQuota: config.SendQuotaConfig{Daily: 1}}, nil, "alice", zerolog.Nop()); q != nil {
+		t.Error("got a quota without a state file, want nil")
+	}
+}
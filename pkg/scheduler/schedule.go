@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// parseSchedule parses a cron expression or descriptor, plus the anchored interval
+// "@every 8h starting 06:00" whose runs stay aligned to the wall clock across restarts
+func parseSchedule(spec string) (cron.Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 4 && fields[0] == "@every" && fields[2] == "starting" {
+		every, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q: %w", fields[1], err)
+		}
+		if every < time.Minute {
+			return nil, fmt.Errorf("interval %s is shorter than a minute", every)
+		}
+		// Runs are re-anchored every day, an interval that does not fit a whole number of
+		// times into a day would leave a short gap before the anchor
+		if (24*time.Hour)%every != 0 {
+			return nil, fmt.Errorf("interval %s does not divide a day evenly, use one like 6h, 8h or 12h", every)
+		}
+		anchor, err := time.Parse("15:04", fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid start time %q, expected HH:MM", fields[3])
+		}
+		return anchoredSchedule{every: every, hour: anchor.Hour(), minute: anchor.Minute()}, nil
+	}
+	return cron.ParseStandard(spec)
+}
+
+// anchoredSchedule activates every interval counted from a time of day, e.g. 06:00, 14:00 and 22:00
+// for 8h from 06:00, instead of counting from the moment the process started
+type anchoredSchedule struct {
+	every        time.Duration
+	hour, minute int
+}
+
+func (a anchoredSchedule) Next(t time.Time) time.Time {
+	anchor := time.Date(t.Year(), t.Month(), t.Day(), a.hour, a.minute, 0, 0, t.Location())
+	// Truncating division lands on the activation at or before t after the anchor,
+	// and at or after t before it
+	next := anchor.Add(t.Sub(anchor) / a.every * a.every)
+	for !next.After(t) {
+		next = next.Add(a.every)
+	}
+	return next
+}
@@ -0,0 +1,978 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/crash"
+	"telegram-auto-checkin/internal/diag"
+	"telegram-auto-checkin/internal/digest"
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/events"
+	"telegram-auto-checkin/internal/history"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/login"
+	"telegram-auto-checkin/internal/notify"
+	"telegram-auto-checkin/internal/state"
+	"telegram-auto-checkin/pkg/client"
+	"telegram-auto-checkin/pkg/executor"
+)
+
+type Scheduler struct {
+	cron    *cron.Cron
+	onPanic func(crash.Report) // Handles a panic recovered in a job, see SetPanicHandler
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		cron: cron.New(),
+	}
+}
+
+func (s *Scheduler) AddTask(schedule string, task func()) error {
+	sched, err := parseSchedule(schedule)
+	if err != nil {
+		return err
+	}
+	s.cron.Schedule(sched, s.job("", task))
+	return nil
+}
+
+// SetPanicHandler registers a callback invoked for every panic recovered in a job, the
+// scheduler and the other jobs keep running. Must be called before Start.
+func (s *Scheduler) SetPanicHandler(fn func(crash.Report)) {
+	s.onPanic = fn
+}
+
+// job wraps fn so a panic in it is recovered instead of crashing the daemon, task names
+// the task the job belongs to in the crash report
+func (s *Scheduler) job(task string, fn func()) cron.Job {
+	return cron.FuncJob(func() {
+		defer func() {
+			if r := recover(); r != nil && s.onPanic != nil {
+				s.onPanic(crash.Capture("schedule", "", task, r))
+			}
+		}()
+		fn()
+	})
+}
+
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+func (s *Scheduler) Stop() {
+	s.cron.Stop()
+}
+
+// onceSchedule activates a single time, a time in the past never fires
+type onceSchedule struct {
+	at time.Time
+}
+
+func (o onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(o.at) {
+		return o.at
+	}
+	return time.Time{}
+}
+
+// offsetSchedule fires offset after every activation of schedule
+type offsetSchedule struct {
+	schedule cron.Schedule
+	offset   time.Duration
+}
+
+func (o offsetSchedule) Next(t time.Time) time.Time {
+	next := o.schedule.Next(t.Add(-o.offset))
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(o.offset)
+}
+
+// TaskSchedule returns when the scheduled runs of a task fire: once at its run_at time, or on its
+// cron schedule shifted by the account stagger (see StaggerOffset) and the task's schedule_offset
+func TaskSchedule(task config.TaskConfig, stagger time.Duration) (cron.Schedule, error) {
+	if task.RunAt != "" {
+		at, err := config.ParseRunAt(task.RunAt)
+		if err != nil {
+			return nil, err
+		}
+		return onceSchedule{at: at}, nil
+	}
+	sched, err := parseSchedule(task.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if offset := stagger + time.Duration(task.ScheduleOffset)*time.Second; offset != 0 {
+		return offsetSchedule{schedule: sched, offset: offset}, nil
+	}
+	return sched, nil
+}
+
+// addTaskSchedule adds fn to s on the schedule of task, see TaskSchedule
+func addTaskSchedule(s *Scheduler, task config.TaskConfig, stagger time.Duration, fn func()) error {
+	sched, err := TaskSchedule(task, stagger)
+	if err != nil {
+		return err
+	}
+	s.cron.Schedule(sched, s.job(task.DisplayName(), fn))
+	return nil
+}
+
+// disableOneShot persists a disable override for a run_at task once its scheduled run has
+// finished, so it does not run again, e.g. after a restart
+func disableOneShot(st *state.State, acc config.AccountConfig, task config.TaskConfig, log zerolog.Logger) {
+	if task.RunAt == "" || st == nil {
+		return
+	}
+	disabled := false
+	if err := st.SetTask(acc.Key(), task.DisplayName(), &disabled); err != nil {
+		log.Warn().Err(err).Str("task", task.DisplayName()).Msg(i18n.T("one_shot_disable_failed"))
+		return
+	}
+	log.Info().Str("task", task.DisplayName()).Msg(i18n.T("one_shot_disabled"))
+}
+
+// warnOneShotLost logs that the scheduled run of a run_at task will not happen, so the lost
+// one-time trigger is not silent. Other tasks run again on their next schedule.
+func warnOneShotLost(task config.TaskConfig, trigger, reason string, log zerolog.Logger) {
+	if task.RunAt == "" || trigger != "scheduled" {
+		return
+	}
+	log.Warn().Str("task", task.DisplayName()).Str("run_at", task.RunAt).Str("reason", reason).Msg(i18n.T("one_shot_lost"))
+}
+
+// skipScheduled reports whether the skip rules of task suppress a scheduled run now
+func skipScheduled(task config.TaskConfig, log zerolog.Logger) bool {
+	skip, rule, err := task.Skip.Skips(time.Now())
+	if err != nil {
+		log.Warn().Err(err).Str("task", task.DisplayName()).Msg(i18n.T("skip_rules_failed"))
+		return false
+	}
+	if skip {
+		log.Info().Str("task", task.DisplayName()).Str("rule", rule).Msg(i18n.T("skip_rule_matched"))
+	}
+	return skip
+}
+
+// StaggerOffset spreads the accounts evenly over the stagger_seconds window, so accounts sharing
+// a cron time do not all connect at once through the same proxy. The first account is not shifted.
+func StaggerOffset(cfg *config.Config, index int) time.Duration {
+	if cfg.StaggerSeconds <= 0 || len(cfg.Accounts) < 2 {
+		return 0
+	}
+	window := time.Duration(cfg.StaggerSeconds) * time.Second
+	return window * time.Duration(index) / time.Duration(len(cfg.Accounts))
+}
+
+type taskClient interface {
+	Auth(ctx context.Context, phone, password string) error
+	Run(ctx context.Context, fn func(ctx context.Context) error) error
+	AuthInRun(ctx context.Context, phone, password string) error
+	CheckInMessage(ctx context.Context, target string, message string, opts client.MessageOptions, taskLogger zerolog.Logger) (client.Result, error)
+	CheckInButton(ctx context.Context, target string, buttonText string, opts client.ButtonOptions, taskLogger zerolog.Logger) (client.Result, error)
+	ReactInRunWithLogger(ctx context.Context, target string, emoji string, selector string, taskLogger zerolog.Logger) error
+	VoteInRunWithLogger(ctx context.Context, target string, option string, taskLogger zerolog.Logger) error
+	SendDiceInRunWithLogger(ctx context.Context, target string, emoji string, taskLogger zerolog.Logger) error
+	SendMediaInRunWithLogger(ctx context.Context, target string, media client.MediaOptions, caption string, format string, taskLogger zerolog.Logger) error
+	SendLocationInRunWithLogger(ctx context.Context, target string, location client.LocationOptions, taskLogger zerolog.Logger) error
+	ForwardInRunWithLogger(ctx context.Context, target string, from string, selector string, taskLogger zerolog.Logger) error
+	ScheduleMessageInRunWithLogger(ctx context.Context, target string, message string, format string, at []time.Time, taskLogger zerolog.Logger) error
+	MarkReadInRun(ctx context.Context, target string) error
+	DeleteMessagesInRun(ctx context.Context, target string, ids []int) error
+	ClearHistoryInRun(ctx context.Context, target string) error
+	SetOnlineInRun(ctx context.Context, online bool) error
+	AuthorizationsInRun(ctx context.Context) ([]client.Authorization, error)
+}
+
+type clientFactory func(appID int, appHash string, sessionName string, conn client.ConnectOptions, log zerolog.Logger) (taskClient, error)
+
+func formatAccountLabel(acc config.AccountConfig, sessionName string) string {
+	if acc.Name != "" && acc.Phone != "" {
+		return fmt.Sprintf("%s(%s)", acc.Name, acc.Phone)
+	}
+	if acc.Name != "" {
+		return acc.Name
+	}
+	if acc.Phone != "" {
+		return acc.Phone
+	}
+	if sessionName != "" {
+		return sessionName
+	}
+	return "unknown_account"
+}
+
+// ErrTasksFailed is wrapped into the RunTasksOnce error when executed tasks reported failures
+var ErrTasksFailed = errors.New("some tasks failed")
+
+// OnceOptions controls a single RunTasksOnce pass
+type OnceOptions struct {
+	// OnResult is invoked for every executed task, possibly from several goroutines
+	OnResult func(executor.TaskResult)
+	// State holds runtime enable/disable toggles, nil uses the config only
+	State *state.State
+}
+
+func RunTasksOnce(ctx context.Context, cfg *config.Config, log zerolog.Logger, opts OnceOptions) error {
+	factory := func(appID int, appHash string, sessionFile string, conn client.ConnectOptions, log zerolog.Logger) (taskClient, error) {
+		return client.NewClient(appID, appHash, sessionFile, conn, log)
+	}
+	return runTasksOnce(ctx, cfg, log, factory, opts)
+}
+
+func runTasksOnce(ctx context.Context, cfg *config.Config, log zerolog.Logger, factory clientFactory, opts OnceOptions) error {
+	// Account-level parallelism, default: one account at a time
+	accountConcurrency := cfg.AccountConcurrency
+	if accountConcurrency <= 0 {
+		accountConcurrency = 1
+	}
+
+	// Overall worker cap shared by all concurrently running accounts
+	var workerLimiter chan struct{}
+	if cfg.MaxWorkers > 0 {
+		workerLimiter = make(chan struct{}, cfg.MaxWorkers)
+	}
+
+	var (
+		mu      sync.Mutex
+		allErrs []error
+		wg      sync.WaitGroup
+	)
+	accountSlots := make(chan struct{}, accountConcurrency)
+	bus := events.NewBus()
+	bus.Subscribe(diag.CountEvent)
+	if store := openHistory(cfg, log); store != nil {
+		bus.Subscribe(recordHistory(store, log))
+	}
+	if opts.State != nil {
+		bus.Subscribe(trackStreaks(opts.State, streakMinDays(cfg.Streaks), log, nil))
+	}
+	if opts.OnResult != nil {
+		bus.Subscribe(func(ev events.Event) {
+			if res, ok := ev.(events.TaskFinished); ok {
+				opts.OnResult(res)
+			}
+		})
+	}
+
+	for _, acc := range cfg.Accounts {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case accountSlots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(acc config.AccountConfig) {
+			defer wg.Done()
+			defer func() { <-accountSlots }()
+
+			if errs := runAccountOnce(ctx, cfg, acc, log, factory, opts, workerLimiter, bus); len(errs) > 0 {
+				mu.Lock()
+				allErrs = append(allErrs, errs...)
+				mu.Unlock()
+			}
+		}(acc)
+	}
+	wg.Wait()
+
+	return errors.Join(allErrs...)
+}
+
+// runAccountOnce runs all enabled tasks of one account within a single client session
+func runAccountOnce(ctx context.Context, cfg *config.Config, acc config.AccountConfig, log zerolog.Logger, factory clientFactory, opts OnceOptions, workerLimiter chan struct{}, bus *events.Bus) []error {
+	var allErrs []error
+
+	sessionName := acc.SessionName()
+
+	// Session file name
+	sessionFile := sessionName + ".session"
+
+	accountLabel := formatAccountLabel(acc, sessionName)
+	accLog := log.With().Str("account", accountLabel).Str("session", sessionName).Logger()
+
+	// Count enabled tasks
+	enabledTaskCount := 0
+	for _, task := range acc.Tasks {
+		if state.TaskActive(opts.State, acc, task) {
+			enabledTaskCount++
+		}
+	}
+
+	if enabledTaskCount == 0 {
+		accLog.Info().Msg(i18n.T("no_enabled_tasks"))
+		return nil
+	}
+
+	accLog.Info().Int("task_count", enabledTaskCount).Msg(i18n.T("start_tasks"))
+	appID, appHash, err := resolveAppConfig(cfg, acc)
+	if err != nil {
+		accLog.Error().Err(err).Msg(i18n.T("account_config_incomplete"))
+		return []error{err}
+	}
+
+	client, err := factory(appID, appHash, sessionFile, connectOptions(cfg, acc), accLog)
+	if err != nil {
+		accLog.Error().Err(err).Msg(i18n.T("client_creation_failed"))
+		return []error{err}
+	}
+
+	// Execute all tasks within long-running Run session
+	err = client.Run(ctx, func(ctx context.Context) error {
+		if err := client.AuthInRun(ctx, acc.Phone, acc.Password); err != nil {
+			accLog.Error().Err(err).Msg(i18n.T("auth_failed"))
+			publishAuthRequired(bus, accountLabel, err)
+			return err
+		}
+
+		// Create task executor
+		var resultMu sync.Mutex
+		failedCount := 0
+		accBus := forwardEvents(bus)
+		accBus.Subscribe(func(ev events.Event) {
+			if res, ok := ev.(events.TaskFinished); ok && !res.Success() {
+				resultMu.Lock()
+				failedCount++
+				resultMu.Unlock()
+			}
+		})
+		exec := newAccountExecutor(client, cfg, acc, accLog, accountLabel, accBus)
+		exec.SetWorkerLimiter(workerLimiter)
+		exec.SetDependents(acc.Tasks, func(t config.TaskConfig) bool { return state.TaskActive(opts.State, acc, t) })
+		exec.SetSendQuota(sendQuota(cfg, opts.State, accountLabel, accLog))
+		exec.Start(ctx)
+		defer exec.Stop()
+
+		// Submit all tasks to executor
+		taskErrors := make([]error, 0)
+		for _, task := range acc.Tasks {
+			// Dependent tasks are run by the executor once their dependencies succeed
+			if !state.TaskActive(opts.State, acc, task) || !task.IsRoot(acc.Tasks) {
+				continue
+			}
+
+			// Block and submit task
+			if !exec.SubmitTaskBlocking(ctx, task, accLog, "once") {
+				taskErrors = append(taskErrors, fmt.Errorf("failed to submit task: %s", task.Name))
+			}
+		}
+
+		// Wait for every submitted task to report its result
+		exec.Drain()
+		if failedCount > 0 {
+			taskErrors = append(taskErrors, fmt.Errorf("%w: %d of %d", ErrTasksFailed, failedCount, enabledTaskCount))
+		}
+
+		if len(taskErrors) > 0 {
+			allErrs = append(allErrs, taskErrors...)
+			accLog.Warn().Int("failed_count", len(taskErrors)).Int("total_count", enabledTaskCount).Msg(i18n.T("some_tasks_failed"))
+		} else {
+			accLog.Info().Int("total_count", enabledTaskCount).Msg(i18n.T("all_tasks_completed"))
+		}
+
+		return nil
+	})
+	if err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	return allErrs
+}
+
+// RunTasks connects every account with runnable tasks and schedules them.
+// Runtime toggles in st are checked whenever a task fires, st may be nil.
+func RunTasks(ctx context.Context, cfg *config.Config, log zerolog.Logger, st *state.State) error {
+	_, err := StartTasks(ctx, cfg, log, st)
+	return err
+}
+
+// StartTasks is RunTasks returning the running daemon, so it can be drained before it is stopped
+func StartTasks(ctx context.Context, cfg *config.Config, log zerolog.Logger, st *state.State) (*Daemon, error) {
+	d := &Daemon{scheduler: NewScheduler()}
+	factory := func(appID int, appHash string, sessionFile string, conn client.ConnectOptions, log zerolog.Logger) (taskClient, error) {
+		return client.NewClient(appID, appHash, sessionFile, conn, log)
+	}
+	if err := startTasks(ctx, cfg, log, st, d, factory); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func startTasks(ctx context.Context, cfg *config.Config, log zerolog.Logger, st *state.State, d *Daemon, factory clientFactory) error {
+	s := d.scheduler
+	s.SetPanicHandler(func(report crash.Report) {
+		writeCrash(crash.Dir(cfg.Log.Dir), report, log)
+	})
+	hasAnyScheduled := false
+	store := openHistory(cfg, log)
+	bus := events.NewBus()
+	bus.Subscribe(diag.CountEvent)
+	if store != nil {
+		bus.Subscribe(recordHistory(store, log))
+	}
+
+	notifier, err := notify.FromConfig(cfg.Notifications, cfg.Proxy)
+	if err != nil {
+		return fmt.Errorf("invalid notification config: %w", err)
+	}
+	bus.Subscribe(notifyEvents(ctx, notifier, log))
+	if st != nil {
+		var alert func(notify.Message)
+		if cfg.Streaks.Deadline != "" {
+			alert = func(msg notify.Message) { go notifySession(ctx, notifier, log, msg) }
+		}
+		bus.Subscribe(trackStreaks(st, streakMinDays(cfg.Streaks), log, alert))
+	}
+	if cfg.RemoteLogin && cfg.Control.Listen == "" {
+		log.Warn().Msg(i18n.T("remote_login_no_control"))
+	}
+
+	if cfg.Digest.Schedule != "" {
+		switch {
+		case len(notifier) == 0:
+			log.Warn().Msg(i18n.T("digest_no_channels"))
+		case store == nil:
+			log.Warn().Msg(i18n.T("digest_no_history"))
+		default:
+			if err := s.AddTask(cfg.Digest.Schedule, func() { sendDigest(ctx, store, notifier, log) }); err != nil {
+				return fmt.Errorf("invalid digest schedule %q: %w", cfg.Digest.Schedule, err)
+			}
+			hasAnyScheduled = true
+			log.Debug().Str("schedule", cfg.Digest.Schedule).Msg(i18n.T("digest_scheduled"))
+		}
+	}
+
+	if cfg.Streaks.Deadline != "" {
+		switch {
+		case len(notifier) == 0:
+			log.Warn().Msg(i18n.T("streaks_no_channels"))
+		case st == nil:
+			log.Warn().Msg(i18n.T("streaks_no_state"))
+		default:
+			// Prepare validated the deadline
+			spec, _ := cfg.Streaks.DeadlineCron()
+			if err := s.AddTask(spec, func() { checkStreaks(ctx, cfg, st, notifier, log) }); err != nil {
+				return fmt.Errorf("invalid streaks deadline %q: %w", cfg.Streaks.Deadline, err)
+			}
+			hasAnyScheduled = true
+			log.Debug().Str("deadline", cfg.Streaks.Deadline).Msg(i18n.T("streaks_scheduled"))
+		}
+	}
+
+	for i, acc := range cfg.Accounts {
+		sessionName := acc.SessionName()
+		stagger := StaggerOffset(cfg, i)
+
+		// Session file name
+		sessionFile := sessionName + ".session"
+
+		accountLabel := formatAccountLabel(acc, sessionName)
+		accLog := log.With().Str("account", accountLabel).Str("session", sessionName).Logger()
+
+		// Disabled tasks count too: the account connects and schedules them, so enabling one
+		// at runtime takes effect without a restart. Toggles are checked when a task fires.
+		hasImmediateTasks := false
+		hasScheduledTasks := false
+		for _, task := range acc.Tasks {
+			if task.RunOnStart {
+				hasImmediateTasks = true
+			}
+			if task.Schedule != "" || task.RunAt != "" {
+				hasScheduledTasks = true
+			}
+		}
+
+		if !hasImmediateTasks && !hasScheduledTasks {
+			accLog.Info().Msg(i18n.T("no_runnable_tasks"))
+			continue
+		}
+
+		appID, appHash, err := resolveAppConfig(cfg, acc)
+		if err != nil {
+			accLog.Error().Err(err).Msg(i18n.T("account_config_incomplete"))
+			continue
+		}
+
+		conn := connectOptions(cfg, acc)
+		if cfg.RemoteLogin {
+			conn.CodePrompt = remoteCodePrompt(acc.Key(), accountLabel, notifier, accLog)
+		}
+		conn.OnMigrate = notifyMigrate(ctx, notifier, accountLabel, accLog)
+
+		// On-demand accounts connect only while their tasks run
+		if resolveConnectionMode(cfg, acc) == ConnectionOnDemand {
+			if acc.SessionWatchMinutes > 0 {
+				accLog.Warn().Msg(i18n.T("session_watch_on_demand"))
+			}
+			run := &onDemandRunner{
+				cfg:          cfg,
+				acc:          acc,
+				log:          accLog,
+				accountLabel: accountLabel,
+				bus:          bus,
+				state:        st,
+				notifier:     notifier,
+				stagger:      stagger,
+				runs:         &d.runs,
+				connect: func() (taskClient, error) {
+					return factory(appID, appHash, sessionFile, conn, accLog)
+				},
+			}
+			if err := run.schedule(ctx, s, hasImmediateTasks); err != nil {
+				return err
+			}
+			if hasScheduledTasks {
+				hasAnyScheduled = true
+			}
+			continue
+		}
+
+		// Start long-running client.Run() session, restarted after a dropped connection.
+		// Scheduled jobs are added once and submit to the executor of the current session.
+		startSession := func() bool {
+			client, err := factory(appID, appHash, sessionFile, conn, accLog)
+			if err != nil {
+				accLog.Error().Err(err).Msg(i18n.T("client_creation_failed"))
+				return false
+			}
+			var current atomic.Pointer[executor.TaskExecutor]
+			firstSession := true
+			d.sessions.Add(1)
+			go runSession(ctx, client, accountLabel, crash.Dir(cfg.Log.Dir), accLog, notifier, d.sessions.Done, func(ctx context.Context) error {
+				// Login authentication
+				if err := client.AuthInRun(ctx, acc.Phone, acc.Password); err != nil {
+					accLog.Error().Err(err).Msg(i18n.T("auth_failed"))
+					// A session that needs an interactive login will not recover by retrying
+					if publishAuthRequired(bus, accountLabel, err) {
+						return backoff.Permanent(err)
+					}
+					return err
+				}
+
+				// Create task executor
+				exec := newAccountExecutor(client, cfg, acc, accLog, accountLabel, bus)
+				exec.SetDependents(acc.Tasks, func(t config.TaskConfig) bool { return state.TaskActive(st, acc, t) })
+				exec.SetSendQuota(sendQuota(cfg, st, accountLabel, accLog))
+				exec.SetFinishHandler(func(req executor.TaskRequest) {
+					if req.TriggerType == "scheduled" {
+						disableOneShot(st, acc, req.Task, accLog)
+					}
+				})
+				exec.SetDropHandler(func(req executor.TaskRequest) {
+					warnOneShotLost(req.Task, req.TriggerType, "dropped from the full task queue", accLog)
+					if len(notifier) == 0 {
+						return
+					}
+					msg := notify.Message{
+						Title:    i18n.T("task_dropped_title"),
+						Text:     i18n.TN("task_dropped_text", int(exec.Dropped()), map[string]any{"Account": accountLabel, "Task": req.Task.DisplayName()}),
+						Priority: notify.PriorityHigh,
+					}
+					go func() {
+						if err := notifier.Notify(ctx, msg); err != nil {
+							accLog.Warn().Err(err).Msg(i18n.T("drop_notify_failed"))
+						}
+					}()
+				})
+				exec.SetCrashHandler(notifyCrash(ctx, notifier, accountLabel, accLog))
+				exec.Start(ctx)
+				current.Store(exec)
+				defer func() {
+					current.Store(nil)
+					exec.Stop()
+				}()
+
+				applyPresence(ctx, client, resolvePresence(cfg, acc), accLog)
+				if acc.SessionWatchMinutes > 0 {
+					go watchSessions(ctx, client, st, acc.Key(), accountLabel, time.Duration(acc.SessionWatchMinutes)*time.Minute, notifier, accLog)
+				}
+
+				if !firstSession {
+					accLog.Info().Msg(i18n.T("session_reestablished"))
+					<-ctx.Done()
+					return nil
+				}
+				firstSession = false
+
+				// Execute run_on_start tasks
+				if hasImmediateTasks {
+					for _, task := range acc.Tasks {
+						if state.TaskActive(st, acc, task) && task.RunOnStart && task.IsRoot(acc.Tasks) {
+							exec.SubmitTask(task, accLog, "run_on_start")
+						}
+					}
+				}
+
+				// Add scheduled tasks to scheduler
+				if hasScheduledTasks {
+					// Disabled tasks are scheduled too, so they can be enabled at runtime
+					for _, task := range acc.Tasks {
+						if task.Schedule == "" && task.RunAt == "" {
+							continue
+						}
+						if !task.IsRoot(acc.Tasks) {
+							accLog.Warn().Str("task", task.DisplayName()).Msg(i18n.T("depends_on_schedule_ignored"))
+							continue
+						}
+
+						t := task // copy
+						taskName := t.Name
+						if taskName == "" {
+							taskName = t.Target
+						}
+
+						err := addTaskSchedule(s, t, stagger, func() {
+							select {
+							case <-ctx.Done():
+								return
+							default:
+							}
+							if !state.TaskActive(st, acc, t) {
+								accLog.Debug().Str("task", taskName).Msg(i18n.T("task_disabled_skipped"))
+								return
+							}
+							if skipScheduled(t, accLog) {
+								return
+							}
+							exec := current.Load()
+							if exec == nil {
+								accLog.Warn().Str("task", taskName).Msg(i18n.T("session_reconnecting_skipped"))
+								warnOneShotLost(t, "scheduled", "fired while the session was reconnecting", accLog)
+								return
+							}
+							// Submit to executor queue, a one-time task is disabled once it ran
+							exec.SubmitTask(t, accLog, "scheduled")
+						})
+
+						if err != nil {
+							accLog.Error().Err(err).Str("schedule", t.Schedule).Msg(i18n.T("task_add_failed"))
+							return backoff.Permanent(err)
+						} else {
+							accLog.Debug().Str("schedule", t.Schedule).Int("offset_seconds", t.ScheduleOffset).Dur("stagger", stagger).Str("task", taskName).Str("target", t.Target).Msg(i18n.T("task_scheduled"))
+						}
+					}
+				}
+
+				// Keep session running
+				<-ctx.Done()
+				return nil
+			})
+			return true
+		}
+
+		// A disabled account does not connect until it is enabled at runtime
+		if !state.AccountActive(st, acc) {
+			accLog.Info().Msg(i18n.T("account_disabled_deferred"))
+			d.sessions.Add(1)
+			go func() {
+				defer d.sessions.Done()
+				if waitAccountEnabled(ctx, st, acc) {
+					accLog.Info().Msg(i18n.T("account_enabled_connecting"))
+					startSession()
+				}
+			}()
+		} else if !startSession() {
+			continue
+		}
+
+		if hasScheduledTasks {
+			hasAnyScheduled = true
+		}
+	}
+
+	if !hasAnyScheduled {
+		log.Info().Msg(i18n.T("no_scheduled_tasks"))
+		return nil
+	}
+
+	s.Start()
+	log.Info().Msg(i18n.T("scheduler_started"))
+	return nil
+}
+
+// waitAccountEnabled blocks until the account is enabled, false when ctx is cancelled first
+func waitAccountEnabled(ctx context.Context, st *state.State, acc config.AccountConfig) bool {
+	for {
+		changed := st.AccountChanged()
+		if state.AccountActive(st, acc) {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-changed:
+		}
+	}
+}
+
+// runSession supervises an account session: when client.Run ends with an error (or panics)
+// before ctx is cancelled it is started again with exponential backoff. fn can stop the
+// retries by returning a backoff.Permanent error. Drops and a stopped session are sent to
+// the notification channels, a panic is also written to a crash file in crashDir. done is
+// called once the session stopped for good.
+func runSession(ctx context.Context, client taskClient, accountLabel, crashDir string, log zerolog.Logger, notifier notify.Multi, done func(), fn func(ctx context.Context) error) {
+	defer done()
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = 5 * time.Second
+	b.MaxInterval = 5 * time.Minute
+	b.MaxElapsedTime = 0
+
+	healthy := true
+	err := backoff.RetryNotify(func() error {
+		startedAt := time.Now()
+		err := runGuarded(ctx, client, accountLabel, fn)
+		var report crash.Report
+		if errors.As(err, &report) {
+			writeCrash(crashDir, report, log)
+			notifyCrash(ctx, notifier, accountLabel, log)(report)
+		}
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		// A session that stayed up for a while starts over with short delays
+		if time.Since(startedAt) > b.MaxInterval {
+			b.Reset()
+			healthy = true
+		}
+		return err
+	}, backoff.WithContext(b, ctx), func(err error, wait time.Duration) {
+		log.Warn().Err(err).Dur("retry_in", wait).Msg(i18n.T("session_reconnecting"))
+		// Notify once per outage rather than on every attempt
+		if healthy {
+			healthy = false
+			notifySession(ctx, notifier, log, notify.Message{
+				Title: i18n.T("session_dropped_title"),
+				Text:  i18n.T("session_dropped_text", map[string]any{"Account": accountLabel, "Error": err}),
+			})
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Error().Err(err).Msg(i18n.T("session_stopped"))
+		// The AuthRequired event was already sent to the notification channels
+		if errors.Is(errs.Classify(err), errs.ErrAuthRequired) {
+			return
+		}
+		notifySession(ctx, notifier, log, notify.Message{
+			Title:    i18n.T("session_stopped_title"),
+			Text:     i18n.T("session_stopped_text", map[string]any{"Account": accountLabel, "Error": err}),
+			Priority: notify.PriorityHigh,
+		})
+	}
+}
+
+// runGuarded runs the session, turning a panic in fn into a crash.Report error so the supervisor can restart it
+func runGuarded(ctx context.Context, client taskClient, accountLabel string, fn func(ctx context.Context) error) error {
+	return client.Run(ctx, func(ctx context.Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = crash.Capture("session", accountLabel, "", r)
+			}
+		}()
+		return fn(ctx)
+	})
+}
+
+// writeCrash saves the stack trace of a recovered panic to a crash file
+func writeCrash(dir string, report crash.Report, log zerolog.Logger) {
+	path, err := crash.Write(dir, report)
+	if err != nil {
+		log.Error().Err(err).Msg(i18n.T("crash_write_failed"))
+		return
+	}
+	log.Error().Str("crash_file", path).Interface("panic", report.Value).Msg(i18n.T("panic_recovered"))
+}
+
+// notifyCrash returns a crash handler sending recovered panics of an account to the notification channels
+func notifyCrash(ctx context.Context, notifier notify.Multi, accountLabel string, log zerolog.Logger) func(crash.Report) {
+	return func(report crash.Report) {
+		msg := notify.Message{
+			Title:    i18n.T("crash_title"),
+			Text:     i18n.T("crash_text", map[string]any{"Account": accountLabel, "Error": report}),
+			Priority: notify.PriorityHigh,
+		}
+		go notifySession(ctx, notifier, log, msg)
+	}
+}
+
+// notifyMigrate returns a client.MigrateHandler sending a DC migration to the notification channels
+func notifyMigrate(ctx context.Context, notifier notify.Multi, accountLabel string, log zerolog.Logger) client.MigrateHandler {
+	return func(fromDC, toDC int) {
+		msg := notify.Message{
+			Title: i18n.T("dc_migrated_title"),
+			Text:  i18n.T("dc_migrated_text", map[string]any{"Account": accountLabel, "From": fromDC, "To": toDC}),
+		}
+		go notifySession(ctx, notifier, log, msg)
+	}
+}
+
+// notifySession sends a session status message, if any notification channels are configured
+func notifySession(ctx context.Context, notifier notify.Multi, log zerolog.Logger, msg notify.Message) {
+	if len(notifier) == 0 {
+		return
+	}
+	if err := notifier.Notify(ctx, msg); err != nil {
+		log.Warn().Err(err).Msg(i18n.T("session_notify_failed"))
+	}
+}
+
+// sendDigest sends the summary of today's runs to the notification channels
+func sendDigest(ctx context.Context, store *history.Store, notifier notify.Notifier, log zerolog.Logger) {
+	records, err := store.Load()
+	if err != nil {
+		log.Error().Err(err).Msg(i18n.T("digest_history_failed"))
+		return
+	}
+	msg := digest.Build(records, digest.StartOfDay(time.Now()))
+	if err := notifier.Notify(ctx, msg); err != nil {
+		log.Error().Err(err).Msg(i18n.T("digest_send_failed"))
+		return
+	}
+	log.Info().Msg(i18n.T("digest_sent"))
+}
+
+// newAccountExecutor creates the task executor for an account from its worker settings,
+// publishing its task events to bus
+func newAccountExecutor(c taskClient, cfg *config.Config, acc config.AccountConfig, accLog zerolog.Logger, accountLabel string, bus *events.Bus) *executor.TaskExecutor {
+	workerCount := acc.WorkerCount
+	// Sequential accounts run one task at a time, regardless of worker_count
+	if acc.Sequential {
+		workerCount = 1
+	}
+	exec := executor.NewTaskExecutor(c, workerCount, acc.TaskQueueSize, accLog, cfg.Log.Dir, cfg.Log.Format, accountLabel)
+	if acc.Sequential && acc.InterTaskDelaySeconds > 0 {
+		exec.SetInterTaskDelay(time.Duration(acc.InterTaskDelaySeconds) * time.Second)
+	}
+	exec.SetArtifactsDir(cfg.ArtifactsDir)
+	exec.SetReplyOptions(client.ReplyOptions{WaitSeconds: acc.ReplyWaitSeconds, HistoryLimit: acc.ReplyHistoryLimit, WindowSeconds: acc.ReplyWindowSeconds})
+	exec.SetPresence(resolvePresence(cfg, acc))
+	switch acc.QueueOverflow {
+	case "", executor.OverflowDrop, executor.OverflowBlock, executor.OverflowDropOldest, executor.OverflowExpand:
+	default:
+		accLog.Warn().Str("queue_overflow", acc.QueueOverflow).Msg(i18n.T("overflow_policy_unknown"))
+	}
+	exec.SetOverflowPolicy(acc.QueueOverflow, time.Duration(acc.QueueBlockSeconds)*time.Second)
+	exec.SetEventBus(bus)
+	return exec
+}
+
+// openHistory opens the configured run history store, history is skipped if it cannot be opened
+func openHistory(cfg *config.Config, log zerolog.Logger) *history.Store {
+	store, err := history.Open(cfg.HistoryFile)
+	if err != nil {
+		log.Warn().Err(err).Msg(i18n.T("history_disabled"))
+		return nil
+	}
+	return store
+}
+
+// historyRecord converts a task result into a run history record
+func historyRecord(res executor.TaskResult) history.Record {
+	rec := history.Record{
+		Time:       res.StartedAt,
+		Account:    res.Account,
+		Task:       res.Task,
+		Target:     res.Target,
+		Method:     res.Method,
+		Trigger:    res.Trigger,
+		RequestID:  res.RequestID,
+		Success:    res.Success(),
+		ErrorClass: res.ErrorClass,
+		DurationMs: res.Duration.Milliseconds(),
+		Reply:      res.Reply,
+		Artifacts:  res.Artifacts,
+		Extracted:  res.Extracted,
+		Transcript: res.Transcript,
+	}
+	if res.Err != nil {
+		rec.Error = res.Err.Error()
+	}
+	return rec
+}
+
+func resolveAppConfig(cfg *config.Config, acc config.AccountConfig) (int, string, error) {
+	appID := acc.AppID
+	appHash := acc.AppHash
+	if appID == 0 {
+		appID = cfg.AppID
+	}
+	if appHash == "" {
+		appHash = cfg.AppHash
+	}
+	if appID == 0 && appHash == "" && cfg.UseTestDC {
+		return client.TestAppID, client.TestAppHash, nil
+	}
+	if appID == 0 || appHash == "" {
+		return 0, "", fmt.Errorf("missing app_id or app_hash")
+	}
+	return appID, appHash, nil
+}
+
+// connectOptions returns how the client of an account reaches Telegram
+func connectOptions(cfg *config.Config, acc config.AccountConfig) client.ConnectOptions {
+	return client.ConnectOptions{
+		ProxyAddr:      cfg.Proxy,
+		ProxyFallbacks: cfg.ProxyFallbacks,
+		FallbackDirect: cfg.FallbackDirect,
+		Device:         client.DeviceOptions(cfg.DeviceFor(acc)),
+		TestDC:         cfg.UseTestDC,
+		NonInteractive: cfg.NonInteractive,
+		LoginCode:      client.LoginCodeOptions(cfg.LoginCodeFor(acc)),
+		Retry:          client.RetryOptions(acc.Retry),
+		ClockOffset:    currentClockOffset,
+		Network:        client.NetworkOptions(cfg.Network),
+		DebugMTProto:   cfg.Log.MTProto,
+	}
+}
+
+// clockOffset corrects the MTProto time of every client, see SetClockOffset
+var clockOffset atomic.Int64
+
+// SetClockOffset makes clients add offset to the local time in MTProto messages, to compensate
+// for a skewed system clock. Clients already running pick it up with their next message. The
+// clock is shared by the process, so the offset is too.
+func SetClockOffset(offset time.Duration) {
+	clockOffset.Store(int64(offset))
+}
+
+// currentClockOffset returns the offset set by SetClockOffset, as read by the clients
+func currentClockOffset() time.Duration {
+	return time.Duration(clockOffset.Load())
+}
+
+// loginCodeTimeout is how long a remote login waits for the code before the attempt fails and is retried
+const loginCodeTimeout = 10 * time.Minute
+
+// remoteCodePrompt asks for a login code through the notification channels and waits for it to be
+// submitted to the control API under the account key
+func remoteCodePrompt(key, accountLabel string, notifier notify.Multi, log zerolog.Logger) client.CodePrompt {
+	return func(ctx context.Context, phone string) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, loginCodeTimeout)
+		defer cancel()
+
+		log.Warn().Str("account", key).Msg(i18n.T("login_code_requested"))
+		notifySession(ctx, notifier, log, notify.Message{
+			Title:    i18n.T("login_code_title"),
+			Text:     i18n.T("login_code_text", map[string]any{"Account": accountLabel, "Phone": phone, "Key": key, "Minutes": int(loginCodeTimeout.Minutes())}),
+			Priority: notify.PriorityHigh,
+		})
+		code, err := login.RequestCode(ctx, key)
+		if err != nil {
+			return "", fmt.Errorf("no login code submitted for %s: %w", key, err)
+		}
+		log.Info().Str("account", key).Msg(i18n.T("login_code_received"))
+		return code, nil
+	}
+}
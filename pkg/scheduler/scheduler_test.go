@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/errs"
+	"telegram-auto-checkin/internal/fake"
+	"telegram-auto-checkin/internal/state"
+	"telegram-auto-checkin/pkg/client"
+	"telegram-auto-checkin/pkg/executor"
+)
+
+// fakeFactory connects every account to server
+func fakeFactory(server *fake.Server) clientFactory {
+	return func(appID int, appHash string, sessionName string, conn client.ConnectOptions, log zerolog.Logger) (taskClient, error) {
+		return client.NewClientWithInvoker(server, conn.Retry, log), nil
+	}
+}
+
+// testConfig returns a config with one account running tasks, keeping every file in a temporary directory
+func testConfig(t *testing.T, tasks ...config.TaskConfig) *config.Config {
+	dir := t.TempDir()
+	return &config.Config{
+		AppID:        1,
+		AppHash:      "hash",
+		Log:          config.LogConfig{Dir: filepath.Join(dir, "log")},
+		HistoryFile:  filepath.Join(dir, "history.jsonl"),
+		ArtifactsDir: filepath.Join(dir, "artifacts"),
+		Accounts: []config.AccountConfig{{
+			Name:             "alice",
+			Phone:            "+10000000000",
+			WorkerCount:      1,
+			ReplyWaitSeconds: 1,
+			Tasks:            tasks,
+		}},
+	}
+}
+
+// runOnce runs the tasks of cfg once against server and returns their results by task name
+func runOnce(t *testing.T, cfg *config.Config, server *fake.Server) (map[string]executor.TaskResult, error) {
+	t.Helper()
+	var mu sync.Mutex
+	results := make(map[string]executor.TaskResult)
+	err := runTasksOnce(context.Background(), cfg, zerolog.Nop(), fakeFactory(server), OnceOptions{
+		OnResult: func(res executor.TaskResult) {
+			mu.Lock()
+			results[res.Task] = res
+			mu.Unlock()
+		},
+	})
+	return results, err
+}
+
+// pointsBot answers every message with the earned points and a keyboard of a callback
+// button and a URL button opening url
+func pointsBot(url string) *fake.Bot {
+	return &fake.Bot{
+		Username: "pointsbot",
+		OnMessage: func(text string) (fake.Reply, bool) {
+			return fake.Reply{
+				Text: "Check-in done, +15 points",
+				Buttons: []fake.Button{
+					{Text: "Claim", OnClick: func() fake.Reply { return fake.Reply{Text: "Claimed +5 points"} }},
+					{Text: "Shop", URL: url},
+				},
+			}, true
+		},
+	}
+}
+
+func TestRunTasksOnce(t *testing.T) {
+	var opened atomic.Int32
+	shop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opened.Add(1)
+	}))
+	defer shop.Close()
+
+	server := fake.NewServer(pointsBot(shop.URL))
+	server.FailNext(errs.ErrNetwork)
+	cfg := testConfig(t,
+		config.TaskConfig{Name: "checkin", Target: "@pointsbot", Method: "message", Payload: "/checkin", SuccessKeywords: []string{"done"}, ReplyExtract: []string{`\+(?P<points>\d+) points`}},
+		config.TaskConfig{Name: "claim", Target: "@pointsbot", Method: "button", Payload: "Claim", DependsOn: []config.DependencyConfig{{Task: "checkin"}}},
+		config.TaskConfig{Name: "shop", Target: "@pointsbot", Method: "button", Payload: "Shop", OtherButton: "open", DependsOn: []config.DependencyConfig{{Task: "claim"}}},
+	)
+
+	results, err := runOnce(t, cfg, server)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got results of %d tasks, want 3", len(results))
+	}
+	if sent := server.Sent("pointsbot"); len(sent) != 1 || sent[0] != "/checkin" {
+		t.Errorf("sent %q, want the check-in sent once after the retry", sent)
+	}
+	if got := results["checkin"].Extracted["points"]; got != 15 {
+		t.Errorf("got %v points from the check-in reply, want 15", got)
+	}
+	if got := results["claim"].Reply; got != "Claimed +5 points" {
+		t.Errorf("got claim reply %q, want the callback answer", got)
+	}
+	if got := opened.Load(); got != 1 {
+		t.Errorf("shop URL opened %d times, want once", got)
+	}
+}
+
+func TestRunTasksOnceFailures(t *testing.T) {
+	server := fake.NewServer(pointsBot("https://example.com/shop"))
+	cfg := testConfig(t,
+		config.TaskConfig{Name: "checkin", Target: "@unknownbot", Method: "message", Payload: "/checkin"},
+		config.TaskConfig{Name: "keyword", Target: "@pointsbot", Method: "message", Payload: "/checkin", SuccessKeywords: []string{"already"}},
+		config.TaskConfig{Name: "shop", Target: "@pointsbot", Method: "button", Payload: "Shop"},
+	)
+
+	results, err := runOnce(t, cfg, server)
+	if !errors.Is(err, ErrTasksFailed) {
+		t.Fatalf("got error %v, want %v", err, ErrTasksFailed)
+	}
+	if res := results["checkin"]; !errors.Is(res.Err, errs.ErrPeerNotFound) {
+		t.Errorf("got check-in error %v, want peer not found", res.Err)
+	}
+	if res := results["keyword"]; res.Success() || res.Reply != "Check-in done, +15 points" {
+		t.Errorf("got keyword task success %v with reply %q, want a failure on the bot reply", res.Success(), res.Reply)
+	}
+	if res := results["shop"]; res.Success() {
+		t.Error("URL button was pressed, want a failure without other_button")
+	}
+}
+
+func TestDisabledAccountConnectsWhenEnabled(t *testing.T) {
+	server := fake.NewServer(pointsBot("https://example.com/shop"))
+	cfg := testConfig(t, config.TaskConfig{Name: "checkin", Target: "@pointsbot", Method: "message", Payload: "/checkin", RunOnStart: true})
+	disabled := false
+	cfg.Accounts[0].Enabled = &disabled
+	st, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var connects atomic.Int32
+	factory := func(appID int, appHash string, sessionName string, conn client.ConnectOptions, log zerolog.Logger) (taskClient, error) {
+		connects.Add(1)
+		return fakeFactory(server)(appID, appHash, sessionName, conn, log)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Daemon{scheduler: NewScheduler()}
+	if err := startTasks(ctx, cfg, zerolog.Nop(), st, d, factory); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	defer func() {
+		cancel()
+		d.Wait(context.Background())
+	}()
+	if got := connects.Load(); got != 0 {
+		t.Fatalf("disabled account connected %d times, want none", got)
+	}
+
+	enabled := true
+	if err := st.SetAccount(cfg.Accounts[0].Key(), &enabled); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Sent("pointsbot")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("enabled account did not run its run_on_start task")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := connects.Load(); got != 1 {
+		t.Errorf("account connected %d times, want once", got)
+	}
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/notify"
+	"telegram-auto-checkin/internal/state"
+	"telegram-auto-checkin/pkg/client"
+)
+
+// watchSessions lists the sessions of an account every interval until ctx is done and alerts
+// about sessions that were not there before. The first check of an account only records its
+// sessions, so existing devices are not reported.
+func watchSessions(ctx context.Context, c taskClient, st *state.State, account, accountLabel string, interval time.Duration, notifier notify.Multi, log zerolog.Logger) {
+	known, checked := st.KnownSessions(account)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		auths, err := c.AuthorizationsInRun(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Warn().Err(err).Msg(i18n.T("sessions_list_failed"))
+		case err == nil:
+			known = checkSessions(ctx, auths, known, checked, accountLabel, notifier, log)
+			checked = true
+			if st != nil {
+				if err := st.SetKnownSessions(account, known); err != nil {
+					log.Warn().Err(err).Msg(i18n.T("sessions_save_failed"))
+				}
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkSessions alerts about the sessions missing from known when the account was checked
+// before, and returns the hashes of the current sessions. The session of this client is skipped.
+func checkSessions(ctx context.Context, auths []client.Authorization, known []int64, checked bool, accountLabel string, notifier notify.Multi, log zerolog.Logger) []int64 {
+	hashes := make([]int64, 0, len(auths))
+	for _, a := range auths {
+		if a.Current {
+			continue
+		}
+		hashes = append(hashes, a.Hash)
+		if !checked || slices.Contains(known, a.Hash) {
+			continue
+		}
+		log.Warn().Str("device", a.Device).Str("platform", a.Platform).Str("app", a.App).Str("ip", a.IP).Str("location", a.Location).
+			Time("created", a.Created).Msg(i18n.T("new_session_detected"))
+		notifySession(ctx, notifier, log, notify.Message{
+			Title: i18n.T("new_session_title"),
+			Text: i18n.T("new_session_text", map[string]any{
+				"Account":  accountLabel,
+				"Device":   a.Device,
+				"Platform": a.Platform,
+				"App":      a.App,
+				"IP":       a.IP,
+				"Location": a.Location,
+				"Created":  a.Created.Format("2006-01-02 15:04"),
+			}),
+			Priority: notify.PriorityHigh,
+		})
+	}
+	if !checked {
+		log.Info().Int("sessions", len(hashes)).Msg(i18n.T("sessions_recorded"))
+	}
+	return hashes
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/digest"
+	"telegram-auto-checkin/internal/events"
+	"telegram-auto-checkin/internal/i18n"
+	"telegram-auto-checkin/internal/notify"
+	"telegram-auto-checkin/internal/state"
+)
+
+// defaultStreakMinDays is the shortest streak alerted about when streaks.min_days is unset
+const defaultStreakMinDays = 2
+
+func streakMinDays(cfg config.StreakConfig) int {
+	if cfg.MinDays > 0 {
+		return cfg.MinDays
+	}
+	return defaultStreakMinDays
+}
+
+// trackStreaks returns a subscriber extending the daily streak of every task that succeeds.
+// alert, when not nil, receives the message about a streak that turned out broken.
+func trackStreaks(st *state.State, minDays int, log zerolog.Logger, alert func(notify.Message)) func(events.Event) {
+	return func(ev events.Event) {
+		res, ok := ev.(events.TaskFinished)
+		if !ok || !res.Success() {
+			return
+		}
+		streak, broken, err := st.RecordSuccess(res.Account, res.Task, res.StartedAt)
+		if err != nil {
+			log.Warn().Err(err).Str("account", res.Account).Msg(i18n.T("streak_save_failed"))
+			return
+		}
+		log.Debug().Str("account", res.Account).Str("task", res.Task).Int("streak_days", streak.Days).Msg(i18n.T("streak_updated"))
+		if broken >= minDays && alert != nil {
+			alert(streakBrokenMessage(res.Account, res.Task, broken))
+		}
+	}
+}
+
+// checkStreaks alerts about the streaks without a success today at the deadline. Streaks that
+// missed a whole day are reported as broken and reset, so they are reported once.
+func checkStreaks(ctx context.Context, cfg *config.Config, st *state.State, notifier notify.Multi, log zerolog.Logger) {
+	minDays := streakMinDays(cfg.Streaks)
+	today := digest.StartOfDay(time.Now())
+	for _, acc := range cfg.Accounts {
+		accountLabel := formatAccountLabel(acc, acc.SessionName())
+		for _, task := range acc.Tasks {
+			if !state.TaskActive(st, acc, task) {
+				continue
+			}
+			name := task.DisplayName()
+			streak := st.Streak(accountLabel, name)
+			if streak.Days < minDays {
+				continue
+			}
+			var msg notify.Message
+			switch last := streak.LastDay(); {
+			case !last.Before(today):
+				continue
+			case last.Equal(today.AddDate(0, 0, -1)):
+				msg = notify.Message{
+					Title:    i18n.T("streak_at_risk_title"),
+					Text:     i18n.T("streak_at_risk_text", map[string]any{"Account": accountLabel, "Task": name, "Days": streak.Days}),
+					Priority: notify.PriorityHigh,
+				}
+			default:
+				if err := st.ResetStreak(accountLabel, name); err != nil {
+					log.Warn().Err(err).Str("account", accountLabel).Msg(i18n.T("streak_save_failed"))
+				}
+				msg = streakBrokenMessage(accountLabel, name, streak.Days)
+			}
+			log.Warn().Str("account", accountLabel).Str("task", name).Int("streak_days", streak.Days).Msg(msg.Title)
+			notifySession(ctx, notifier, log, msg)
+		}
+	}
+}
+
+func streakBrokenMessage(account, task string, days int) notify.Message {
+	return notify.Message{
+		Title:    i18n.T("streak_broken_title"),
+		Text:     i18n.T("streak_broken_text", map[string]any{"Account": account, "Task": task, "Days": days}),
+		Priority: notify.PriorityHigh,
+	}
+}
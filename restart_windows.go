@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "errors"
+
+// restartSelf cannot replace a running process on Windows, the daemon exits instead and the
+// recovery actions set by service install start it again
+func restartSelf() error {
+	return errors.New("restarting in place is not supported on Windows")
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/state"
+	"telegram-auto-checkin/pkg/scheduler"
+)
+
+// runScheduleCommand lists the scheduled tasks with their next fire times and returns the exit code
+func runScheduleCommand(args []string) int {
+	fs := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	cfgPath := fs.String("config", "config.yaml", "Path to main config file")
+	count := fs.Int("count", 3, "Number of upcoming runs to show per task")
+	all := fs.Bool("all", false, "Include disabled tasks")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if err := runSchedule(*cfgPath, *count, *all); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func runSchedule(cfgPath string, count int, all bool) error {
+	cfg, err := config.LoadConfig(cfgPath, viper.New())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	st, err := state.Open(cfg.StateFile)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACCOUNT\tTASK\tSCHEDULE\tTIMEZONE\tNEXT RUNS")
+	for i, acc := range cfg.Accounts {
+		name := acc.Name
+		if name == "" {
+			name = acc.SessionName()
+		}
+		stagger := scheduler.StaggerOffset(cfg, i)
+		for _, task := range acc.Tasks {
+			if (task.Schedule == "" && task.RunAt == "") || !task.IsRoot(acc.Tasks) {
+				continue
+			}
+			active := state.TaskActive(st, acc, task)
+			if !active && !all {
+				continue
+			}
+
+			spec := describeSchedule(task, stagger)
+			if !active {
+				spec += " (disabled)"
+			}
+			sched, err := scheduler.TaskSchedule(task, stagger)
+			if err != nil {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t-\tinvalid: %v\n", name, task.DisplayName(), spec, err)
+				continue
+			}
+
+			var runs []string
+			zone := "-"
+			for next := sched.Next(now); !next.IsZero() && len(runs) < count; next = sched.Next(next) {
+				run := next.Format("2006-01-02 15:04:05")
+				if skip, rule, _ := task.Skip.Skips(next); skip {
+					run += " (skipped: " + rule + ")"
+				}
+				runs = append(runs, run)
+				zone = zoneName(next)
+			}
+			if len(runs) == 0 {
+				runs = append(runs, "never")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, task.DisplayName(), spec, zone, strings.Join(runs, ", "))
+		}
+	}
+	return tw.Flush()
+}
+
+// describeSchedule shows the configured schedule of a task together with its shifts
+func describeSchedule(task config.TaskConfig, stagger time.Duration) string {
+	if task.RunAt != "" {
+		return "run_at " + task.RunAt
+	}
+	spec := task.Schedule
+	offset := stagger + time.Duration(task.ScheduleOffset)*time.Second
+	switch {
+	case offset > 0:
+		spec += " +" + offset.String()
+	case offset < 0:
+		spec += " " + offset.String()
+	}
+	return spec
+}
+
+// zoneName names the time zone of t, the TZ variable or zone abbreviation for local times
+func zoneName(t time.Time) string {
+	if name := t.Location().String(); name != "Local" {
+		return name
+	}
+	abbrev, _ := t.Zone()
+	if tz := os.Getenv("TZ"); tz != "" {
+		return tz + " (" + abbrev + ")"
+	}
+	return abbrev + " (" + t.Format("-07:00") + ")"
+}
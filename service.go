@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kardianos/service"
+)
+
+const serviceUsage = `Usage: telegram-auto-checkin service <command> [options]
+
+Commands:
+  install     Register the daemon with the system service manager, started at boot
+  uninstall   Stop and remove the service
+  start       Start the installed service
+  stop        Stop the running service
+
+Supported service managers: systemd, upstart, SysV init and OpenRC (Linux), launchd (macOS),
+the Windows service manager, and rc.d (FreeBSD)
+`
+
+// defaultServiceName is the systemd unit / Windows service name
+const defaultServiceName = "telegram-auto-checkin"
+
+// serviceRestartDelay is how long the service manager waits before restarting a crashed daemon.
+// Windows applies it, the systemd unit of kardianos/service always waits 120 seconds
+const serviceRestartDelay = "10s"
+
+// serviceResetPeriod is after how many seconds without failures Windows resets the restart count
+const serviceResetPeriod = 24 * 60 * 60
+
+// serviceOptions describe the service to manage
+type serviceOptions struct {
+	Name       string // Unit or service name
+	ConfigPath string // Absolute path of the config file passed to the daemon
+	WorkDir    string // Working directory of the daemon, relative paths in the config resolve against it
+	User       bool   // systemd and launchd: install a per-user service instead of a system one
+}
+
+// serviceConfig describes the daemon to the service manager
+func serviceConfig(opts serviceOptions) *service.Config {
+	cfg := &service.Config{
+		Name:             opts.Name,
+		DisplayName:      "Telegram auto check-in",
+		Description:      "Runs scheduled Telegram check-in tasks",
+		Arguments:        []string{"--workdir", opts.WorkDir, "--config", opts.ConfigPath},
+		WorkingDirectory: opts.WorkDir,
+		Option: service.KeyValue{
+			"UserService":            opts.User,
+			"Restart":                "on-failure",
+			"OnFailure":              "restart",
+			"OnFailureDelayDuration": serviceRestartDelay,
+			"OnFailureResetPeriod":   serviceResetPeriod,
+		},
+	}
+	// Installed through sudo, the daemon runs as the invoking user and uses their session files
+	if !opts.User {
+		cfg.UserName = os.Getenv("SUDO_USER")
+	}
+	return cfg
+}
+
+// runServiceCommand dispatches "service" subcommands and returns the exit code
+func runServiceCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, serviceUsage)
+		return exitError
+	}
+
+	fs := flag.NewFlagSet("service "+args[0], flag.ContinueOnError)
+	name := fs.String("name", defaultServiceName, "Service name")
+	cfgPath := fs.String("config", "config.yaml", "Path to main config file used by the service (install)")
+	workDir := fs.String("workdir", "", "Working directory of the service (install), default: current directory")
+	user := fs.Bool("user", false, "Manage a systemd user unit or launchd user agent instead of a system service")
+
+	switch args[0] {
+	case "install", "uninstall", "start", "stop":
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stdout, serviceUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown service command %q\n\n%s", args[0], serviceUsage)
+		return exitError
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitError
+	}
+
+	opts := serviceOptions{Name: *name, User: *user}
+	var err error
+	if opts.WorkDir, err = absPath(*workDir); err == nil {
+		opts.ConfigPath, err = absPath(*cfgPath)
+	}
+	var s service.Service
+	if err == nil {
+		s, err = service.New(&serviceProgram{}, serviceConfig(opts))
+	}
+	if err == nil {
+		err = service.Control(s, args[0])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if args[0] == "install" {
+		fmt.Printf("Installed service %s (%s), start it with: telegram-auto-checkin service start\n", opts.Name, s.Platform())
+	}
+	return exitOK
+}
+
+// serviceProgram turns stop requests of the service manager into a cancelled context
+type serviceProgram struct {
+	stop context.CancelFunc
+}
+
+func (p *serviceProgram) Start(service.Service) error { return nil }
+
+func (p *serviceProgram) Stop(service.Service) error {
+	if p.stop != nil {
+		p.stop()
+	}
+	return nil
+}
+
+// serviceContext returns ctx, cancelled as well when the service manager stops the service.
+// Outside a service ctx is returned unchanged.
+func serviceContext(ctx context.Context) context.Context {
+	if service.Interactive() {
+		return ctx
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s, err := service.New(&serviceProgram{stop: cancel}, &service.Config{Name: defaultServiceName})
+	if err != nil {
+		return ctx
+	}
+	go func() {
+		defer cancel()
+		_ = s.Run()
+	}()
+	return ctx
+}
+
+// absPath resolves path against the current directory, which an empty path stands for
+func absPath(path string) (string, error) {
+	if path == "" {
+		return os.Getwd()
+	}
+	return filepath.Abs(path)
+}
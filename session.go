@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gotd/td/session"
+	"github.com/spf13/viper"
+
+	"telegram-auto-checkin/internal/config"
+	"telegram-auto-checkin/internal/sessions"
+)
+
+const sessionUsage = `Usage: telegram-auto-checkin session <command> [options]
+
+Commands:
+  import    Convert a Telethon/Pyrogram string session or a tdata directory into a session file
+  export    Package session files into a (optionally encrypted) archive for backup or migration
+  restore   Extract session files from an archive created by export
+  list      Show the session status of every configured account
+`
+
+// passphraseEnv can provide the archive passphrase instead of the command line
+const passphraseEnv = "TG_SESSION_PASSPHRASE"
+
+// runSessionCommand dispatches "session" subcommands and returns the exit code
+func runSessionCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, sessionUsage)
+		return exitError
+	}
+
+	var err error
+	switch args[0] {
+	case "import":
+		err = runSessionImport(args[1:])
+	case "export":
+		err = runSessionExport(args[1:])
+	case "restore":
+		err = runSessionRestore(args[1:])
+	case "list":
+		err = runSessionList(args[1:])
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stdout, sessionUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown session command %q\n\n%s", args[0], sessionUsage)
+		return exitError
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func runSessionImport(args []string) error {
+	fs := flag.NewFlagSet("session import", flag.ContinueOnError)
+	format := fs.String("format", sessions.FormatAuto, "Input format: auto|telethon|pyrogram|tdata")
+	input := fs.String("input", "", "String session, a file containing it, or the tdata directory; reads stdin when empty")
+	passcode := fs.String("passcode", "", "tdata local passcode, if set in Telegram Desktop")
+	tdataIndex := fs.Int("tdata-index", 0, "Account index inside tdata when it holds several accounts")
+	cfgPath := fs.String("config", "config.yaml", "Path to main config file, used to resolve --account")
+	account := fs.String("account", "", "Account name or phone from the config the session belongs to")
+	out := fs.String("out", "", "Output session file (default: derived from --account)")
+	force := fs.Bool("force", false, "Overwrite an existing session file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	target := *out
+	if target == "" {
+		if *account == "" {
+			return fmt.Errorf("either --account or --out is required")
+		}
+		acc, err := findAccount(*cfgPath, *account)
+		if err != nil {
+			return err
+		}
+		target = sessions.Path(acc.SessionName())
+	}
+
+	var (
+		data *session.Data
+		err  error
+	)
+	if *format == sessions.FormatTData {
+		if *input == "" {
+			return fmt.Errorf("--input must point to the tdata directory")
+		}
+		data, err = sessions.FromTData(*input, *passcode, *tdataIndex)
+	} else {
+		var str string
+		str, err = readSessionString(*input)
+		if err != nil {
+			return err
+		}
+		kind := *format
+		if kind == sessions.FormatAuto {
+			if kind, err = sessions.DetectFormat(str); err != nil {
+				return err
+			}
+		}
+		switch kind {
+		case sessions.FormatTelethon:
+			data, err = sessions.FromTelethon(str)
+		case sessions.FormatPyrogram:
+			data, err = sessions.FromPyrogram(str)
+		default:
+			return fmt.Errorf("unknown format %q", kind)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := sessions.Save(context.Background(), target, data, *force); err != nil {
+		return err
+	}
+	fmt.Printf("Session imported: %s (DC %d)\n", target, data.DC)
+	return nil
+}
+
+func runSessionExport(args []string) error {
+	fs := flag.NewFlagSet("session export", flag.ContinueOnError)
+	out := fs.String("out", "sessions.tar.gz", "Archive file to write")
+	passphrase := fs.String("passphrase", os.Getenv(passphraseEnv), "Encrypt the archive with this passphrase (or set "+passphraseEnv+")")
+	cfgPath := fs.String("config", "config.yaml", "Path to main config file, used to resolve --account")
+	account := fs.String("account", "", "Only export the session of this account (name or phone)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var files []string
+	if *account != "" {
+		acc, err := findAccount(*cfgPath, *account)
+		if err != nil {
+			return err
+		}
+		files = []string{sessions.Path(acc.SessionName())}
+	} else {
+		matches, err := filepath.Glob(filepath.Join(sessions.Dir, "*.session"))
+		if err != nil {
+			return err
+		}
+		files = matches
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no session files found in %s", sessions.Dir)
+	}
+
+	f, err := os.OpenFile(*out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := sessions.Export(f, files, *passphrase); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	encrypted := "no"
+	if *passphrase != "" {
+		encrypted = "yes"
+	}
+	fmt.Printf("Exported %d session(s) to %s (encrypted: %s)\n", len(files), *out, encrypted)
+	return nil
+}
+
+func runSessionRestore(args []string) error {
+	fs := flag.NewFlagSet("session restore", flag.ContinueOnError)
+	input := fs.String("input", "sessions.tar.gz", "Archive file created by session export")
+	passphrase := fs.String("passphrase", os.Getenv(passphraseEnv), "Passphrase of an encrypted archive (or set "+passphraseEnv+")")
+	force := fs.Bool("force", false, "Overwrite existing session files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	written, err := sessions.Restore(f, sessions.Dir, *passphrase, *force)
+	for _, path := range written {
+		fmt.Printf("Restored %s\n", path)
+	}
+	return err
+}
+
+func runSessionList(args []string) error {
+	fs := flag.NewFlagSet("session list", flag.ContinueOnError)
+	cfgPath := fs.String("config", "config.yaml", "Path to main config file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(*cfgPath, viper.New())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACCOUNT\tSESSION FILE\tSTATUS\tDC")
+	for _, acc := range cfg.Accounts {
+		name := acc.Name
+		if name == "" {
+			name = acc.SessionName()
+		}
+		path := sessions.Path(acc.SessionName())
+
+		status, dc := "valid", "-"
+		data, err := sessions.Load(context.Background(), path)
+		switch {
+		case errors.Is(err, session.ErrNotFound):
+			status = "missing"
+		case err != nil:
+			status = "invalid: " + err.Error()
+		case len(data.AuthKey) == 0:
+			status = "not logged in"
+		default:
+			dc = strconv.Itoa(data.DC)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, path, status, dc)
+	}
+	return tw.Flush()
+}
+
+// readSessionString reads a string session from the argument, a file, or stdin
+func readSessionString(input string) (string, error) {
+	if input == "" {
+		fmt.Fprint(os.Stderr, "Paste string session: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+	if info, err := os.Stat(input); err == nil && !info.IsDir() {
+		content, err := os.ReadFile(input)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	return strings.TrimSpace(input), nil
+}
+
+// findAccount looks up an account by name or phone in the config file
+func findAccount(cfgPath string, nameOrPhone string) (config.AccountConfig, error) {
+	cfg, err := config.LoadConfig(cfgPath, viper.New())
+	if err != nil {
+		return config.AccountConfig{}, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	for _, acc := range cfg.Accounts {
+		if acc.Name == nameOrPhone || acc.Phone == nameOrPhone {
+			return acc, nil
+		}
+	}
+	return config.AccountConfig{}, fmt.Errorf("account %q not found in %s", nameOrPhone, cfgPath)
+}